@@ -0,0 +1,164 @@
+package twapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+const defaultBatchConcurrency = 4
+
+// ErrBatchSkipped is the error of the batch items that were never sent,
+// because the batch stopped on a previous error or its context was cancelled.
+var ErrBatchSkipped = errors.New("batch item skipped")
+
+// BatchOptions configures ExecuteBatch. Zero values are replaced by the
+// defaults documented on each field.
+type BatchOptions struct {
+	// Concurrency is the maximum number of requests in flight at once. Defaults
+	// to 4.
+	Concurrency int
+
+	// StopOnError stops sending new requests as soon as one fails. Requests
+	// already in flight are completed, and the remaining ones fail with
+	// ErrBatchSkipped.
+	StopOnError bool
+
+	// OnProgress is called after every completed item, one call at a time, so it
+	// does not need to be safe for concurrent use.
+	OnProgress func(BatchProgress)
+}
+
+// BatchProgress reports the progress of ExecuteBatch after an item completed.
+type BatchProgress struct {
+	// Index is the position of the completed item in the requests.
+	Index int
+
+	// Err is the error of the completed item, if it failed.
+	Err error
+
+	// Completed is the number of items completed so far, including the failed
+	// ones.
+	Completed int
+
+	// Failed is the number of items that failed so far.
+	Failed int
+
+	// Total is the number of items in the batch.
+	Total int
+}
+
+// BatchResult is the outcome of a batch item.
+type BatchResult[T HTTPResponser] struct {
+	// Response is the handled response of the item. It is only meaningful when
+	// Err is nil.
+	Response T
+
+	// Err is the error of the item, or ErrBatchSkipped when it was never sent.
+	Err error
+}
+
+// ExecuteBatch executes the requests with Execute, sending up to
+// opts.Concurrency of them at once. The results are in the same order as the
+// requests, so partial failures can be reported item by item:
+//
+//	results, err := twapi.ExecuteBatch[projects.TaskCreateRequest, *projects.TaskCreateResponse](
+//		ctx, engine, requests, twapi.BatchOptions{Concurrency: 8},
+//	)
+//	for i, result := range results {
+//		if result.Err != nil {
+//			log.Printf("row %d: %v", i, result.Err)
+//		}
+//	}
+//
+// The returned error is nil when every item succeeded, and otherwise reports
+// how many failed, wrapping the error of the first item that was sent and
+// failed. A cancelled context stops sending new requests, like StopOnError
+// does.
+func ExecuteBatch[R HTTPRequester, T HTTPResponser](
+	ctx context.Context,
+	engine *Engine,
+	requests []R,
+	opts BatchOptions,
+) ([]BatchResult[T], error) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultBatchConcurrency
+	}
+
+	results := make([]BatchResult[T], len(requests))
+	for i := range results {
+		results[i].Err = ErrBatchSkipped
+	}
+
+	var (
+		mu        sync.Mutex
+		stopped   bool
+		completed int
+		failed    int
+		wg        sync.WaitGroup
+	)
+	indexes := make(chan int)
+	for range min(opts.Concurrency, len(requests)) {
+		wg.Go(func() {
+			for i := range indexes {
+				mu.Lock()
+				stop := stopped
+				mu.Unlock()
+				if stop {
+					continue
+				}
+
+				response, err := Execute[R, T](ctx, engine, requests[i])
+
+				mu.Lock()
+				results[i] = BatchResult[T]{Response: response, Err: err}
+				completed++
+				if err != nil {
+					failed++
+					stopped = stopped || opts.StopOnError
+				}
+				if opts.OnProgress != nil {
+					opts.OnProgress(BatchProgress{
+						Index:     i,
+						Err:       err,
+						Completed: completed,
+						Failed:    failed,
+						Total:     len(requests),
+					})
+				}
+				mu.Unlock()
+			}
+		})
+	}
+
+send:
+	for i := range requests {
+		mu.Lock()
+		stop := stopped
+		mu.Unlock()
+		if stop {
+			break
+		}
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if failures := len(requests) - completed + failed; failures > 0 {
+		index := slices.IndexFunc(results, func(result BatchResult[T]) bool {
+			return result.Err != nil && !errors.Is(result.Err, ErrBatchSkipped)
+		})
+		if index < 0 {
+			index = slices.IndexFunc(results, func(result BatchResult[T]) bool { return result.Err != nil })
+		}
+		return results, fmt.Errorf("failed to execute %d of %d requests, item %d: %w",
+			failures, len(requests), index, results[index].Err)
+	}
+	return results, nil
+}
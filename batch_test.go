@@ -0,0 +1,114 @@
+package twapi_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+)
+
+// testResponse is a minimal twapi.HTTPResponser keeping the response body.
+type testResponse struct {
+	body string
+}
+
+func (r *testResponse) HandleHTTPResponse(resp *http.Response) error {
+	if resp.StatusCode >= http.StatusBadRequest {
+		return twapi.NewHTTPError(resp, "failed to handle test response")
+	}
+	body, err := io.ReadAll(resp.Body)
+	r.body = string(body)
+	return err
+}
+
+func TestExecuteBatch(t *testing.T) {
+	requests := []testRequest{
+		{path: "/items/1"},
+		{path: "/items/2"},
+		{path: "/items/fail"},
+		{path: "/items/4"},
+		{path: "/items/5"},
+	}
+
+	tests := []struct {
+		name        string
+		opts        twapi.BatchOptions
+		wantBodies  []string
+		wantSkipped []int
+		wantFailed  int
+	}{{
+		name:       "every item",
+		opts:       twapi.BatchOptions{Concurrency: 3},
+		wantBodies: []string{"/items/1", "/items/2", "", "/items/4", "/items/5"},
+		wantFailed: 1,
+	}, {
+		name:        "stop on error",
+		opts:        twapi.BatchOptions{Concurrency: 1, StopOnError: true},
+		wantBodies:  []string{"/items/1", "/items/2", "", "", ""},
+		wantSkipped: []int{3, 4},
+		wantFailed:  1,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inFlight, maxInFlight atomic.Int64
+			engine := newTestEngine(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				current := inFlight.Add(1)
+				defer inFlight.Add(-1)
+				for {
+					peak := maxInFlight.Load()
+					if current <= peak || maxInFlight.CompareAndSwap(peak, current) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+
+				if strings.HasSuffix(r.URL.Path, "fail") {
+					w.WriteHeader(http.StatusUnprocessableEntity)
+					return
+				}
+				_, _ = io.WriteString(w, r.URL.Path)
+			}))
+
+			var progress []twapi.BatchProgress
+			tt.opts.OnProgress = func(p twapi.BatchProgress) {
+				progress = append(progress, p)
+			}
+
+			results, err := twapi.ExecuteBatch[testRequest, *testResponse](t.Context(), engine, requests, tt.opts)
+			if !errors.Is(err, twapi.ErrValidation) {
+				t.Errorf("expected batch error to wrap the item error, got %v", err)
+			}
+			if len(results) != len(requests) {
+				t.Fatalf("expected %d results, got %d", len(requests), len(results))
+			}
+			for i, result := range results {
+				if result.Err == nil && result.Response.body != tt.wantBodies[i] {
+					t.Errorf("item %d: expected body %q, got %q", i, tt.wantBodies[i], result.Response.body)
+				}
+			}
+			for _, i := range tt.wantSkipped {
+				if !errors.Is(results[i].Err, twapi.ErrBatchSkipped) {
+					t.Errorf("item %d: expected to be skipped, got %v", i, results[i].Err)
+				}
+			}
+
+			wantCompleted := len(requests) - len(tt.wantSkipped)
+			if len(progress) != wantCompleted {
+				t.Fatalf("expected %d progress reports, got %d", wantCompleted, len(progress))
+			}
+			last := progress[len(progress)-1]
+			if last.Completed != wantCompleted || last.Failed != tt.wantFailed || last.Total != len(requests) {
+				t.Errorf("unexpected final progress %+v", last)
+			}
+			if got := maxInFlight.Load(); got > int64(tt.opts.Concurrency) {
+				t.Errorf("expected at most %d requests in flight, got %d", tt.opts.Concurrency, got)
+			}
+		})
+	}
+}
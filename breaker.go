@@ -0,0 +1,248 @@
+package twapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBreakerFailureThreshold = 5
+	defaultBreakerOpenTimeout      = 30 * time.Second
+	defaultBreakerHalfOpenProbes   = 1
+)
+
+// ErrCircuitOpen is matched by the errors returned while the circuit breaker
+// of the installation is open. The request was not sent.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitOpenError is returned when a request is rejected by an open circuit
+// breaker. It matches ErrCircuitOpen through errors.Is.
+type CircuitOpenError struct {
+	// Server is the installation the circuit breaker protects.
+	Server string
+
+	// RetryAt is when the circuit breaker lets probe requests through again.
+	RetryAt time.Time
+}
+
+// Error implements the error interface.
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%s for %s until %s", ErrCircuitOpen, e.Server, e.RetryAt.Format(time.RFC3339))
+}
+
+// Is reports whether the target is ErrCircuitOpen.
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitBreakerPolicy configures the circuit breaker of the Engine. Zero values
+// are replaced by the defaults documented on each field.
+type CircuitBreakerPolicy struct {
+	// FailureThreshold is the number of consecutive failures that opens the
+	// circuit. Defaults to 5.
+	FailureThreshold int
+
+	// OpenTimeout is how long the circuit stays open before letting probe
+	// requests through. Defaults to 30s.
+	OpenTimeout time.Duration
+
+	// HalfOpenProbes is the number of consecutive successful probes required to
+	// close the circuit again. Probes are sent one at a time. Defaults to 1.
+	HalfOpenProbes int
+}
+
+// circuitState is the state of the circuit breaker of an installation.
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// String returns the name of the state, as logged.
+func (s circuitState) String() string {
+	switch s {
+	case circuitOpen:
+		return "open"
+	case circuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// WithCircuitBreaker stops sending requests to an installation that keeps
+// failing. There is a circuit per session server, so a degraded installation
+// does not affect the others served by the same Engine.
+//
+// Responses with a 5xx status code and transport errors, such as timeouts,
+// count as failures, unless caused by the request context being cancelled.
+// After FailureThreshold consecutive failures the circuit opens, and requests
+// fail straight away with an error matching ErrCircuitOpen. Once OpenTimeout
+// has passed the circuit is half-open: a single probe request is let through at
+// a time, and HalfOpenProbes successful probes close the circuit, while a
+// failed one opens it again. Requests sent before the circuit opened, which
+// complete while it is half-open, do not count. State changes are logged
+// through the Engine logger.
+//
+// The circuit breaker is checked before each attempt is sent, after waiting
+// for the rate limiter, so a request being retried by WithRetryPolicy stops as
+// soon as the circuit opens.
+func WithCircuitBreaker(policy CircuitBreakerPolicy) EngineOption {
+	if policy.FailureThreshold <= 0 {
+		policy.FailureThreshold = defaultBreakerFailureThreshold
+	}
+	if policy.OpenTimeout <= 0 {
+		policy.OpenTimeout = defaultBreakerOpenTimeout
+	}
+	if policy.HalfOpenProbes <= 0 {
+		policy.HalfOpenProbes = defaultBreakerHalfOpenProbes
+	}
+	return func(e *Engine) {
+		e.breaker = &circuitBreaker{
+			policy:   policy,
+			logger:   func() *slog.Logger { return e.logger },
+			circuits: make(map[string]*circuit),
+		}
+	}
+}
+
+// circuitBreaker tracks the circuit of every installation.
+type circuitBreaker struct {
+	mu       sync.Mutex
+	policy   CircuitBreakerPolicy
+	logger   func() *slog.Logger
+	circuits map[string]*circuit
+}
+
+// circuit is the circuit breaker state of an installation.
+type circuit struct {
+	state     circuitState
+	failures  int
+	successes int
+	probing   bool
+	openedAt  time.Time
+}
+
+// allow reports whether the request may be sent, failing with a
+// CircuitOpenError otherwise, and whether it is the probe of a half-open
+// circuit. The outcome must be passed back to record. A nil breaker allows
+// every request.
+func (b *circuitBreaker) allow(req *http.Request) (probe bool, err error) {
+	if b == nil {
+		return false, nil
+	}
+	server := breakerKey(req)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(server)
+	retryAt := c.openedAt.Add(b.policy.OpenTimeout)
+	switch c.state {
+	case circuitOpen:
+		if time.Now().Before(retryAt) {
+			return false, &CircuitOpenError{Server: server, RetryAt: retryAt}
+		}
+		b.transition(req.Context(), server, c, circuitHalfOpen)
+		c.probing = true
+		return true, nil
+	case circuitHalfOpen:
+		if c.probing {
+			return false, &CircuitOpenError{Server: server, RetryAt: retryAt}
+		}
+		c.probing = true
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// record updates the circuit of the installation with the outcome of the
+// request, which allow marked as probe or not. While the circuit is half-open
+// only the probe decides its state: requests let through before it opened
+// may complete at any time, and say nothing about the installation now. A nil
+// breaker ignores it.
+func (b *circuitBreaker) record(req *http.Request, probe bool, resp *http.Response, err error) {
+	if b == nil {
+		return
+	}
+	server := breakerKey(req)
+	ctx := req.Context()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c := b.circuit(server)
+	if probe {
+		c.probing = false
+	} else if c.state == circuitHalfOpen {
+		return
+	}
+
+	switch {
+	case err != nil && ctx.Err() != nil:
+		// the caller gave up, which says nothing about the installation
+		return
+	case err != nil || resp.StatusCode >= http.StatusInternalServerError:
+		c.successes = 0
+		if c.state == circuitHalfOpen {
+			c.openedAt = time.Now()
+			b.transition(ctx, server, c, circuitOpen)
+			return
+		}
+		c.failures++
+		if c.state == circuitClosed && c.failures >= b.policy.FailureThreshold {
+			c.openedAt = time.Now()
+			b.transition(ctx, server, c, circuitOpen)
+		}
+	default:
+		c.failures = 0
+		if probe {
+			c.successes++
+			if c.successes >= b.policy.HalfOpenProbes {
+				c.successes = 0
+				b.transition(ctx, server, c, circuitClosed)
+			}
+		}
+	}
+}
+
+// circuit returns the circuit of the installation, creating it if needed. It
+// must be called with the lock held.
+func (b *circuitBreaker) circuit(server string) *circuit {
+	c, ok := b.circuits[server]
+	if !ok {
+		c = &circuit{}
+		b.circuits[server] = c
+	}
+	return c
+}
+
+// transition moves the circuit to a new state and logs it. It must be called
+// with the lock held.
+func (b *circuitBreaker) transition(ctx context.Context, server string, c *circuit, state circuitState) {
+	previous := c.state
+	c.state = state
+	level := slog.LevelInfo
+	if state == circuitOpen {
+		level = slog.LevelWarn
+	}
+	b.logger().Log(ctx, level, "circuit breaker state changed",
+		slog.String("server", server),
+		slog.String("from", previous.String()),
+		slog.String("to", state.String()),
+		slog.Int("failures", c.failures),
+	)
+}
+
+// breakerKey identifies the installation the request is sent to.
+func breakerKey(req *http.Request) string {
+	return req.URL.Scheme + "://" + req.URL.Host
+}
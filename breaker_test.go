@@ -0,0 +1,227 @@
+package twapi_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	const openTimeout = 50 * time.Millisecond
+
+	var failing atomic.Bool
+	var hits atomic.Int64
+	failing.Store(true)
+
+	var logs bytes.Buffer
+	engine := newTestEngine(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}),
+		twapi.WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+		twapi.WithCircuitBreaker(twapi.CircuitBreakerPolicy{FailureThreshold: 2, OpenTimeout: openTimeout}),
+	)
+
+	send := func() error {
+		resp, err := twapi.ExecuteRaw(t.Context(), engine, testRequest{path: "/projects.json"})
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		return nil
+	}
+
+	steps := []struct {
+		name     string
+		failing  bool
+		wait     time.Duration
+		wantOpen bool
+		wantHits int64
+	}{{
+		name:     "first failure",
+		failing:  true,
+		wantHits: 1,
+	}, {
+		name:     "threshold reached",
+		failing:  true,
+		wantHits: 2,
+	}, {
+		name:     "open circuit fails fast",
+		failing:  false,
+		wantOpen: true,
+		wantHits: 2,
+	}, {
+		name:     "failed probe opens the circuit again",
+		failing:  true,
+		wait:     openTimeout,
+		wantHits: 3,
+	}, {
+		name:     "reopened circuit fails fast",
+		failing:  false,
+		wantOpen: true,
+		wantHits: 3,
+	}, {
+		name:     "successful probe closes the circuit",
+		failing:  false,
+		wait:     openTimeout,
+		wantHits: 4,
+	}, {
+		name:     "closed circuit",
+		failing:  false,
+		wantHits: 5,
+	}}
+
+	for _, step := range steps {
+		failing.Store(step.failing)
+		time.Sleep(step.wait)
+
+		err := send()
+		if got := errors.Is(err, twapi.ErrCircuitOpen); got != step.wantOpen {
+			t.Errorf("%s: expected circuit open %t, got error %v", step.name, step.wantOpen, err)
+		}
+		if got := hits.Load(); got != step.wantHits {
+			t.Errorf("%s: expected %d requests to reach the server, got %d", step.name, step.wantHits, got)
+		}
+	}
+
+	for _, transition := range []string{"from=closed to=open", "from=open to=half-open", "from=half-open to=closed"} {
+		if !strings.Contains(logs.String(), transition) {
+			t.Errorf("expected state change %q to be logged, got:\n%s", transition, logs.String())
+		}
+	}
+}
+
+func TestCircuitBreakerStopsRetries(t *testing.T) {
+	var hits atomic.Int64
+	engine := newTestEngine(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}),
+		twapi.WithRetryPolicy(twapi.RetryPolicy{MaxAttempts: 5, MinBackoff: time.Millisecond}),
+		twapi.WithCircuitBreaker(twapi.CircuitBreakerPolicy{FailureThreshold: 2}),
+	)
+
+	_, err := twapi.ExecuteRaw(t.Context(), engine, testRequest{path: "/projects.json"})
+	var circuitErr *twapi.CircuitOpenError
+	if !errors.As(err, &circuitErr) {
+		t.Fatalf("expected circuit open error, got %v", err)
+	}
+	if circuitErr.RetryAt.IsZero() || circuitErr.Server == "" {
+		t.Errorf("expected server and retry time in error, got %+v", circuitErr)
+	}
+	if got := hits.Load(); got != 2 {
+		t.Errorf("expected retries to stop once the circuit opened after 2 requests, got %d", got)
+	}
+}
+
+func TestCircuitBreakerProbeCancelled(t *testing.T) {
+	const openTimeout = 20 * time.Millisecond
+
+	var failing atomic.Bool
+	failing.Store(true)
+	engine := newTestEngine(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}),
+		twapi.WithRateLimit(10, 1),
+		twapi.WithCircuitBreaker(twapi.CircuitBreakerPolicy{FailureThreshold: 1, OpenTimeout: openTimeout}),
+	)
+
+	resp, err := twapi.ExecuteRaw(t.Context(), engine, testRequest{path: "/projects.json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	_ = resp.Body.Close()
+	failing.Store(false)
+	time.Sleep(openTimeout)
+
+	// the probe gives up while waiting for the rate limiter
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := twapi.ExecuteRaw(ctx, engine, testRequest{path: "/projects.json"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the rate limiter wait to exceed the deadline, got %v", err)
+	}
+
+	resp, err = twapi.ExecuteRaw(t.Context(), engine, testRequest{path: "/projects.json"})
+	if err != nil {
+		t.Fatalf("expected the next probe to be sent, got %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+func TestCircuitBreakerStaleRequest(t *testing.T) {
+	const openTimeout = 20 * time.Millisecond
+
+	started := make(chan string)
+	release := map[string]chan struct{}{
+		"/slow.json":  make(chan struct{}),
+		"/probe.json": make(chan struct{}),
+	}
+	engine := newTestEngine(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fail.json":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/slow.json", "/probe.json":
+			started <- r.URL.Path
+			<-release[r.URL.Path]
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}), twapi.WithCircuitBreaker(twapi.CircuitBreakerPolicy{FailureThreshold: 1, OpenTimeout: openTimeout}))
+
+	send := func(path string) error {
+		resp, err := twapi.ExecuteRaw(t.Context(), engine, testRequest{path: path})
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		return nil
+	}
+	sendInBackground := func(path string) chan error {
+		done := make(chan error, 1)
+		go func() { done <- send(path) }()
+		<-started
+		return done
+	}
+
+	// a request is sent while the circuit is closed, and completes once the
+	// circuit is half-open and probing
+	slow := sendInBackground("/slow.json")
+	if err := send("/fail.json"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	time.Sleep(openTimeout)
+	probe := sendInBackground("/probe.json")
+	close(release["/slow.json"])
+	if err := <-slow; err != nil {
+		t.Fatalf("unexpected error for the slow request: %s", err)
+	}
+
+	if err := send("/projects.json"); !errors.Is(err, twapi.ErrCircuitOpen) {
+		t.Errorf("expected the circuit to wait for its probe, got %v", err)
+	}
+
+	close(release["/probe.json"])
+	if err := <-probe; err != nil {
+		t.Fatalf("unexpected error for the probe: %s", err)
+	}
+	if err := send("/projects.json"); err != nil {
+		t.Errorf("expected the probe to close the circuit, got %v", err)
+	}
+}
@@ -0,0 +1,346 @@
+package twapi
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/teamwork/twapi-go-sdk/internal/redact"
+)
+
+// ErrCacheMiss is returned by a CacheStore when it has no response for a key.
+var ErrCacheMiss = errors.New("cache miss")
+
+// CachedResponse is a response kept by a CacheStore, along with the
+// validators used to revalidate it. The headers carrying credentials, such as
+// Set-Cookie, are not kept.
+type CachedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	StoredAt   time.Time   `json:"storedAt"`
+}
+
+// ETag returns the entity tag of the cached response, if any.
+func (c *CachedResponse) ETag() string {
+	return c.Header.Get("ETag")
+}
+
+// LastModified returns the last modification date of the cached response, if
+// any.
+func (c *CachedResponse) LastModified() string {
+	return c.Header.Get("Last-Modified")
+}
+
+// CacheStore keeps the responses cached by WithCache. Keys identify the
+// request, including the credentials used to send it, so responses are never
+// shared between users. Implementations must be safe for concurrent use.
+type CacheStore interface {
+	// Get returns the response stored for the key, or ErrCacheMiss.
+	Get(ctx context.Context, key string) (*CachedResponse, error)
+
+	// Set stores the response for the key, replacing any previous one.
+	Set(ctx context.Context, key string, response *CachedResponse) error
+}
+
+// cacheBypassKey is the context key that disables the cache for a request.
+type cacheBypassKey struct{}
+
+// WithCacheBypassContext returns a context that makes WithCache ignore the
+// stored response, sending the request without validators. The response still
+// refreshes the cache.
+func WithCacheBypassContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+// cacheBypassed reports whether the context disables the cache.
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return bypass
+}
+
+// WithCache adds a conditional GET cache to the Engine. Successful GET
+// responses carrying an ETag or Last-Modified header are kept in the store, and
+// the following identical requests are sent with If-None-Match and
+// If-Modified-Since. When the API answers 304 Not Modified, the cached response
+// is returned in its place with a 200 status code, so the HTTPResponser never
+// sees the difference.
+//
+// Responses marked with Cache-Control: no-store are never cached. Use
+// WithCacheBypassContext to skip the cache for a single request.
+//
+// Like WithMiddleware, it wraps the client configured so far, and is ignored if
+// WithHTTPClient is used afterwards.
+func WithCache(store CacheStore) EngineOption {
+	return func(e *Engine) {
+		cache := &conditionalCache{engine: e, store: store}
+		e.client = &httpClientMiddleware{
+			client:     e.client,
+			middleware: cache.middleware,
+		}
+	}
+}
+
+// conditionalCache implements the WithCache middleware.
+type conditionalCache struct {
+	engine *Engine
+	store  CacheStore
+}
+
+// middleware revalidates the GET requests of the next client against the
+// store.
+func (c *conditionalCache) middleware(next HTTPClient) HTTPClient {
+	return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodGet {
+			return next.Do(req)
+		}
+		ctx := req.Context()
+		key := cacheKey(req)
+
+		var cached *CachedResponse
+		if !cacheBypassed(ctx) {
+			var err error
+			cached, err = c.store.Get(ctx, key)
+			if err != nil && !errors.Is(err, ErrCacheMiss) {
+				c.engine.logger.Warn("failed to read cached response",
+					slog.String("url", redact.URL(req.URL)),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+		if cached != nil && (cached.ETag() != "" || cached.LastModified() != "") {
+			req = req.Clone(ctx)
+			if etag := cached.ETag(); etag != "" && req.Header.Get("If-None-Match") == "" {
+				req.Header.Set("If-None-Match", etag)
+			}
+			if modified := cached.LastModified(); modified != "" && req.Header.Get("If-Modified-Since") == "" {
+				req.Header.Set("If-Modified-Since", modified)
+			}
+		}
+
+		resp, err := next.Do(req)
+		if err != nil {
+			return resp, err
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusNotModified && cached != nil:
+			c.engine.discard(resp)
+			return cached.response(req), nil
+
+		case resp.StatusCode == http.StatusOK && cacheable(resp):
+			body, err := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read response body: %w", err)
+			}
+			// the session cookie or other credentials must not outlive the
+			// response, as the store may be shared or written to disk
+			header := resp.Header.Clone()
+			redact.Remove(header)
+			stored := &CachedResponse{
+				StatusCode: resp.StatusCode,
+				Header:     header,
+				Body:       body,
+				StoredAt:   time.Now(),
+			}
+			if err := c.store.Set(ctx, key, stored); err != nil {
+				c.engine.logger.Warn("failed to cache response",
+					slog.String("url", redact.URL(req.URL)),
+					slog.String("error", err.Error()),
+				)
+			}
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			return resp, nil
+
+		default:
+			return resp, nil
+		}
+	})
+}
+
+// response rebuilds the HTTP response from the cache.
+func (c *CachedResponse) response(req *http.Request) *http.Response {
+	header := c.Header.Clone()
+	header.Set("Content-Length", strconv.Itoa(len(c.Body)))
+	return &http.Response{
+		Status:        strconv.Itoa(c.StatusCode) + " " + http.StatusText(c.StatusCode),
+		StatusCode:    c.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(c.Body)),
+		ContentLength: int64(len(c.Body)),
+		Request:       req,
+	}
+}
+
+// cacheable reports whether the response can be revalidated later.
+func cacheable(resp *http.Response) bool {
+	if strings.Contains(strings.ToLower(resp.Header.Get("Cache-Control")), "no-store") {
+		return false
+	}
+	return resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+}
+
+// cacheKey identifies the request by its method, URL and credentials.
+func cacheKey(req *http.Request) string {
+	return req.Method + " " + req.URL.String() + " " + requestIdentity(req)
+}
+
+// requestIdentity hashes the credentials of the request, so requests sent on
+// behalf of different users never share a key.
+func requestIdentity(req *http.Request) string {
+	hash := sha256.New()
+	_, _ = io.WriteString(hash, req.Header.Get("Authorization"))
+	_, _ = io.WriteString(hash, "\n")
+	for _, cookie := range req.Cookies() {
+		if strings.EqualFold(cookie.Name, "tw-auth") {
+			_, _ = io.WriteString(hash, cookie.Value)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// MemoryCache is an in-memory CacheStore that evicts the least recently used
+// responses once it holds its maximum number of entries.
+type MemoryCache struct {
+	mutex      sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+// memoryCacheEntry is an element of the MemoryCache eviction list.
+type memoryCacheEntry struct {
+	key      string
+	response *CachedResponse
+}
+
+// NewMemoryCache creates an in-memory CacheStore holding up to maxEntries
+// responses. A maxEntries of zero or less means no limit.
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Get implements CacheStore.
+func (m *MemoryCache) Get(_ context.Context, key string) (*CachedResponse, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	m.order.MoveToFront(element)
+	return element.Value.(*memoryCacheEntry).response, nil
+}
+
+// Set implements CacheStore.
+func (m *MemoryCache) Set(_ context.Context, key string, response *CachedResponse) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if element, ok := m.entries[key]; ok {
+		element.Value.(*memoryCacheEntry).response = response
+		m.order.MoveToFront(element)
+		return nil
+	}
+	m.entries[key] = m.order.PushFront(&memoryCacheEntry{key: key, response: response})
+	for m.maxEntries > 0 && m.order.Len() > m.maxEntries {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+	return nil
+}
+
+// Len returns the number of cached responses.
+func (m *MemoryCache) Len() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.order.Len()
+}
+
+// DiskCache is a CacheStore keeping each response in a JSON file of a
+// directory, so the cache survives restarts. Files are named after the hash of
+// their key, which contains the credentials hash rather than the credentials.
+type DiskCache struct {
+	dir string
+}
+
+// NewDiskCache creates a CacheStore in the directory, creating it if needed.
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &DiskCache{dir: dir}, nil
+}
+
+// Get implements CacheStore.
+func (d *DiskCache) Get(_ context.Context, key string) (*CachedResponse, error) {
+	data, err := os.ReadFile(d.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrCacheMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached response: %w", err)
+	}
+	var response CachedResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode cached response: %w", err)
+	}
+	return &response, nil
+}
+
+// Set implements CacheStore. The file is replaced atomically, so concurrent
+// readers never see a partial response.
+func (d *DiskCache) Set(_ context.Context, key string, response *CachedResponse) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to encode cached response: %w", err)
+	}
+	file, err := os.CreateTemp(d.dir, "tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+	defer func() { _ = os.Remove(file.Name()) }()
+
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+	if err := os.Rename(file.Name(), d.path(key)); err != nil {
+		return fmt.Errorf("failed to store cache file: %w", err)
+	}
+	return nil
+}
+
+// path returns the file holding the response of the key.
+func (d *DiskCache) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(hash[:])+".json")
+}
@@ -0,0 +1,193 @@
+package twapi_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/projects"
+)
+
+func TestWithCache(t *testing.T) {
+	diskCache, err := twapi.NewDiskCache(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to create disk cache: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		store twapi.CacheStore
+	}{{
+		name:  "memory",
+		store: twapi.NewMemoryCache(10),
+	}, {
+		name:  "disk",
+		store: diskCache,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fullResponses, notModified atomic.Int64
+			engine := newTestEngine(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("If-None-Match") == `"v1"` {
+					notModified.Add(1)
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				fullResponses.Add(1)
+				w.Header().Set("ETag", `"v1"`)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"project":{"id":1,"name":"Apollo"}}`))
+			}), twapi.WithCache(tt.store))
+
+			for i := range 3 {
+				response, err := projects.ProjectGet(t.Context(), engine, projects.NewProjectGetRequest(1))
+				if err != nil {
+					t.Fatalf("request %d: failed to retrieve project: %v", i, err)
+				}
+				if response.Project.Name != "Apollo" {
+					t.Errorf("request %d: expected cached project, got %+v", i, response.Project)
+				}
+			}
+			if got := fullResponses.Load(); got != 1 {
+				t.Errorf("expected 1 full response, got %d", got)
+			}
+			if got := notModified.Load(); got != 2 {
+				t.Errorf("expected 2 not modified responses, got %d", got)
+			}
+
+			ctx := twapi.WithCacheBypassContext(t.Context())
+			if _, err := projects.ProjectGet(ctx, engine, projects.NewProjectGetRequest(1)); err != nil {
+				t.Fatalf("failed to retrieve project bypassing the cache: %v", err)
+			}
+			if got := fullResponses.Load(); got != 2 {
+				t.Errorf("expected bypass to fetch a full response, got %d full responses", got)
+			}
+		})
+	}
+}
+
+func TestWithCacheSkipsUncacheable(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		header http.Header
+	}{{
+		name:   "no validators",
+		method: http.MethodGet,
+	}, {
+		name:   "no-store",
+		method: http.MethodGet,
+		header: http.Header{"Etag": {`"v1"`}, "Cache-Control": {"private, no-store"}},
+	}, {
+		name:   "unsafe method",
+		method: http.MethodPost,
+		header: http.Header{"Etag": {`"v1"`}},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := twapi.NewMemoryCache(10)
+			engine := newTestEngine(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				for name, values := range tt.header {
+					w.Header()[name] = values
+				}
+				_, _ = w.Write([]byte(`{}`))
+			}), twapi.WithCache(store))
+
+			resp, err := twapi.ExecuteRaw(t.Context(), engine, testRequest{method: tt.method, path: "/projects.json"})
+			if err != nil {
+				t.Fatalf("failed to execute request: %v", err)
+			}
+			_ = resp.Body.Close()
+
+			if store.Len() != 0 {
+				t.Errorf("expected response not to be cached, got %d entries", store.Len())
+			}
+		})
+	}
+}
+
+func TestWithCacheRemovesCredentials(t *testing.T) {
+	dir := t.TempDir()
+	store, err := twapi.NewDiskCache(dir)
+	if err != nil {
+		t.Fatalf("failed to create disk cache: %v", err)
+	}
+	engine := newTestEngine(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Set-Cookie", "tw-auth=secret; Path=/; HttpOnly")
+		w.Header().Set("Authentication-Info", "nextnonce=secret")
+		w.Header().Set("X-Amz-Security-Token", "secret")
+		_, _ = w.Write([]byte(`{}`))
+	}), twapi.WithCache(store))
+
+	resp, err := twapi.ExecuteRaw(t.Context(), engine, testRequest{method: http.MethodGet, path: "/projects.json"})
+	if err != nil {
+		t.Fatalf("failed to execute request: %v", err)
+	}
+	_ = resp.Body.Close()
+	if got := resp.Header.Get("Set-Cookie"); got == "" {
+		t.Errorf("expected the response to keep its cookie")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read cache directory: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 cached entry, got %d", len(entries))
+	}
+	data, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	if err != nil {
+		t.Fatalf("failed to read cached entry: %v", err)
+	}
+	var stored twapi.CachedResponse
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatalf("failed to decode cached entry: %v", err)
+	}
+	if stored.ETag() != `"v1"` {
+		t.Errorf("expected the validators to be cached, got %v", stored.Header)
+	}
+	for _, name := range []string{"Set-Cookie", "Authentication-Info", "X-Amz-Security-Token"} {
+		if value := stored.Header.Get(name); value != "" {
+			t.Errorf("expected %s not to be cached, got %q", name, value)
+		}
+	}
+	if bytes.Contains(data, []byte("secret")) {
+		t.Errorf("expected no credential in the cached entry, got %s", data)
+	}
+}
+
+func TestMemoryCacheEviction(t *testing.T) {
+	ctx := context.Background()
+	store := twapi.NewMemoryCache(2)
+	for _, key := range []string{"a", "b"} {
+		if err := store.Set(ctx, key, &twapi.CachedResponse{StatusCode: http.StatusOK}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// reading "a" makes "b" the least recently used entry
+	if _, err := store.Get(ctx, "a"); err != nil {
+		t.Fatalf("expected a to be cached, got %v", err)
+	}
+	if err := store.Set(ctx, "c", &twapi.CachedResponse{StatusCode: http.StatusOK}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Get(ctx, "b"); !errors.Is(err, twapi.ErrCacheMiss) {
+		t.Errorf("expected b to be evicted, got %v", err)
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, err := store.Get(ctx, key); err != nil {
+			t.Errorf("expected %s to be cached, got %v", key, err)
+		}
+	}
+}
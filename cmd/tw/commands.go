@@ -0,0 +1,427 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/projects"
+)
+
+// outputFlags registers the --output and --fields flags of a command.
+type outputFlags struct {
+	output *string
+	fields *string
+}
+
+// addOutputFlags registers the output flags, printing the default fields
+// unless told otherwise.
+func addOutputFlags(flags *flag.FlagSet, defaultFields string) outputFlags {
+	return outputFlags{
+		output: flags.String("output", outputTable, "output format: json, table or csv"),
+		fields: flags.String("fields", defaultFields, "comma-separated attributes to print"),
+	}
+}
+
+// printer creates the printer selected by the flags.
+func (o outputFlags) printer(a *app) (*printer, error) {
+	return newPrinter(a.stdout, *o.output, splitFields(*o.fields))
+}
+
+// parse parses the flags of a command, allowing them before and after its
+// positional arguments, which are returned.
+func parse(flags *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// newFlagSet creates the flag set of a command.
+func newFlagSet(a *app, name string) *flag.FlagSet {
+	flags := flag.NewFlagSet("tw "+name, flag.ContinueOnError)
+	flags.SetOutput(a.stdout)
+	return flags
+}
+
+// tasksList prints the tasks matching the filters, following the pagination.
+func tasksList(ctx context.Context, a *app, args []string) error {
+	flags := newFlagSet(a, "tasks list")
+	projectID := flags.Int64("project", 0, "only list the tasks of this project")
+	tasklistID := flags.Int64("tasklist", 0, "only list the tasks of this tasklist")
+	searchTerm := flags.String("search", "", "only list the tasks matching this term")
+	assignees := flags.String("assignee", "", "comma-separated IDs of the assignees to filter by")
+	completed := flags.Bool("completed", false, "include completed tasks")
+	limit := flags.Int("limit", 0, "maximum number of tasks to print (default all)")
+	pageSize := flags.Int64("page-size", 100, "number of tasks loaded per request")
+	output := addOutputFlags(flags, "id,name,status,dueDate")
+	if _, err := parse(flags, args); err != nil {
+		return err
+	}
+	assigneeIDs, err := parseIDs(*assignees)
+	if err != nil {
+		return fmt.Errorf("invalid --assignee: %w", err)
+	}
+
+	req := projects.NewTaskListRequest()
+	req.Path.ProjectID = *projectID
+	req.Path.TasklistID = *tasklistID
+	req.Filters.SearchTerm = *searchTerm
+	req.Filters.AssigneeUserIDs = assigneeIDs
+	req.Filters.PageSize = *pageSize
+	req.Filters.CountMode = twapi.ListCountModeSkip
+	if *completed {
+		req.Filters.IncludeCompletedTasks = new(true)
+	}
+	for _, field := range splitFields(*output.fields) {
+		// nested fields are returned with their top level attribute
+		field, _, _ = strings.Cut(field, ".")
+		req.Filters.Fields.Tasks = append(req.Filters.Fields.Tasks, projects.TaskField(field))
+	}
+
+	p, err := output.printer(a)
+	if err != nil {
+		return err
+	}
+	engine, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	next, err := twapi.Iterate[projects.TaskListRequest, *projects.TaskListResponse](ctx, engine, req)
+	if err != nil {
+		return fmt.Errorf("failed to list tasks: %w", err)
+	}
+	var printed int
+	for hasNext := true; hasNext; {
+		var page *projects.TaskListResponse
+		if page, hasNext, err = next(); err != nil {
+			return fmt.Errorf("failed to list tasks: %w", err)
+		}
+		for _, task := range page.Tasks {
+			if *limit > 0 && printed >= *limit {
+				return p.flush()
+			}
+			if err := p.print(task); err != nil {
+				return err
+			}
+			printed++
+		}
+	}
+	return p.flush()
+}
+
+// timelogsCreate logs time on a task or project.
+func timelogsCreate(ctx context.Context, a *app, args []string) error {
+	flags := newFlagSet(a, "timelogs create")
+	taskID := flags.Int64("task", 0, "task to log time on")
+	projectID := flags.Int64("project", 0, "project to log time on, when not on a task")
+	hours := flags.Int64("hours", 0, "hours logged")
+	minutes := flags.Int64("minutes", 0, "minutes logged")
+	date := flags.String("date", "", "date and time the work started, as 2006-01-02 or RFC 3339 (default now)")
+	description := flags.String("description", "", "description of the work")
+	billable := flags.Bool("billable", false, "mark the time as billable")
+	output := addOutputFlags(flags, "id,minutes,timeLogged,description")
+	if _, err := parse(flags, args); err != nil {
+		return err
+	}
+	if *taskID == 0 && *projectID == 0 {
+		return errors.New("missing --task or --project")
+	}
+	if *hours <= 0 && *minutes <= 0 {
+		return errors.New("missing --hours or --minutes")
+	}
+	loggedAt, err := parseDate(*date)
+	if err != nil {
+		return fmt.Errorf("invalid --date: %w", err)
+	}
+
+	duration := time.Duration(*hours)*time.Hour + time.Duration(*minutes)*time.Minute
+	req := projects.NewTimelogCreateRequestInProject(*projectID, loggedAt, duration)
+	if *taskID > 0 {
+		req = projects.NewTimelogCreateRequestInTask(*taskID, loggedAt, duration)
+	}
+	if *description != "" {
+		req.Description = description
+	}
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "billable" {
+			req.Billable = billable
+		}
+	})
+
+	p, err := output.printer(a)
+	if err != nil {
+		return err
+	}
+	engine, err := a.client()
+	if err != nil {
+		return err
+	}
+	response, err := projects.TimelogCreate(ctx, engine, req)
+	if err != nil {
+		return fmt.Errorf("failed to create timelog: %w", err)
+	}
+	if err := p.print(response.Timelog); err != nil {
+		return err
+	}
+	return p.flush()
+}
+
+// timerFields are the attributes of a timer printed by default.
+const timerFields = "id,running,duration,project.id,task.id,description"
+
+// timersStart starts a timer.
+func timersStart(ctx context.Context, a *app, args []string) error {
+	flags := newFlagSet(a, "timers start")
+	projectID := flags.Int64("project", 0, "project of the timer (required)")
+	taskID := flags.Int64("task", 0, "task of the timer")
+	description := flags.String("description", "", "description of the work")
+	billable := flags.Bool("billable", false, "mark the time as billable")
+	stopOthers := flags.Bool("stop-others", false, "stop the timers already running")
+	output := addOutputFlags(flags, timerFields)
+	if _, err := parse(flags, args); err != nil {
+		return err
+	}
+	if *projectID == 0 {
+		return errors.New("missing --project")
+	}
+
+	req := projects.NewTimerCreateRequest(*projectID)
+	req.Running = new(true)
+	req.Billable = billable
+	req.StopRunningTimers = stopOthers
+	if *taskID > 0 {
+		req.TaskID = taskID
+	}
+	if *description != "" {
+		req.Description = description
+	}
+
+	p, err := output.printer(a)
+	if err != nil {
+		return err
+	}
+	engine, err := a.client()
+	if err != nil {
+		return err
+	}
+	response, err := projects.TimerCreate(ctx, engine, req)
+	if err != nil {
+		return fmt.Errorf("failed to start timer: %w", err)
+	}
+	if err := p.print(response.Timer); err != nil {
+		return err
+	}
+	return p.flush()
+}
+
+// timersPause pauses a running timer.
+func timersPause(ctx context.Context, a *app, args []string) error {
+	return timerAction(a, "pause", args, func(engine *twapi.Engine, timerID int64) (projects.Timer, error) {
+		response, err := projects.TimerPause(ctx, engine, projects.NewTimerPauseRequest(timerID))
+		if err != nil {
+			return projects.Timer{}, err
+		}
+		return response.Timer, nil
+	})
+}
+
+// timersResume resumes a paused timer.
+func timersResume(ctx context.Context, a *app, args []string) error {
+	return timerAction(a, "resume", args, func(engine *twapi.Engine, timerID int64) (projects.Timer, error) {
+		response, err := projects.TimerResume(ctx, engine, projects.NewTimerResumeRequest(timerID))
+		if err != nil {
+			return projects.Timer{}, err
+		}
+		return response.Timer, nil
+	})
+}
+
+// timersComplete stops a timer, logging its time.
+func timersComplete(ctx context.Context, a *app, args []string) error {
+	return timerAction(a, "complete", args, func(engine *twapi.Engine, timerID int64) (projects.Timer, error) {
+		response, err := projects.TimerComplete(ctx, engine, projects.NewTimerCompleteRequest(timerID))
+		if err != nil {
+			return projects.Timer{}, err
+		}
+		return response.Timer, nil
+	})
+}
+
+// timerAction runs an action on the timer identified by the only positional
+// argument, printing the updated timer.
+func timerAction(
+	a *app,
+	action string,
+	args []string,
+	execute func(engine *twapi.Engine, timerID int64) (projects.Timer, error),
+) error {
+	flags := newFlagSet(a, "timers "+action)
+	output := addOutputFlags(flags, timerFields)
+	positional, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("expected the timer ID, as in: tw timers %s 123", action)
+	}
+	timerID, err := strconv.ParseInt(positional[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timer ID %q", positional[0])
+	}
+
+	p, err := output.printer(a)
+	if err != nil {
+		return err
+	}
+	engine, err := a.client()
+	if err != nil {
+		return err
+	}
+	timer, err := execute(engine, timerID)
+	if err != nil {
+		return fmt.Errorf("failed to %s timer: %w", action, err)
+	}
+	if err := p.print(timer); err != nil {
+		return err
+	}
+	return p.flush()
+}
+
+// searchResult is a search item with the name of the entity it found.
+type searchResult struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+// search prints the entities matching the search term.
+func search(ctx context.Context, a *app, args []string) error {
+	flags := newFlagSet(a, "search")
+	searchType := flags.String("type", "", "only search this type of entity, such as tasks or projects")
+	projectID := flags.Int64("project", 0, "only search this project")
+	limit := flags.Int("limit", 50, "maximum number of results to print (0 for all)")
+	output := addOutputFlags(flags, "id,type,name")
+	positional, err := parse(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return errors.New(`expected the search term, as in: tw search "release notes"`)
+	}
+
+	req := projects.NewSearchRequest(strings.Join(positional, " "))
+	req.Filters.Type = projects.SearchRequestType(*searchType)
+	req.Filters.ProjectID = *projectID
+	if *limit > 0 {
+		req.Filters.Limit = int64(min(*limit, 50))
+	}
+
+	p, err := output.printer(a)
+	if err != nil {
+		return err
+	}
+	engine, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	var printed int
+	for page, err := range twapi.All[projects.SearchRequest, *projects.SearchResponse](ctx, engine, req) {
+		if err != nil {
+			return fmt.Errorf("failed to search: %w", err)
+		}
+		names, err := includedNames(page)
+		if err != nil {
+			return err
+		}
+		for _, item := range page.Items {
+			if *limit > 0 && printed >= *limit {
+				return p.flush()
+			}
+			result := searchResult{ID: item.ID, Type: item.Type, Name: names[item.Type][item.ID]}
+			if err := p.print(result); err != nil {
+				return err
+			}
+			printed++
+		}
+	}
+	return p.flush()
+}
+
+// includedNames indexes the names of the entities sideloaded with the search
+// results by type and ID.
+func includedNames(page *projects.SearchResponse) (map[string]map[int64]string, error) {
+	data, err := json.Marshal(page.Included)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode search results: %w", err)
+	}
+	var included map[string]map[string]map[string]any
+	if err := json.Unmarshal(data, &included); err != nil {
+		return nil, fmt.Errorf("failed to decode search results: %w", err)
+	}
+
+	names := make(map[string]map[int64]string)
+	for entity, items := range included {
+		names[entity] = make(map[int64]string)
+		for key, attributes := range items {
+			id, err := strconv.ParseInt(key, 10, 64)
+			if err != nil {
+				continue
+			}
+			names[entity][id] = entityName(attributes)
+		}
+	}
+	return names, nil
+}
+
+// entityName returns the name of a sideloaded entity, whatever it is called.
+func entityName(attributes map[string]any) string {
+	for _, key := range []string{"name", "title", "subject", "description", "body"} {
+		if name, ok := attributes[key].(string); ok && name != "" {
+			return name
+		}
+	}
+	first, _ := attributes["firstName"].(string)
+	last, _ := attributes["lastName"].(string)
+	return strings.TrimSpace(first + " " + last)
+}
+
+// parseIDs parses a comma-separated list of IDs.
+func parseIDs(value string) ([]int64, error) {
+	var ids []int64
+	for _, field := range splitFields(value) {
+		id, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ID %q", field)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parseDate parses a date, or a date and time, defaulting to now.
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Now(), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(time.DateOnly, value, time.Local)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/session"
+)
+
+// config holds the credentials of the installation.
+type config struct {
+	// Server is the URL of the installation, such as
+	// https://example.teamwork.com.
+	Server string `json:"server"`
+
+	// Token is an API key or bearer token. It takes precedence over OAuth2.
+	Token string `json:"token"`
+
+	// ClientID and ClientSecret identify the OAuth2 application used when
+	// there is no token.
+	ClientID     string `json:"clientId"`
+	ClientSecret string `json:"clientSecret"`
+
+	// OAuthServer is the OAuth2 authorization server, which is not the
+	// installation. The installation is the one the user authorises. Defaults
+	// to the one of the SDK.
+	OAuthServer string `json:"oauthServer"`
+
+	// path is the configuration file, next to which the OAuth2 tokens are kept.
+	path string
+}
+
+// loadConfig reads the configuration file, when it exists, and applies the
+// environment variables over it.
+func loadConfig(path string, getenv func(string) string) (*config, error) {
+	explicit := path != ""
+	if !explicit {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate configuration directory: %w", err)
+		}
+		path = filepath.Join(dir, "tw", "config.json")
+	}
+
+	c := &config{path: path}
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist) && !explicit:
+		// the environment alone may be enough
+	case err != nil:
+		return nil, fmt.Errorf("failed to read configuration: %w", err)
+	default:
+		if err := json.Unmarshal(data, c); err != nil {
+			return nil, fmt.Errorf("failed to decode configuration %s: %w", path, err)
+		}
+	}
+
+	for variable, field := range map[string]*string{
+		"TW_SERVER":        &c.Server,
+		"TW_TOKEN":         &c.Token,
+		"TW_CLIENT_ID":     &c.ClientID,
+		"TW_CLIENT_SECRET": &c.ClientSecret,
+		"TW_OAUTH_SERVER":  &c.OAuthServer,
+	} {
+		if value := getenv(variable); value != "" {
+			*field = value
+		}
+	}
+	return c, nil
+}
+
+// session returns the session described by the configuration.
+func (c *config) session() (twapi.Session, error) {
+	switch {
+	case c.Token != "":
+		if c.Server == "" {
+			return nil, errors.New("missing server in configuration or TW_SERVER")
+		}
+		return session.NewBearerToken(c.Token, c.Server), nil
+	case c.ClientID != "":
+		opts := []session.OAuth2Option{
+			session.WithOAuth2TokenStore(session.NewFileTokenStore(c.tokenPath())),
+		}
+		if c.OAuthServer != "" {
+			opts = append(opts, session.WithOAuth2Server(c.OAuthServer))
+		}
+		return session.NewOAuth2(c.ClientID, c.ClientSecret, opts...), nil
+	default:
+		return nil, fmt.Errorf("missing credentials: set a token or an OAuth2 client in %s, "+
+			"or TW_TOKEN and TW_SERVER", c.path)
+	}
+}
+
+// tokenPath returns the file keeping the OAuth2 token, next to the
+// configuration file. It is named after the client, so profiles sharing a
+// directory with different applications do not load each other's tokens.
+func (c *config) tokenPath() string {
+	return filepath.Join(filepath.Dir(c.path), "token-"+url.PathEscape(c.ClientID)+".json")
+}
+
+// app is the state shared by the subcommands.
+type app struct {
+	config *config
+	stdout io.Writer
+	engine *twapi.Engine
+}
+
+// client returns the engine, creating it on first use so commands failing
+// their flag validation never trigger an OAuth2 authorisation.
+func (a *app) client() (*twapi.Engine, error) {
+	if a.engine != nil {
+		return a.engine, nil
+	}
+	s, err := a.config.session()
+	if err != nil {
+		return nil, err
+	}
+	a.engine = twapi.NewEngine(s, twapi.WithRetryPolicy(twapi.RetryPolicy{}))
+	return a.engine, nil
+}
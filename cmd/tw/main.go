@@ -0,0 +1,146 @@
+// tw is a command-line client for Teamwork.com, built on the projects package,
+// so support engineers can script against an installation without writing Go.
+//
+// Usage:
+//
+//	tw tasks list --project 123 --fields id,name --output table
+//	tw timelogs create --task 456 --minutes 90 --description "Review"
+//	tw timers start --project 123 --task 456
+//	tw timers pause 789
+//	tw timers complete 789
+//	tw search "release notes" --type tasks
+//
+// Credentials are read from the configuration file, by default
+// $XDG_CONFIG_HOME/tw/config.json, and the TW_SERVER, TW_TOKEN,
+// TW_CLIENT_ID, TW_CLIENT_SECRET and TW_OAUTH_SERVER environment variables,
+// which take precedence:
+//
+//	{"server": "https://example.teamwork.com", "token": "…"}
+//
+// A bearer token is used when present. Otherwise the OAuth2 client is used,
+// asking the user to authorise it in the browser the first time, and keeping
+// its token next to the configuration file.
+//
+// Lists follow the pagination until every item is printed, or --limit items
+// were. The --fields flag both selects the printed columns and restricts the
+// attributes returned by the API, using the JSON names of the entity.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdout, os.Getenv); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "tw: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// command is a subcommand, such as "tasks list".
+type command struct {
+	usage string
+	run   func(ctx context.Context, app *app, args []string) error
+}
+
+// commands lists the subcommands by entity and action.
+var commands = map[string]map[string]command{
+	"tasks": {
+		"list": {usage: "list tasks, optionally of a project or tasklist", run: tasksList},
+	},
+	"timelogs": {
+		"create": {usage: "log time on a task or project", run: timelogsCreate},
+	},
+	"timers": {
+		"start":    {usage: "start a timer on a project or task", run: timersStart},
+		"pause":    {usage: "pause a running timer", run: timersPause},
+		"resume":   {usage: "resume a paused timer", run: timersResume},
+		"complete": {usage: "stop a timer and log its time", run: timersComplete},
+	},
+	"search": {
+		"": {usage: "search every entity of the installation", run: search},
+	},
+}
+
+// run parses the global flags and dispatches to the subcommand.
+func run(ctx context.Context, args []string, stdout io.Writer, getenv func(string) string) error {
+	flags := flag.NewFlagSet("tw", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	configPath := flags.String("config", "", "configuration file (default $XDG_CONFIG_HOME/tw/config.json)")
+	flags.Usage = func() { printUsage(flags) }
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	args = flags.Args()
+	if len(args) == 0 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+	actions, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	cmd, ok := actions[""]
+	if ok {
+		args = args[1:]
+	} else {
+		if len(args) < 2 {
+			return fmt.Errorf("missing %s action, one of: %s", args[0], actionNames(actions))
+		}
+		if cmd, ok = actions[args[1]]; !ok {
+			return fmt.Errorf("unknown %s action %q, one of: %s", args[0], args[1], actionNames(actions))
+		}
+		args = args[2:]
+	}
+
+	config, err := loadConfig(*configPath, getenv)
+	if err != nil {
+		return err
+	}
+	return cmd.run(ctx, &app{config: config, stdout: stdout}, args)
+}
+
+// printUsage describes the global flags and every subcommand.
+func printUsage(flags *flag.FlagSet) {
+	out := flags.Output()
+	_, _ = fmt.Fprintln(out, "Usage: tw [--config file] <command> [action] [flags]")
+	_, _ = fmt.Fprintln(out, "\nCommands:")
+	for _, entity := range []string{"tasks", "timelogs", "timers", "search"} {
+		for _, action := range actionOrder(commands[entity]) {
+			name := strings.TrimSpace(entity + " " + action)
+			_, _ = fmt.Fprintf(out, "  %-18s %s\n", name, commands[entity][action].usage)
+		}
+	}
+	_, _ = fmt.Fprintln(out, "\nGlobal flags:")
+	flags.PrintDefaults()
+}
+
+// actionNames lists the actions of an entity for error messages.
+func actionNames(actions map[string]command) string {
+	return strings.Join(actionOrder(actions), ", ")
+}
+
+// actionOrder returns the actions of an entity in a stable order.
+func actionOrder(actions map[string]command) []string {
+	order := []string{"", "list", "create", "start", "pause", "resume", "complete"}
+	var names []string
+	for _, name := range order {
+		if _, ok := actions[name]; ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/projects"
+	"github.com/teamwork/twapi-go-sdk/twapitest"
+)
+
+// testEnv returns the environment pointing tw at the fake API server.
+func testEnv(server *twapitest.Server) func(string) string {
+	return func(name string) string {
+		switch name {
+		case "TW_SERVER":
+			return server.URL()
+		case "TW_TOKEN":
+			return twapitest.Token
+		default:
+			return ""
+		}
+	}
+}
+
+func TestTasksList(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	server := twapitest.NewServer(t)
+	project := server.AddProject(projects.Project{Name: "Apollo"})
+	tasklist := server.AddTasklist(projects.Tasklist{Name: "Launch", Project: twapi.Relationship{ID: project.ID}})
+	var tasks []projects.Task
+	for _, name := range []string{"Countdown", "Ignition, liftoff", "Orbit"} {
+		tasks = append(tasks, server.AddTask(projects.Task{Name: name, Tasklist: twapi.Relationship{ID: tasklist.ID}}))
+	}
+	projectFlag := strconv.FormatInt(project.ID, 10)
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{{
+		name: "csv across pages",
+		args: []string{
+			"tasks", "list", "--project", projectFlag, "--page-size", "2", "--fields", "id,name", "--output", "csv",
+		},
+		want: "id,name\n" +
+			strconv.FormatInt(tasks[0].ID, 10) + ",Countdown\n" +
+			strconv.FormatInt(tasks[1].ID, 10) + ",\"Ignition, liftoff\"\n" +
+			strconv.FormatInt(tasks[2].ID, 10) + ",Orbit\n",
+	}, {
+		name: "table with limit",
+		args: []string{"tasks", "list", "--project", projectFlag, "--fields", "name,tasklist.id", "--limit", "1"},
+		want: "NAME       TASKLIST.ID\n" +
+			"Countdown  " + strconv.FormatInt(tasklist.ID, 10) + "\n",
+	}, {
+		name: "json",
+		args: []string{
+			"tasks", "list", "--tasklist", strconv.FormatInt(tasklist.ID, 10), "--fields", "id", "--output", "json",
+		},
+		want: "[\n" +
+			"  {\"id\":" + strconv.FormatInt(tasks[0].ID, 10) + "},\n" +
+			"  {\"id\":" + strconv.FormatInt(tasks[1].ID, 10) + "},\n" +
+			"  {\"id\":" + strconv.FormatInt(tasks[2].ID, 10) + "}\n" +
+			"]\n",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			if err := run(t.Context(), tt.args, &stdout, testEnv(server)); err != nil {
+				t.Fatalf("failed to run: %v", err)
+			}
+			if stdout.String() != tt.want {
+				t.Errorf("unexpected output:\n%s\nexpected:\n%s", stdout.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTimelogsCreate(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	server := twapitest.NewServer(t)
+	project := server.AddProject(projects.Project{Name: "Apollo"})
+	tasklist := server.AddTasklist(projects.Tasklist{Name: "Launch", Project: twapi.Relationship{ID: project.ID}})
+	task := server.AddTask(projects.Task{Name: "Countdown", Tasklist: twapi.Relationship{ID: tasklist.ID}})
+
+	var stdout bytes.Buffer
+	args := []string{
+		"timelogs", "create",
+		"--task", strconv.FormatInt(task.ID, 10),
+		"--hours", "1", "--minutes", "30",
+		"--description", "Checklist",
+		"--output", "json", "--fields", "minutes,description,task.id",
+	}
+	if err := run(t.Context(), args, &stdout, testEnv(server)); err != nil {
+		t.Fatalf("failed to run: %v", err)
+	}
+
+	var created []struct {
+		Minutes     int64  `json:"minutes"`
+		Description string `json:"description"`
+		TaskID      int64  `json:"task.id"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &created); err != nil {
+		t.Fatalf("failed to decode output %q: %v", stdout.String(), err)
+	}
+	if len(created) != 1 || created[0].Minutes != 90 || created[0].Description != "Checklist" ||
+		created[0].TaskID != task.ID {
+		t.Errorf("unexpected timelog %+v", created)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	server := twapitest.NewServer(t)
+
+	invalidConfig := filepath.Join(configDir, "invalid.json")
+	if err := os.WriteFile(invalidConfig, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		getenv  func(string) string
+		wantErr string
+	}{{
+		name:    "unknown command",
+		args:    []string{"projects", "list"},
+		getenv:  testEnv(server),
+		wantErr: `unknown command "projects"`,
+	}, {
+		name:    "unknown action",
+		args:    []string{"timers", "stop"},
+		getenv:  testEnv(server),
+		wantErr: `unknown timers action "stop", one of: start, pause, resume, complete`,
+	}, {
+		name:    "missing credentials",
+		args:    []string{"tasks", "list"},
+		getenv:  func(string) string { return "" },
+		wantErr: "missing credentials",
+	}, {
+		name:    "invalid configuration",
+		args:    []string{"--config", invalidConfig, "tasks", "list"},
+		getenv:  testEnv(server),
+		wantErr: "failed to decode configuration",
+	}, {
+		name:    "unknown output",
+		args:    []string{"tasks", "list", "--output", "xml"},
+		getenv:  testEnv(server),
+		wantErr: `unknown output "xml"`,
+	}, {
+		name:    "missing timer ID",
+		args:    []string{"timers", "pause"},
+		getenv:  testEnv(server),
+		wantErr: "expected the timer ID",
+	}, {
+		name:    "missing duration",
+		args:    []string{"timelogs", "create", "--task", "1"},
+		getenv:  testEnv(server),
+		wantErr: "missing --hours or --minutes",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout bytes.Buffer
+			err := run(t.Context(), tt.args, &stdout, tt.getenv)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestConfigTokenPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"clientId": "apollo"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	paths := make(map[string]bool)
+	for _, clientID := range []string{"apollo", "gemini"} {
+		c, err := loadConfig(path, func(name string) string {
+			if name == "TW_CLIENT_ID" {
+				return clientID
+			}
+			return ""
+		})
+		if err != nil {
+			t.Fatalf("failed to load configuration: %s", err)
+		}
+		if dir := filepath.Dir(c.tokenPath()); dir != filepath.Dir(path) {
+			t.Errorf("expected the token next to the configuration, got %s", dir)
+		}
+		paths[c.tokenPath()] = true
+	}
+	if len(paths) != 2 {
+		t.Errorf("expected a token file per client, got %v", paths)
+	}
+}
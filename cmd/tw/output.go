@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// List of possible output formats.
+const (
+	outputJSON  = "json"
+	outputTable = "table"
+	outputCSV   = "csv"
+)
+
+// printer writes items in the output format selected by the user, one at a
+// time, so lists are printed while their pages load.
+type printer struct {
+	format string
+	fields []string
+	count  int
+
+	out   io.Writer
+	csv   *csv.Writer
+	table *tabwriter.Writer
+}
+
+// newPrinter creates a printer for the format. The fields are the JSON names
+// of the attributes to print, where "tasklist.id" reads a nested attribute.
+// An empty list prints every attribute in JSON, and is rejected for table and
+// CSV.
+func newPrinter(out io.Writer, format string, fields []string) (*printer, error) {
+	p := &printer{format: format, fields: fields, out: out}
+	switch format {
+	case outputJSON:
+	case outputCSV:
+		p.csv = csv.NewWriter(out)
+	case outputTable:
+		p.table = tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	default:
+		return nil, fmt.Errorf("unknown output %q, one of: json, table, csv", format)
+	}
+	if format != outputJSON && len(fields) == 0 {
+		return nil, fmt.Errorf("missing fields for %s output", format)
+	}
+	return p, nil
+}
+
+// print writes an item.
+func (p *printer) print(item any) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to encode item: %w", err)
+	}
+	var attributes map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&attributes); err != nil {
+		return fmt.Errorf("failed to decode item: %w", err)
+	}
+
+	first := p.count == 0
+	p.count++
+	switch p.format {
+	case outputCSV:
+		if first {
+			if err := p.csv.Write(p.fields); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+		}
+		if err := p.csv.Write(p.cells(attributes)); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+		return nil
+	case outputTable:
+		if first {
+			_, _ = fmt.Fprintln(p.table, strings.ToUpper(strings.Join(p.fields, "\t")))
+		}
+		_, err := fmt.Fprintln(p.table, strings.Join(p.cells(attributes), "\t"))
+		return err
+	default:
+		return p.printJSON(first, data, attributes)
+	}
+}
+
+// printJSON writes the item as an element of a JSON array.
+func (p *printer) printJSON(first bool, data []byte, attributes map[string]any) error {
+	if len(p.fields) > 0 {
+		var object bytes.Buffer
+		object.WriteByte('{')
+		for i, field := range p.fields {
+			if i > 0 {
+				object.WriteByte(',')
+			}
+			key, _ := json.Marshal(field)
+			value, err := json.Marshal(lookup(attributes, field))
+			if err != nil {
+				return fmt.Errorf("failed to encode item: %w", err)
+			}
+			object.Write(key)
+			object.WriteByte(':')
+			object.Write(value)
+		}
+		object.WriteByte('}')
+		data = object.Bytes()
+	}
+
+	separator := ",\n  "
+	if first {
+		separator = "[\n  "
+	}
+	if _, err := io.WriteString(p.out, separator); err != nil {
+		return err
+	}
+	_, err := p.out.Write(data)
+	return err
+}
+
+// flush completes the output once every item was printed.
+func (p *printer) flush() error {
+	switch p.format {
+	case outputCSV:
+		p.csv.Flush()
+		return p.csv.Error()
+	case outputTable:
+		return p.table.Flush()
+	default:
+		closing := "\n]\n"
+		if p.count == 0 {
+			closing = "[]\n"
+		}
+		_, err := io.WriteString(p.out, closing)
+		return err
+	}
+}
+
+// cells returns the values of the printed fields as text.
+func (p *printer) cells(attributes map[string]any) []string {
+	cells := make([]string, len(p.fields))
+	for i, field := range p.fields {
+		switch value := lookup(attributes, field).(type) {
+		case nil:
+		case string:
+			cells[i] = value
+		case json.Number:
+			cells[i] = value.String()
+		case bool:
+			cells[i] = fmt.Sprint(value)
+		default:
+			data, _ := json.Marshal(value)
+			cells[i] = string(data)
+		}
+	}
+	return cells
+}
+
+// lookup returns the attribute at the dotted path, or nil.
+func lookup(attributes map[string]any, path string) any {
+	var value any = attributes
+	for key := range strings.SplitSeq(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+// splitFields parses a comma-separated list of fields.
+func splitFields(value string) []string {
+	var fields []string
+	for field := range strings.SplitSeq(value, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
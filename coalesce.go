@@ -0,0 +1,126 @@
+package twapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// WithRequestCoalescing deduplicates identical GET requests sent concurrently
+// through the Engine. Requests are identical when they share the method, URL
+// and credentials, so callers acting on behalf of different users never share
+// a response. The first request is sent upstream, and the callers arriving
+// while it is in flight wait for its response instead of sending their own.
+// Every caller receives its own copy of the response, with a body it can read
+// and close independently.
+//
+// When the request sent upstream fails because its caller gave up, the other
+// callers send the request themselves. Like WithMiddleware, it wraps the client
+// configured so far, and is ignored if WithHTTPClient is used afterwards.
+func WithRequestCoalescing() EngineOption {
+	return func(e *Engine) {
+		group := &coalescingGroup{calls: make(map[string]*coalescedCall)}
+		e.client = &httpClientMiddleware{
+			client:     e.client,
+			middleware: group.middleware,
+		}
+	}
+}
+
+// coalescingGroup tracks the requests in flight.
+type coalescingGroup struct {
+	mutex sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+// coalescedCall is a request in flight, shared by every identical request.
+type coalescedCall struct {
+	done chan struct{}
+	resp *http.Response
+	body []byte
+	err  error
+}
+
+// middleware shares the responses of the next client between identical GET
+// requests in flight.
+func (g *coalescingGroup) middleware(next HTTPClient) HTTPClient {
+	return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodGet {
+			return next.Do(req)
+		}
+		key := cacheKey(req)
+
+		g.mutex.Lock()
+		if call, ok := g.calls[key]; ok {
+			g.mutex.Unlock()
+			return g.wait(req, next, call)
+		}
+		call := &coalescedCall{done: make(chan struct{})}
+		g.calls[key] = call
+		g.mutex.Unlock()
+
+		call.resp, call.body, call.err = send(next, req)
+
+		g.mutex.Lock()
+		delete(g.calls, key)
+		g.mutex.Unlock()
+		close(call.done)
+
+		return call.response(req)
+	})
+}
+
+// wait blocks until the call in flight completes, returning a copy of its
+// response.
+func (g *coalescingGroup) wait(req *http.Request, next HTTPClient, call *coalescedCall) (*http.Response, error) {
+	ctx := req.Context()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-call.done:
+	}
+
+	// the shared request was cancelled by its own caller, not this one
+	if errors.Is(call.err, context.Canceled) || errors.Is(call.err, context.DeadlineExceeded) {
+		resp, body, err := send(next, req)
+		if err != nil {
+			return nil, err
+		}
+		return (&coalescedCall{resp: resp, body: body}).response(req)
+	}
+	return call.response(req)
+}
+
+// send executes the request, reading the whole response body so it can be
+// shared.
+func send(client HTTPClient, req *http.Request) (*http.Response, []byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	return resp, body, nil
+}
+
+// response returns a copy of the shared response for the request.
+func (c *coalescedCall) response(req *http.Request) (*http.Response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	resp := *c.resp
+	resp.Header = c.resp.Header.Clone()
+	resp.Trailer = c.resp.Trailer.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(c.body))
+	resp.ContentLength = int64(len(c.body))
+	resp.Request = req
+	return &resp, nil
+}
@@ -0,0 +1,81 @@
+package twapi_test
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+)
+
+func TestWithRequestCoalescing(t *testing.T) {
+	const callers = 5
+
+	tests := []struct {
+		name         string
+		method       string
+		wantUpstream int64
+	}{{
+		name:         "identical GET requests",
+		method:       http.MethodGet,
+		wantUpstream: 1,
+	}, {
+		name:         "unsafe requests",
+		method:       http.MethodPost,
+		wantUpstream: callers,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var arrived, upstream atomic.Int64
+			engine := newTestEngine(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				upstream.Add(1)
+				// hold the response until every caller is waiting on it
+				for arrived.Load() < callers {
+					time.Sleep(time.Millisecond)
+				}
+				time.Sleep(50 * time.Millisecond)
+				_, _ = io.WriteString(w, `{"task":{"id":1}}`)
+			}),
+				twapi.WithRequestCoalescing(),
+				twapi.WithMiddleware(func(next twapi.HTTPClient) twapi.HTTPClient {
+					return twapi.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+						arrived.Add(1)
+						return next.Do(req)
+					})
+				}),
+			)
+
+			var wg sync.WaitGroup
+			bodies := make([]string, callers)
+			for i := range callers {
+				wg.Go(func() {
+					resp, err := twapi.ExecuteRaw(t.Context(), engine, testRequest{method: tt.method, path: "/tasks/1.json"})
+					if err != nil {
+						t.Errorf("caller %d: failed to execute request: %v", i, err)
+						return
+					}
+					defer func() { _ = resp.Body.Close() }()
+					body, err := io.ReadAll(resp.Body)
+					if err != nil {
+						t.Errorf("caller %d: failed to read body: %v", i, err)
+					}
+					bodies[i] = string(body)
+				})
+			}
+			wg.Wait()
+
+			if got := upstream.Load(); got != tt.wantUpstream {
+				t.Errorf("expected %d upstream requests, got %d", tt.wantUpstream, got)
+			}
+			for i, body := range bodies {
+				if body != `{"task":{"id":1}}` {
+					t.Errorf("caller %d: expected full response body, got %q", i, body)
+				}
+			}
+		})
+	}
+}
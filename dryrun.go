@@ -0,0 +1,174 @@
+package twapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/teamwork/twapi-go-sdk/internal/redact"
+)
+
+// ErrDryRun is returned instead of sending a request when the Engine is in dry
+// run mode. See WithDryRun.
+var ErrDryRun = errors.New("dry run: request not sent")
+
+// DryRunFormat selects how WithDryRun renders the requests.
+type DryRunFormat string
+
+// List of possible formats for DryRunFormat.
+const (
+	// DryRunFormatCurl renders each request as an equivalent curl command.
+	DryRunFormatCurl DryRunFormat = "curl"
+
+	// DryRunFormatJSON renders each request as a JSON object on its own line,
+	// with the method, URL, headers and body.
+	DryRunFormatJSON DryRunFormat = "json"
+)
+
+// DryRunOption configures WithDryRun.
+type DryRunOption func(*dryRun)
+
+// WithDryRunFormat sets the format of the rendered requests. Defaults to
+// DryRunFormatCurl.
+func WithDryRunFormat(format DryRunFormat) DryRunOption {
+	return func(d *dryRun) {
+		d.format = format
+	}
+}
+
+// WithDryRunReads lets GET and HEAD requests through, so scripts can look up
+// the entities they would change. Only the requests with side effects are
+// rendered.
+func WithDryRunReads() DryRunOption {
+	return func(d *dryRun) {
+		d.reads = true
+	}
+}
+
+// WithDryRun puts the Engine in dry run mode. Each request is built and
+// authenticated as usual, then rendered to the writer instead of being sent,
+// and Execute and ExecuteRaw return ErrDryRun. Credentials are redacted from
+// the rendered requests, like WithRequestLogging does.
+//
+//	engine := twapi.NewEngine(session, twapi.WithDryRun(os.Stdout))
+//	_, err := projects.TaskDelete(ctx, engine, projects.NewTaskDeleteRequest(taskID))
+//	if errors.Is(err, twapi.ErrDryRun) {
+//		// the DELETE request was printed to stdout
+//	}
+//
+// Writes are serialized, so the Engine can be shared by concurrent goroutines.
+func WithDryRun(w io.Writer, opts ...DryRunOption) EngineOption {
+	d := &dryRun{writer: w, format: DryRunFormatCurl}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return func(e *Engine) {
+		e.dryRun = d
+	}
+}
+
+// dryRun renders the requests of an Engine in dry run mode.
+type dryRun struct {
+	mu     sync.Mutex
+	writer io.Writer
+	format DryRunFormat
+	reads  bool
+}
+
+// intercept renders the request instead of sending it, returning ErrDryRun. It
+// returns nil when the request must be sent, including on a nil dryRun.
+func (d *dryRun) intercept(req *http.Request) error {
+	if d == nil || (d.reads && (req.Method == http.MethodGet || req.Method == http.MethodHead)) {
+		return nil
+	}
+
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+		_ = req.Body.Close()
+	}
+
+	var rendered []byte
+	switch d.format {
+	case DryRunFormatJSON:
+		var err error
+		if rendered, err = renderJSON(req, body); err != nil {
+			return fmt.Errorf("failed to render request: %w", err)
+		}
+	default:
+		rendered = renderCurl(req, body)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, err := d.writer.Write(rendered); err != nil {
+		return fmt.Errorf("failed to write request: %w", err)
+	}
+	return ErrDryRun
+}
+
+// renderCurl renders the request as a curl command, ending with a new line.
+func renderCurl(req *http.Request, body []byte) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("curl -X " + req.Method + " " + shellQuote(redact.URL(req.URL)))
+
+	header := redact.Header(req.Header)
+	for _, name := range slices.Sorted(maps.Keys(header)) {
+		for _, value := range header[name] {
+			buf.WriteString(" \\\n  -H " + shellQuote(name+": "+value))
+		}
+	}
+	if len(body) > 0 {
+		buf.WriteString(" \\\n  --data-raw " + shellQuote(string(body)))
+	}
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
+
+// renderJSON renders the request as a JSON object, ending with a new line.
+// JSON bodies are embedded as they are, and other bodies as strings.
+func renderJSON(req *http.Request, body []byte) ([]byte, error) {
+	description := struct {
+		Method string          `json:"method"`
+		URL    string          `json:"url"`
+		Header http.Header     `json:"headers"`
+		Body   json.RawMessage `json:"body,omitempty"`
+	}{
+		Method: req.Method,
+		URL:    redact.URL(req.URL),
+		Header: redact.Header(req.Header),
+	}
+	switch {
+	case len(body) == 0:
+		// no body to render
+	case json.Valid(body):
+		description.Body = body
+	default:
+		encoded, err := json.Marshal(string(body))
+		if err != nil {
+			return nil, err
+		}
+		description.Body = encoded
+	}
+
+	rendered, err := json.Marshal(description)
+	if err != nil {
+		return nil, err
+	}
+	return append(rendered, '\n'), nil
+}
+
+// shellQuote quotes the value for a POSIX shell.
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,96 @@
+package twapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/projects"
+)
+
+func TestWithDryRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []twapi.DryRunOption
+		request  testRequest
+		wantSent bool
+		want     string
+	}{{
+		name:    "curl",
+		request: testRequest{method: http.MethodPost, path: "/tasks.json", body: `{"name":"It's done"}`},
+		want: "curl -X POST 'SERVER/tasks.json' \\\n" +
+			"  -H 'Authorization: Bearer REDACTED' \\\n" +
+			"  -H 'Jobroles-Enabled: true' \\\n" +
+			"  --data-raw '{\"name\":\"It'\\''s done\"}'\n",
+	}, {
+		name:    "json",
+		opts:    []twapi.DryRunOption{twapi.WithDryRunFormat(twapi.DryRunFormatJSON)},
+		request: testRequest{method: http.MethodDelete, path: "/tasks/1.json"},
+		want: `{"method":"DELETE","url":"SERVER/tasks/1.json",` +
+			`"headers":{"Authorization":["Bearer REDACTED"],"Jobroles-Enabled":["true"]}}` + "\n",
+	}, {
+		name:     "reads allowed",
+		opts:     []twapi.DryRunOption{twapi.WithDryRunReads()},
+		request:  testRequest{path: "/tasks.json"},
+		wantSent: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sent atomic.Bool
+			var output bytes.Buffer
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				sent.Store(true)
+				w.WriteHeader(http.StatusOK)
+			}))
+			t.Cleanup(server.Close)
+			engine := twapi.NewEngine(testSession{server: server.URL}, twapi.WithDryRun(&output, tt.opts...))
+
+			resp, err := twapi.ExecuteRaw(t.Context(), engine, tt.request)
+			if tt.wantSent {
+				if err != nil {
+					t.Fatalf("expected request to be sent, got %v", err)
+				}
+				_ = resp.Body.Close()
+			} else if !errors.Is(err, twapi.ErrDryRun) {
+				t.Fatalf("expected ErrDryRun, got %v", err)
+			}
+
+			if sent.Load() != tt.wantSent {
+				t.Errorf("expected request sent %t, got %t", tt.wantSent, sent.Load())
+			}
+			if want := strings.ReplaceAll(tt.want, "SERVER", server.URL); output.String() != want {
+				t.Errorf("unexpected rendered request:\n%s\nexpected:\n%s", output.String(), want)
+			}
+		})
+	}
+}
+
+func TestWithDryRunExecute(t *testing.T) {
+	var output bytes.Buffer
+	engine := newTestEngine(t, http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		t.Error("expected no request to be sent")
+	}), twapi.WithDryRun(&output, twapi.WithDryRunFormat(twapi.DryRunFormatJSON)))
+
+	_, err := projects.TaskDelete(t.Context(), engine, projects.NewTaskDeleteRequest(42))
+	if !errors.Is(err, twapi.ErrDryRun) {
+		t.Fatalf("expected ErrDryRun, got %v", err)
+	}
+
+	var rendered struct {
+		Method string `json:"method"`
+		URL    string `json:"url"`
+	}
+	if err := json.Unmarshal(output.Bytes(), &rendered); err != nil {
+		t.Fatalf("failed to decode rendered request: %v", err)
+	}
+	if rendered.Method != http.MethodDelete || !strings.HasSuffix(rendered.URL, "/tasks/42.json") {
+		t.Errorf("unexpected rendered request %+v", rendered)
+	}
+}
@@ -3,6 +3,7 @@ package twapi
 import (
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"reflect"
@@ -39,6 +40,25 @@ type Session interface {
 	Server() string
 }
 
+// RefreshableSession is implemented by sessions whose credentials can be
+// renewed. When the API rejects a request with 401 Unauthorized, the Engine
+// calls Refresh with the rejected request and, if it succeeds, sends the
+// request again with the renewed credentials. This happens at most once per
+// request.
+type RefreshableSession interface {
+	Session
+	Refresh(ctx context.Context, rejected *http.Request) error
+}
+
+// ContextSession is implemented by sessions serving many Teamwork
+// installations, where the server depends on the request being sent. The Engine
+// builds each request against the server returned by ServerContext instead of
+// Server.
+type ContextSession interface {
+	Session
+	ServerContext(ctx context.Context) (string, error)
+}
+
 // httpClientMiddleware is a wrapper around an HTTP client that applies a
 // middleware function to the client.
 type httpClientMiddleware struct {
@@ -57,6 +77,10 @@ type Engine struct {
 	client  HTTPClient
 	session Session
 	logger  *slog.Logger
+	retry   *RetryPolicy
+	limiter *rateLimiter
+	breaker *circuitBreaker
+	dryRun  *dryRun
 }
 
 // EngineOption is a function that modifies the Engine configuration.
@@ -150,21 +174,132 @@ func Execute[R HTTPRequester, T HTTPResponser](ctx context.Context, engine *Engi
 // body. This is useful when using sparse fields, where only a subset of the
 // fields are returned in the response, and the caller needs to handle the
 // response manually.
+//
+// The name of the operation, derived from the requester type, is attached to
+// the request context. See WithOperationContext.
 func ExecuteRaw[R HTTPRequester](ctx context.Context, engine *Engine, requester R) (*http.Response, error) {
-	req, err := requester.HTTPRequest(ctx, engine.session.Server())
+	if _, ok := OperationFromContext(ctx); !ok {
+		if operation := operationName(requester); operation != "" {
+			ctx = WithOperationContext(ctx, operation)
+		}
+	}
+
+	var refreshed bool
+	for attempt := 1; ; attempt++ {
+		req, err := engine.newRequest(ctx, requester)
+		if err != nil {
+			return nil, err
+		}
+		if err := engine.dryRun.intercept(req); err != nil {
+			return nil, err
+		}
+		waited, err := engine.limiter.wait(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to wait for rate limiter: %w", err)
+		}
+		if waited > 0 {
+			engine.logger.Debug("waited for rate limiter",
+				slog.String("method", req.Method),
+				slog.String("url", req.URL.String()),
+				slog.Duration("wait", waited),
+			)
+		}
+
+		// the breaker is checked once nothing can stop the request from being
+		// sent, as a probe let through must always be recorded
+		probe, err := engine.breaker.allow(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute request: %w", err)
+		}
+		resp, err := engine.client.Do(req)
+		engine.limiter.observe(resp)
+		engine.breaker.record(req, probe, resp, err)
+
+		if err == nil && resp.StatusCode == http.StatusUnauthorized && !refreshed && engine.refresh(ctx, req) {
+			refreshed = true
+			engine.discard(resp)
+			continue
+		}
+
+		delay, retry := engine.retry.next(ctx, req, resp, err, attempt)
+		if !retry {
+			if err != nil {
+				return nil, fmt.Errorf("failed to execute request: %w", err)
+			}
+			return resp, nil
+		}
+
+		attrs := []any{
+			slog.String("method", req.Method),
+			slog.String("url", req.URL.String()),
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", delay),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		} else {
+			attrs = append(attrs, slog.Int("status", resp.StatusCode))
+			engine.discard(resp)
+		}
+		engine.logger.Debug("retrying request", attrs...)
+
+		if err := sleep(ctx, delay); err != nil {
+			return nil, fmt.Errorf("failed to execute request: %w", err)
+		}
+	}
+}
+
+// newRequest builds and authenticates a new HTTP request from the requester.
+// It is called once per attempt, so a request body consumed by a previous
+// attempt is never reused.
+func (e *Engine) newRequest(ctx context.Context, requester HTTPRequester) (*http.Request, error) {
+	server := e.session.Server()
+	if session, ok := e.session.(ContextSession); ok {
+		var err error
+		if server, err = session.ServerContext(ctx); err != nil {
+			return nil, fmt.Errorf("failed to resolve server: %w", err)
+		}
+	}
+
+	req, err := requester.HTTPRequest(ctx, server)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	if err := engine.session.Authenticate(ctx, req); err != nil {
+	if err := e.session.Authenticate(ctx, req); err != nil {
 		return nil, fmt.Errorf("failed to authenticate request: %w", err)
 	}
 
 	// Make the API aware that the SDK can handle job roles
 	req.Header.Set("Jobroles-Enabled", "true")
 
-	resp, err := engine.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+	return req, nil
+}
+
+// refresh renews the session credentials after the API rejected them, when the
+// session supports it. It reports whether the request should be sent again.
+func (e *Engine) refresh(ctx context.Context, rejected *http.Request) bool {
+	session, ok := e.session.(RefreshableSession)
+	if !ok {
+		return false
+	}
+	if err := session.Refresh(ctx, rejected); err != nil {
+		e.logger.Warn("failed to refresh session credentials",
+			slog.String("url", rejected.URL.String()),
+			slog.String("error", err.Error()),
+		)
+		return false
+	}
+	return true
+}
+
+// discard drains and closes the body of a response that will not be handed to
+// the caller, so the underlying connection can be reused.
+func (e *Engine) discard(resp *http.Response) {
+	const maxDrain = 64 << 10
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrain))
+	if err := resp.Body.Close(); err != nil {
+		e.logger.Error("failed to close response body",
+			slog.String("error", err.Error()),
+		)
 	}
-	return resp, nil
 }
@@ -0,0 +1,119 @@
+package twapi_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+)
+
+// testSession authenticates requests against a local test server with a fixed
+// bearer token.
+type testSession struct {
+	server string
+}
+
+func (s testSession) Authenticate(_ context.Context, req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer test-token")
+	return nil
+}
+
+func (s testSession) Server() string {
+	return s.server
+}
+
+// testRequest is a minimal twapi.HTTPRequester that sends a request with the
+// given method to path on the session server.
+type testRequest struct {
+	method string
+	path   string
+	body   string
+}
+
+func (r testRequest) HTTPRequest(ctx context.Context, server string) (*http.Request, error) {
+	method := r.method
+	if method == "" {
+		method = http.MethodGet
+	}
+	var body io.Reader
+	if r.body != "" {
+		body = strings.NewReader(r.body)
+	}
+	return http.NewRequestWithContext(ctx, method, server+r.path, body)
+}
+
+// newTestEngine starts a test server with the given handler and returns an
+// engine pointed at it. The server is closed when the test finishes.
+func newTestEngine(t *testing.T, handler http.Handler, opts ...twapi.EngineOption) *twapi.Engine {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return twapi.NewEngine(testSession{server: server.URL}, opts...)
+}
+
+func TestExecuteRawHeaders(t *testing.T) {
+	engine := newTestEngine(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer test-token" {
+			t.Errorf("expected authorization header but got %q", got)
+		}
+		if got := r.Header.Get("Jobroles-Enabled"); got != "true" {
+			t.Errorf("expected Jobroles-Enabled=true but got %q", got)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	resp, err := twapi.ExecuteRaw(t.Context(), engine, testRequest{path: "/ping"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected status %d but got %d", http.StatusNoContent, resp.StatusCode)
+	}
+}
+
+// tenantSession is a twapi.ContextSession that resolves the server from the
+// request context.
+type tenantSession struct {
+	testSession
+}
+
+type tenantServerKey struct{}
+
+func (s tenantSession) ServerContext(ctx context.Context) (string, error) {
+	server, ok := ctx.Value(tenantServerKey{}).(string)
+	if !ok {
+		return "", errors.New("missing tenant")
+	}
+	return server, nil
+}
+
+func TestExecuteRawContextSession(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	engine := twapi.NewEngine(tenantSession{})
+
+	ctx := context.WithValue(t.Context(), tenantServerKey{}, server.URL)
+	resp, err := twapi.ExecuteRaw(ctx, engine, testRequest{path: "/ping"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected status %d but got %d", http.StatusNoContent, resp.StatusCode)
+	}
+
+	if _, err := twapi.ExecuteRaw(t.Context(), engine, testRequest{path: "/ping"}); err == nil {
+		t.Error("expected an error without tenant server")
+	}
+}
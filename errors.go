@@ -0,0 +1,82 @@
+package twapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Sentinel errors matched by HTTPError through errors.Is, according to the
+// status code returned by the API. They allow telling common failures apart
+// without inspecting the status code or the response body:
+//
+//	if errors.Is(err, twapi.ErrNotFound) {
+//		// ...
+//	}
+var (
+	// ErrValidation is matched by 400 Bad Request and 422 Unprocessable Entity
+	// responses. The reasons are usually listed in HTTPError.Errors.
+	ErrValidation = errors.New("validation failed")
+
+	// ErrUnauthorized is matched by 401 Unauthorized responses.
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// ErrForbidden is matched by 403 Forbidden responses.
+	ErrForbidden = errors.New("forbidden")
+
+	// ErrNotFound is matched by 404 Not Found responses.
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict is matched by 409 Conflict responses.
+	ErrConflict = errors.New("conflict")
+
+	// ErrRateLimited is matched by 429 Too Many Requests responses.
+	ErrRateLimited = errors.New("rate limited")
+)
+
+// statusErrors maps the status codes to the sentinel errors they match.
+var statusErrors = map[int]error{
+	http.StatusBadRequest:          ErrValidation,
+	http.StatusUnprocessableEntity: ErrValidation,
+	http.StatusUnauthorized:        ErrUnauthorized,
+	http.StatusForbidden:           ErrForbidden,
+	http.StatusNotFound:            ErrNotFound,
+	http.StatusConflict:            ErrConflict,
+	http.StatusTooManyRequests:     ErrRateLimited,
+}
+
+// APIErrorDetail describes a single error reported by the API in the response
+// body.
+type APIErrorDetail struct {
+	// Title is a short summary of the error.
+	Title string `json:"title"`
+
+	// Detail is a human readable explanation of the error, usually naming the
+	// offending field.
+	Detail string `json:"detail"`
+
+	// Meta contains additional information about the error, such as the
+	// attribute that failed validation. It is only populated by v3 endpoints.
+	Meta map[string]any `json:"meta,omitempty"`
+}
+
+// parseAPIErrorDetails decodes the error details from a response body. It
+// supports the v3 `{"errors":[{"title","detail","meta"}]}` envelope and the v1
+// `{"MESSAGE":"..."}` shape, returning nil for anything else.
+func parseAPIErrorDetails(body []byte) []APIErrorDetail {
+	var payload struct {
+		Errors  []APIErrorDetail `json:"errors"`
+		Message string           `json:"MESSAGE"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil
+	}
+	if len(payload.Errors) > 0 {
+		return payload.Errors
+	}
+	if message := strings.TrimSpace(payload.Message); message != "" {
+		return []APIErrorDetail{{Detail: message}}
+	}
+	return nil
+}
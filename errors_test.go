@@ -0,0 +1,97 @@
+package twapi_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+)
+
+func TestHTTPErrorIs(t *testing.T) {
+	sentinels := []error{
+		twapi.ErrValidation,
+		twapi.ErrUnauthorized,
+		twapi.ErrForbidden,
+		twapi.ErrNotFound,
+		twapi.ErrConflict,
+		twapi.ErrRateLimited,
+	}
+
+	tests := []struct {
+		statusCode int
+		want       error
+	}{
+		{statusCode: http.StatusBadRequest, want: twapi.ErrValidation},
+		{statusCode: http.StatusUnauthorized, want: twapi.ErrUnauthorized},
+		{statusCode: http.StatusForbidden, want: twapi.ErrForbidden},
+		{statusCode: http.StatusNotFound, want: twapi.ErrNotFound},
+		{statusCode: http.StatusConflict, want: twapi.ErrConflict},
+		{statusCode: http.StatusUnprocessableEntity, want: twapi.ErrValidation},
+		{statusCode: http.StatusTooManyRequests, want: twapi.ErrRateLimited},
+		{statusCode: http.StatusInternalServerError, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.statusCode), func(t *testing.T) {
+			// wrapped the same way twapi.Execute reports response errors
+			err := fmt.Errorf("failed to handle response: %w", &twapi.HTTPError{StatusCode: tt.statusCode})
+
+			for _, sentinel := range sentinels {
+				if got, want := errors.Is(err, sentinel), sentinel == tt.want; got != want {
+					t.Errorf("expected errors.Is(err, %q) to be %t", sentinel, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewHTTPErrorDetails(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []twapi.APIErrorDetail
+	}{{
+		name: "v3 envelope",
+		body: `{"errors":[{"title":"Invalid value","detail":"name is required","meta":{"attribute":"name"}}]}`,
+		want: []twapi.APIErrorDetail{{
+			Title:  "Invalid value",
+			Detail: "name is required",
+			Meta:   map[string]any{"attribute": "name"},
+		}},
+	}, {
+		name: "v1 message",
+		body: `{"MESSAGE":"Project name taken","STATUS":"Error"}`,
+		want: []twapi.APIErrorDetail{{
+			Detail: "Project name taken",
+		}},
+	}, {
+		name: "unknown JSON",
+		body: `{"foo":"bar"}`,
+	}, {
+		name: "plain text",
+		body: "Internal Server Error",
+	}, {
+		name: "empty body",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpErr := twapi.NewHTTPError(&http.Response{
+				StatusCode: http.StatusBadRequest,
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			}, "failed")
+
+			if !reflect.DeepEqual(httpErr.Errors, tt.want) {
+				t.Errorf("expected errors %+v but got %+v", tt.want, httpErr.Errors)
+			}
+			if tt.body != "" && httpErr.Details != tt.body {
+				t.Errorf("expected details to keep the raw body %q but got %q", tt.body, httpErr.Details)
+			}
+		})
+	}
+}
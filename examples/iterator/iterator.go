@@ -24,34 +24,36 @@ func main() {
 	session := session.NewBearerToken(*bearerToken, *server)
 	engine := twapi.NewEngine(session)
 
-	next, err := twapi.Iterate[projects.ProjectListRequest, *projects.ProjectListResponse](
-		context.Background(),
+	ctx := context.Background()
+	pages := twapi.All[projects.ProjectListRequest, *projects.ProjectListResponse](
+		ctx,
 		engine,
 		projects.NewProjectListRequest(),
 	)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to create iterator: %v\n", err)
-		os.Exit(1)
-	}
 
 	var iteration int
-	for {
+	for response, err := range pages {
 		iteration++
 		fmt.Printf("🔍 Iteration %d\n", iteration)
 
-		response, hasNext, err := next()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "failed to list projects: %v\n", err)
 			os.Exit(1)
 		}
-		if response == nil {
-			break
-		}
 		for _, project := range response.Projects {
 			fmt.Printf("  ➢ Project: %s (%d)\n", project.Name, project.ID)
 		}
-		if !hasNext {
-			break
+	}
+
+	fmt.Println("🔍 All tasks")
+	items := twapi.AllItems(ctx, engine, projects.NewTaskListRequest(), func(r *projects.TaskListResponse) []projects.Task {
+		return r.Tasks
+	})
+	for task, err := range items {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to list tasks: %v\n", err)
+			os.Exit(1)
 		}
+		fmt.Printf("  ➢ Task: %s (%d)\n", task.Name, task.ID)
 	}
 }
@@ -0,0 +1,196 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// checkpointHeader is the first line of a checkpoint, identifying the export it
+// belongs to.
+type checkpointHeader struct {
+	ProjectID int64  `json:"projectId"`
+	Format    Format `json:"format"`
+}
+
+// checkpointRecord is a line of a checkpoint, appended once a job completed.
+type checkpointRecord struct {
+	// Job is the name of the completed job.
+	Job string `json:"job"`
+
+	// Offsets is the size of the files the job wrote to, once it did.
+	Offsets map[Entity]int64 `json:"offsets,omitempty"`
+
+	// Rows is the number of items the job wrote to each file.
+	Rows map[Entity]int64 `json:"rows,omitempty"`
+
+	// Parents is the IDs of the items that following jobs depend on.
+	Parents []int64 `json:"parents,omitempty"`
+}
+
+// checkpoint is the progress of an export, kept as an NDJSON journal of the
+// completed jobs. Appending a line per job keeps recording progress cheap
+// however large the project is, and a line torn by a crash is dropped when the
+// checkpoint is read back.
+type checkpoint struct {
+	file    *os.File
+	resumed bool
+	jobs    map[string]bool
+	offsets map[Entity]int64
+	rows    map[Entity]int64
+	parents map[string][]int64
+}
+
+// openCheckpoint reads the checkpoint at the path, or creates it for the export
+// described by the header.
+func openCheckpoint(path string, header checkpointHeader) (*checkpoint, error) {
+	c := &checkpoint{
+		jobs:    make(map[string]bool),
+		offsets: make(map[Entity]int64),
+		rows:    make(map[Entity]int64),
+		parents: make(map[string][]int64),
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist), err == nil && len(data) == 0:
+		return c, c.create(path, header)
+	case err != nil:
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	size, err := c.load(data, header)
+	if err != nil {
+		return nil, err
+	}
+	c.file, err = os.OpenFile(path, os.O_WRONLY, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checkpoint: %w", err)
+	}
+	if err := c.file.Truncate(size); err != nil {
+		_ = c.file.Close()
+		return nil, fmt.Errorf("failed to truncate checkpoint: %w", err)
+	}
+	if _, err := c.file.Seek(size, io.SeekStart); err != nil {
+		_ = c.file.Close()
+		return nil, fmt.Errorf("failed to seek checkpoint: %w", err)
+	}
+	c.resumed = true
+	return c, nil
+}
+
+// create creates the checkpoint file, starting with the header.
+func (c *checkpoint) create(path string, header checkpointHeader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	var err error
+	c.file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to create checkpoint: %w", err)
+	}
+	if err := c.append(header); err != nil {
+		_ = c.file.Close()
+		return err
+	}
+	return nil
+}
+
+// load replays the lines of a checkpoint, returning the size of its complete
+// lines.
+func (c *checkpoint) load(data []byte, header checkpointHeader) (int64, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	scanner.Split(scanCompleteLines)
+
+	var size int64
+	for first := true; scanner.Scan(); first = false {
+		line := scanner.Bytes()
+		if first {
+			var stored checkpointHeader
+			if err := json.Unmarshal(line, &stored); err != nil {
+				return 0, fmt.Errorf("failed to decode checkpoint: %w", err)
+			}
+			if stored != header {
+				return 0, fmt.Errorf("checkpoint belongs to the %s export of project %d", stored.Format, stored.ProjectID)
+			}
+		} else {
+			var record checkpointRecord
+			if err := json.Unmarshal(line, &record); err != nil {
+				return 0, fmt.Errorf("failed to decode checkpoint: %w", err)
+			}
+			c.replay(record)
+		}
+		size += int64(len(line)) + 1
+	}
+	if size == 0 {
+		return 0, errors.New("failed to decode checkpoint: missing header")
+	}
+	return size, nil
+}
+
+// scanCompleteLines is a bufio.SplitFunc like bufio.ScanLines, except that it
+// drops a last line without a newline, which was torn while being written.
+func scanCompleteLines(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), nil, bufio.ErrFinalToken
+	}
+	return 0, nil, nil
+}
+
+// done reports whether the job was completed.
+func (c *checkpoint) done(job string) bool {
+	return c.jobs[job]
+}
+
+// record appends the record of a completed job.
+func (c *checkpoint) record(record checkpointRecord) error {
+	if err := c.append(record); err != nil {
+		return err
+	}
+	c.replay(record)
+	return nil
+}
+
+// replay applies the record of a completed job.
+func (c *checkpoint) replay(record checkpointRecord) {
+	c.jobs[record.Job] = true
+	for entity, offset := range record.Offsets {
+		c.offsets[entity] = max(c.offsets[entity], offset)
+	}
+	for entity, rows := range record.Rows {
+		c.rows[entity] += rows
+	}
+	if len(record.Parents) > 0 {
+		c.parents[record.Job] = record.Parents
+	}
+}
+
+// append writes a line to the checkpoint and syncs it.
+func (c *checkpoint) append(line any) error {
+	data, err := json.Marshal(line)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	if _, err := c.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := c.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync checkpoint: %w", err)
+	}
+	return nil
+}
+
+// close closes the checkpoint file.
+func (c *checkpoint) close() error {
+	return c.file.Close()
+}
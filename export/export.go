@@ -0,0 +1,490 @@
+// Package export dumps a project, with everything that belongs to it, into one
+// file per entity, for audits and backups.
+//
+// The project is walked with the list APIs of the projects package, and every
+// item is written as it is returned by the API: as a JSON object per line
+// (NDJSON), or as a CSV row whose columns are the JSON attributes of the
+// entity. Either way, the schema of a file only depends on the entity, so
+// exports of different projects can be loaded side by side.
+//
+// An export is resumable. Progress is recorded in a checkpoint file after every
+// unit of work, and running the export again with the same options continues
+// where the previous run stopped.
+package export
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/projects"
+)
+
+const defaultConcurrency = 4
+
+// Format is the format of the exported files.
+type Format string
+
+// List of possible formats.
+const (
+	// FormatNDJSON writes each item as a JSON object on its own line, in files
+	// with the .ndjson extension.
+	FormatNDJSON Format = "ndjson"
+
+	// FormatCSV writes each item as a row, in files with the .csv extension. The
+	// first row names the columns, one for each JSON attribute of the entity.
+	// Text, numbers and booleans are written as they are, dates in RFC 3339 and
+	// any other value, such as relationships, as compact JSON.
+	FormatCSV Format = "csv"
+)
+
+// Entity is a kind of item exported from a project. It is also the name of the
+// file the items are written to.
+type Entity string
+
+// List of entities exported from a project.
+const (
+	EntityProject           Entity = "project"
+	EntityTasklists         Entity = "tasklists"
+	EntityTasks             Entity = "tasks"
+	EntityMilestones        Entity = "milestones"
+	EntityComments          Entity = "comments"
+	EntityMessages          Entity = "messages"
+	EntityMessageReplies    Entity = "messageReplies"
+	EntityNotebooks         Entity = "notebooks"
+	EntityLinks             Entity = "links"
+	EntityTimelogs          Entity = "timelogs"
+	EntityTags              Entity = "tags"
+	EntityCustomFieldValues Entity = "customFieldValues"
+)
+
+// Entities lists every entity exported by default, in the order they are
+// walked.
+var Entities = []Entity{
+	EntityProject,
+	EntityTasklists,
+	EntityTasks,
+	EntityMilestones,
+	EntityComments,
+	EntityMessages,
+	EntityMessageReplies,
+	EntityNotebooks,
+	EntityLinks,
+	EntityTimelogs,
+	EntityTags,
+	EntityCustomFieldValues,
+}
+
+// Options configures an export. Zero values are replaced by the defaults
+// documented on each field.
+type Options struct {
+	// Dir is the directory the files are written to. It is created if needed.
+	Dir string
+
+	// Format is the format of the files. Defaults to FormatNDJSON.
+	Format Format
+
+	// Concurrency is the maximum number of lists loaded at once. Defaults to 4.
+	Concurrency int
+
+	// Checkpoint is the path of the checkpoint file. Defaults to
+	// checkpoint.ndjson in Dir.
+	Checkpoint string
+
+	// Entities restricts the export to these entities. Comments are exported
+	// for the selected tasks, milestones, notebooks and links, replies for the
+	// selected messages, and custom field values for the project and, when
+	// selected, its tasks. Defaults to Entities.
+	Entities []Entity
+}
+
+// Summary reports the outcome of an export.
+type Summary struct {
+	// Files is the path of the file of each exported entity.
+	Files map[Entity]string
+
+	// Rows is the number of items written for each entity, including the ones
+	// written by the previous runs of a resumed export.
+	Rows map[Entity]int64
+
+	// Resumed indicates whether the export continued from a checkpoint.
+	Resumed bool
+}
+
+// Project exports the project and the entities selected in the options. When
+// the checkpoint file exists, the export resumes from it, and fails if it was
+// created for another project or format. The checkpoint is kept once the
+// export completes, so running it again does nothing; remove the directory to
+// start over.
+func Project(ctx context.Context, engine *twapi.Engine, projectID int64, opts Options) (*Summary, error) {
+	if opts.Dir == "" {
+		return nil, errors.New("missing export directory")
+	}
+	if opts.Format == "" {
+		opts.Format = FormatNDJSON
+	}
+	if opts.Format != FormatNDJSON && opts.Format != FormatCSV {
+		return nil, fmt.Errorf("unknown format %q", opts.Format)
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultConcurrency
+	}
+	if opts.Checkpoint == "" {
+		opts.Checkpoint = filepath.Join(opts.Dir, "checkpoint.ndjson")
+	}
+	if len(opts.Entities) == 0 {
+		opts.Entities = Entities
+	}
+	for _, entity := range opts.Entities {
+		if !slices.Contains(Entities, entity) {
+			return nil, fmt.Errorf("unknown entity %q", entity)
+		}
+	}
+
+	e, err := newExporter(engine, projectID, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer e.close()
+
+	if err := e.run(ctx, e.projectJobs()); err != nil {
+		return nil, err
+	}
+	if err := e.run(ctx, e.childJobs()); err != nil {
+		return nil, err
+	}
+	return e.summary(), nil
+}
+
+// job is a unit of work of an export, completed at once. It returns the items
+// it loaded for each entity, and the IDs of the items the following jobs
+// depend on.
+type job struct {
+	name string
+	load func(ctx context.Context) (rows map[Entity][]any, parents []int64, err error)
+}
+
+// exporter holds the state of a running export.
+type exporter struct {
+	engine    *twapi.Engine
+	projectID int64
+	opts      Options
+	selected  map[Entity]bool
+
+	mutex      sync.Mutex
+	files      map[Entity]*file
+	checkpoint *checkpoint
+}
+
+// newExporter opens the checkpoint and the files of the export.
+func newExporter(engine *twapi.Engine, projectID int64, opts Options) (*exporter, error) {
+	e := &exporter{
+		engine:    engine,
+		projectID: projectID,
+		opts:      opts,
+		selected:  make(map[Entity]bool),
+		files:     make(map[Entity]*file),
+	}
+	for _, entity := range opts.Entities {
+		e.selected[entity] = true
+	}
+
+	var err error
+	e.checkpoint, err = openCheckpoint(opts.Checkpoint, checkpointHeader{ProjectID: projectID, Format: opts.Format})
+	if err != nil {
+		return nil, err
+	}
+	for _, entity := range opts.Entities {
+		path := filepath.Join(opts.Dir, string(entity)+"."+string(opts.Format))
+		f, err := openFile(path, opts.Format, schemas[entity], e.checkpoint.offsets[entity])
+		if err != nil {
+			e.close()
+			return nil, err
+		}
+		e.files[entity] = f
+	}
+	return e, nil
+}
+
+// close closes the checkpoint and the files of the export.
+func (e *exporter) close() {
+	for _, f := range e.files {
+		_ = f.close()
+	}
+	if e.checkpoint != nil {
+		_ = e.checkpoint.close()
+	}
+}
+
+// run runs the jobs not completed yet, at most opts.Concurrency at once,
+// stopping on the first error.
+func (e *exporter) run(ctx context.Context, jobs []job) error {
+	jobs = slices.DeleteFunc(jobs, func(j job) bool {
+		return e.checkpoint.done(j.name)
+	})
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	queue := make(chan job)
+	var wg sync.WaitGroup
+	for range e.opts.Concurrency {
+		wg.Go(func() {
+			for j := range queue {
+				if err := e.complete(ctx, j); err != nil {
+					cancel(err)
+				}
+			}
+		})
+	}
+
+send:
+	for _, j := range jobs {
+		select {
+		case queue <- j:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	return context.Cause(ctx)
+}
+
+// complete runs a job and records its outcome: the items are appended to the
+// files, then the job is marked done in the checkpoint, so a job interrupted
+// in between is run again without duplicating items.
+func (e *exporter) complete(ctx context.Context, j job) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	rows, parents, err := j.load(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to export %s: %w", j.name, err)
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	record := checkpointRecord{
+		Job:     j.name,
+		Offsets: make(map[Entity]int64),
+		Rows:    make(map[Entity]int64),
+		Parents: parents,
+	}
+	for entity, items := range rows {
+		f, ok := e.files[entity]
+		if !ok || len(items) == 0 {
+			continue
+		}
+		offset, err := f.write(items)
+		if err != nil {
+			return fmt.Errorf("failed to export %s: %w", j.name, err)
+		}
+		record.Offsets[entity] = offset
+		record.Rows[entity] = int64(len(items))
+	}
+	if err := e.checkpoint.record(record); err != nil {
+		return fmt.Errorf("failed to export %s: %w", j.name, err)
+	}
+	return nil
+}
+
+// summary reports the files and rows of the export.
+func (e *exporter) summary() *Summary {
+	summary := &Summary{
+		Files:   make(map[Entity]string),
+		Rows:    make(map[Entity]int64),
+		Resumed: e.checkpoint.resumed,
+	}
+	for entity, f := range e.files {
+		summary.Files[entity] = f.path
+		summary.Rows[entity] = e.checkpoint.rows[entity]
+	}
+	return summary
+}
+
+// projectJobs returns the jobs loading the lists of the project. The tasks,
+// milestones, notebooks, links and messages are recorded as the parents of
+// childJobs.
+func (e *exporter) projectJobs() []job {
+	var jobs []job
+	if e.selected[EntityProject] {
+		jobs = append(jobs, job{name: string(EntityProject), load: e.loadProject})
+	}
+	if e.selected[EntityTasklists] {
+		req := projects.NewTasklistListRequest()
+		req.Path.ProjectID = e.projectID
+		req.Filters.ShowCompleted = new(true)
+		jobs = append(jobs, listJob(e, EntityTasklists, req, func(r *projects.TasklistListResponse) []projects.Tasklist { return r.Tasklists }, nil))
+	}
+	if e.selected[EntityTasks] {
+		jobs = append(jobs, job{name: string(EntityTasks), load: e.loadTasks})
+	}
+	if e.selected[EntityMilestones] {
+		req := projects.NewMilestoneListRequest()
+		req.Path.ProjectID = e.projectID
+		jobs = append(jobs, listJob(e, EntityMilestones, req, func(r *projects.MilestoneListResponse) []projects.Milestone { return r.Milestones }, func(m projects.Milestone) int64 {
+			return m.ID
+		}))
+	}
+	if e.selected[EntityMessages] {
+		req := projects.NewMessageListRequest()
+		req.Filters.ProjectIDs = []int64{e.projectID}
+		jobs = append(jobs, listJob(e, EntityMessages, req, func(r *projects.MessageListResponse) []projects.Message { return r.Messages }, func(m projects.Message) int64 {
+			return m.ID
+		}))
+	}
+	if e.selected[EntityNotebooks] {
+		req := projects.NewNotebookListRequest()
+		req.Filters.ProjectIDs = []int64{e.projectID}
+		req.Filters.IncludeContents = new(true)
+		jobs = append(jobs, listJob(e, EntityNotebooks, req, func(r *projects.NotebookListResponse) []projects.Notebook { return r.Notebooks }, func(n projects.Notebook) int64 {
+			return n.ID
+		}))
+	}
+	if e.selected[EntityLinks] {
+		req := projects.NewLinkListRequest()
+		req.Filters.ProjectID = e.projectID
+		jobs = append(jobs, listJob(e, EntityLinks, req, func(r *projects.LinkListResponse) []projects.Link { return r.Links }, func(l projects.Link) int64 {
+			return int64(l.ID)
+		}))
+	}
+	if e.selected[EntityTimelogs] {
+		req := projects.NewTimelogListRequest()
+		req.Path.ProjectID = e.projectID
+		jobs = append(jobs, listJob(e, EntityTimelogs, req, func(r *projects.TimelogListResponse) []projects.Timelog { return r.Timelogs }, nil))
+	}
+	if e.selected[EntityTags] {
+		req := projects.NewTagListRequest()
+		req.Filters.ProjectIDs = []int64{e.projectID}
+		jobs = append(jobs, listJob(e, EntityTags, req, func(r *projects.TagListResponse) []projects.Tag { return r.Tags }, nil))
+	}
+	if e.selected[EntityCustomFieldValues] {
+		req := projects.NewProjectCustomFieldValueListRequest(e.projectID)
+		j := listJob(e, EntityCustomFieldValues, req, func(r *projects.CustomFieldValueListResponse) []projects.CustomFieldValue { return r.CustomFieldValues }, nil)
+		j.name = string(EntityCustomFieldValues) + "/project"
+		jobs = append(jobs, j)
+	}
+	return jobs
+}
+
+// childJobs returns the jobs loading the items that belong to the ones listed
+// by projectJobs: the comments of every task, milestone, notebook and link,
+// and the replies of every message. There is a job for each parent, so a
+// resumed export does not load them again.
+func (e *exporter) childJobs() []job {
+	var jobs []job
+	if e.selected[EntityComments] {
+		parents := []struct {
+			entity Entity
+			path   func(*projects.CommentListRequestPath, int64)
+		}{
+			{EntityTasks, func(p *projects.CommentListRequestPath, id int64) { p.TaskID = id }},
+			{EntityMilestones, func(p *projects.CommentListRequestPath, id int64) { p.MilestoneID = id }},
+			{EntityNotebooks, func(p *projects.CommentListRequestPath, id int64) { p.NotebookID = id }},
+			{EntityLinks, func(p *projects.CommentListRequestPath, id int64) { p.LinkID = id }},
+		}
+		for _, parent := range parents {
+			for _, id := range e.checkpoint.parents[string(parent.entity)] {
+				req := projects.NewCommentListRequest()
+				parent.path(&req.Path, id)
+				j := listJob(e, EntityComments, req, func(r *projects.CommentListResponse) []projects.Comment { return r.Comments }, nil)
+				j.name = fmt.Sprintf("%s/%s/%d", EntityComments, parent.entity, id)
+				jobs = append(jobs, j)
+			}
+		}
+	}
+	if e.selected[EntityMessageReplies] {
+		for _, id := range e.checkpoint.parents[string(EntityMessages)] {
+			req := projects.NewMessageReplyListRequest()
+			req.Path.MessageID = id
+			j := listJob(e, EntityMessageReplies, req, func(r *projects.MessageReplyListResponse) []projects.MessageReply { return r.MessageReplies }, nil)
+			j.name = fmt.Sprintf("%s/%d", EntityMessageReplies, id)
+			jobs = append(jobs, j)
+		}
+	}
+	return jobs
+}
+
+// listJob creates the job writing every item of a list to the file of the
+// entity, taken from every page with items. When id is set, the IDs of the
+// items are recorded as parents.
+func listJob[T twapi.HTTPRequester, R interface {
+	twapi.HTTPResponser
+	Iterate() *T
+}, I any](
+	e *exporter,
+	entity Entity,
+	req T,
+	items func(R) []I,
+	id func(I) int64,
+) job {
+	return job{
+		name: string(entity),
+		load: func(ctx context.Context) (map[Entity][]any, []int64, error) {
+			var rows []any
+			var parents []int64
+			for item, err := range twapi.AllItems(ctx, e.engine, req, items) {
+				if err != nil {
+					return nil, nil, err
+				}
+				rows = append(rows, item)
+				if id != nil {
+					parents = append(parents, id(item))
+				}
+			}
+			return map[Entity][]any{entity: rows}, parents, nil
+		},
+	}
+}
+
+// loadProject loads the project itself.
+func (e *exporter) loadProject(ctx context.Context) (map[Entity][]any, []int64, error) {
+	response, err := projects.ProjectGet(ctx, e.engine, projects.NewProjectGetRequest(e.projectID))
+	if err != nil {
+		return nil, nil, err
+	}
+	return map[Entity][]any{EntityProject: {response.Project}}, nil, nil
+}
+
+// loadTasks loads every task of the project, completed or not, including
+// subtasks and the IDs of their predecessors. The custom field values of the
+// tasks are sideloaded with them.
+func (e *exporter) loadTasks(ctx context.Context) (map[Entity][]any, []int64, error) {
+	req := projects.NewTaskListRequest()
+	req.Path.ProjectID = e.projectID
+	req.Filters.IncludeCompletedTasks = new(true)
+	req.Filters.IncludeTasksFromCompletedTasklists = new(true)
+	req.Filters.IncludeRelatedTasks = true
+	req.Filters.IncludeCompletedPredecessors = true
+	if e.selected[EntityCustomFieldValues] {
+		req.Filters.Include = []projects.TaskRequestSideload{projects.TaskRequestSideloadCustomFieldValues}
+	}
+
+	rows := make(map[Entity][]any)
+	var parents []int64
+	for page, err := range twapi.All[projects.TaskListRequest, *projects.TaskListResponse](ctx, e.engine, req) {
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, task := range page.Tasks {
+			rows[EntityTasks] = append(rows[EntityTasks], task)
+			parents = append(parents, task.ID)
+		}
+		values := slices.SortedFunc(maps.Values(page.Included.CustomFieldValues), func(a, b projects.CustomFieldValue) int {
+			return cmp.Compare(a.ID, b.ID)
+		})
+		for _, value := range values {
+			rows[EntityCustomFieldValues] = append(rows[EntityCustomFieldValues], value)
+		}
+	}
+	return rows, parents, nil
+}
@@ -0,0 +1,318 @@
+package export_test
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/export"
+	"github.com/teamwork/twapi-go-sdk/projects"
+	"github.com/teamwork/twapi-go-sdk/twapitest"
+)
+
+// testEntities are the entities the fake server models.
+var testEntities = []export.Entity{
+	export.EntityProject,
+	export.EntityTasklists,
+	export.EntityTasks,
+	export.EntityMilestones,
+	export.EntityComments,
+	export.EntityTimelogs,
+	export.EntityTags,
+}
+
+// testProject is a project added to the fake server, with the IDs of its
+// items.
+type testProject struct {
+	id       int64
+	tasks    []int64
+	comments []int64
+}
+
+// addTestProject adds a project to the fake server, with two tasks, a subtask
+// and comments on them.
+func addTestProject(server *twapitest.Server) testProject {
+	project := server.AddProject(projects.Project{Name: "Apollo"})
+	tasklist := server.AddTasklist(projects.Tasklist{Name: "Launch", Project: twapi.Relationship{ID: project.ID}})
+	countdown := server.AddTask(projects.Task{Name: "Countdown", Tasklist: twapi.Relationship{ID: tasklist.ID}})
+	ignition := server.AddTask(projects.Task{
+		Name:     "Ignition, liftoff",
+		Tasklist: twapi.Relationship{ID: tasklist.ID},
+	})
+	checklist := server.AddTask(projects.Task{
+		Name:       "Checklist",
+		Tasklist:   twapi.Relationship{ID: tasklist.ID},
+		ParentTask: &twapi.Relationship{ID: countdown.ID, Type: "tasks"},
+	})
+	server.AddMilestone(projects.Milestone{Name: "Launch day", Project: twapi.Relationship{ID: project.ID}})
+	server.AddTimelog(projects.Timelog{
+		Minutes: 30,
+		Task:    &twapi.Relationship{ID: countdown.ID, Type: "tasks"},
+		Project: twapi.Relationship{ID: project.ID, Type: "projects"},
+	})
+	server.AddTag(projects.Tag{Name: "critical", Project: &twapi.Relationship{ID: project.ID}})
+
+	result := testProject{id: project.ID, tasks: []int64{countdown.ID, ignition.ID, checklist.ID}}
+	for _, task := range result.tasks {
+		comment := server.AddComment(projects.Comment{
+			Body:    "Go for launch",
+			Object:  &twapi.Relationship{ID: task, Type: "tasks"},
+			Project: twapi.Relationship{ID: project.ID},
+		})
+		result.comments = append(result.comments, comment.ID)
+	}
+	return result
+}
+
+func TestProject(t *testing.T) {
+	server := twapitest.NewServer(t)
+	project := addTestProject(server)
+
+	tests := []struct {
+		name   string
+		format export.Format
+		ids    func(t *testing.T, path string) []int64
+	}{{
+		name:   "ndjson",
+		format: export.FormatNDJSON,
+		ids:    readNDJSONIDs,
+	}, {
+		name:   "csv",
+		format: export.FormatCSV,
+		ids:    readCSVIDs,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, err := export.Project(t.Context(), server.Engine(), project.id, export.Options{
+				Dir:      t.TempDir(),
+				Format:   tt.format,
+				Entities: testEntities,
+			})
+			if err != nil {
+				t.Fatalf("failed to export: %v", err)
+			}
+			if summary.Resumed {
+				t.Error("expected a new export")
+			}
+
+			wantRows := map[export.Entity]int64{
+				export.EntityProject:    1,
+				export.EntityTasklists:  1,
+				export.EntityTasks:      3,
+				export.EntityMilestones: 1,
+				export.EntityComments:   3,
+				export.EntityTimelogs:   1,
+				export.EntityTags:       1,
+			}
+			for entity, want := range wantRows {
+				if got := summary.Rows[entity]; got != want {
+					t.Errorf("expected %d %s, got %d", want, entity, got)
+				}
+				path := summary.Files[entity]
+				if filepath.Ext(path) != "."+string(tt.format) {
+					t.Errorf("unexpected file %q for %s", path, entity)
+				}
+				if got := tt.ids(t, path); int64(len(got)) != want {
+					t.Errorf("expected %d %s in %s, got %v", want, entity, path, got)
+				}
+			}
+			if got := tt.ids(t, summary.Files[export.EntityTasks]); !equalIDs(got, project.tasks) {
+				t.Errorf("expected tasks %v, got %v", project.tasks, got)
+			}
+			if got := tt.ids(t, summary.Files[export.EntityComments]); !equalIDs(got, project.comments) {
+				t.Errorf("expected comments %v, got %v", project.comments, got)
+			}
+		})
+	}
+}
+
+func TestProjectCSVSchema(t *testing.T) {
+	server := twapitest.NewServer(t)
+	project := addTestProject(server)
+
+	summary, err := export.Project(t.Context(), server.Engine(), project.id, export.Options{
+		Dir:      t.TempDir(),
+		Format:   export.FormatCSV,
+		Entities: []export.Entity{export.EntityTasks},
+	})
+	if err != nil {
+		t.Fatalf("failed to export: %v", err)
+	}
+
+	records := readCSV(t, summary.Files[export.EntityTasks])
+	header := records[0]
+	for _, column := range []string{"id", "name", "parentTask", "predecessors", "tasklist"} {
+		if !slices.Contains(header, column) {
+			t.Errorf("expected column %q in %v", column, header)
+		}
+	}
+	row := make(map[string]string)
+	for i, column := range header {
+		row[column] = records[3][i]
+	}
+	if row["name"] != "Checklist" {
+		t.Errorf("expected text as is, got %q", row["name"])
+	}
+	var parent twapi.Relationship
+	if err := json.Unmarshal([]byte(row["parentTask"]), &parent); err != nil || parent.ID != project.tasks[0] {
+		t.Errorf("expected the parent task as JSON, got %q", row["parentTask"])
+	}
+}
+
+func TestProjectResume(t *testing.T) {
+	server := twapitest.NewServer(t)
+	project := addTestProject(server)
+	dir := t.TempDir()
+	opts := export.Options{Dir: dir, Concurrency: 1, Entities: testEntities}
+
+	// the comments of the last task fail the first time
+	failing := "/projects/api/v3/tasks/" + strconv.FormatInt(project.tasks[2], 10) + "/comments.json"
+	var fail atomic.Bool
+	fail.Store(true)
+	var requests atomic.Int64
+	engine := server.Engine(twapi.WithMiddleware(func(next twapi.HTTPClient) twapi.HTTPClient {
+		return twapi.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			requests.Add(1)
+			if req.URL.Path == failing && fail.Load() {
+				return nil, errors.New("connection reset")
+			}
+			return next.Do(req)
+		})
+	}))
+
+	if _, err := export.Project(t.Context(), engine, project.id, opts); err == nil ||
+		!strings.Contains(err.Error(), "connection reset") {
+		t.Fatalf("expected the export to fail, got %v", err)
+	}
+
+	// a job interrupted after writing its items is run again from scratch
+	comments := filepath.Join(dir, "comments.ndjson")
+	file, err := os.OpenFile(comments, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString(`{"id":1}` + "\n{\"id\""); err != nil {
+		t.Fatal(err)
+	}
+	_ = file.Close()
+
+	fail.Store(false)
+	requests.Store(0)
+	summary, err := export.Project(t.Context(), engine, project.id, opts)
+	if err != nil {
+		t.Fatalf("failed to resume export: %v", err)
+	}
+	if !summary.Resumed {
+		t.Error("expected a resumed export")
+	}
+	// the failed comments, then the comments of the milestone that were never
+	// loaded
+	if got := requests.Load(); got != 2 {
+		t.Errorf("expected only the remaining lists to be loaded, got %d requests", got)
+	}
+	if got := readNDJSONIDs(t, comments); !equalIDs(got, project.comments) {
+		t.Errorf("expected comments %v, got %v", project.comments, got)
+	}
+	if got := summary.Rows[export.EntityComments]; got != 3 {
+		t.Errorf("expected 3 comments, got %d", got)
+	}
+
+	// a completed export does nothing
+	requests.Store(0)
+	if _, err := export.Project(t.Context(), engine, project.id, opts); err != nil {
+		t.Fatalf("failed to run completed export: %v", err)
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("expected no requests, got %d", got)
+	}
+
+	// the checkpoint belongs to the project
+	if _, err := export.Project(t.Context(), engine, project.id+1, opts); err == nil ||
+		!strings.Contains(err.Error(), "checkpoint belongs to the ndjson export of project") {
+		t.Errorf("expected checkpoint mismatch, got %v", err)
+	}
+}
+
+// readNDJSONIDs returns the IDs of the items of an NDJSON file.
+func readNDJSONIDs(t *testing.T, path string) []int64 {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var ids []int64
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var item struct {
+			ID int64 `json:"id"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			t.Fatalf("failed to decode line %q of %s: %v", scanner.Text(), path, err)
+		}
+		ids = append(ids, item.ID)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	return ids
+}
+
+// readCSVIDs returns the IDs of the rows of a CSV file.
+func readCSVIDs(t *testing.T, path string) []int64 {
+	t.Helper()
+
+	records := readCSV(t, path)
+	column := slices.Index(records[0], "id")
+	if column < 0 {
+		t.Fatalf("missing id column in %s", path)
+	}
+	var ids []int64
+	for _, record := range records[1:] {
+		var id int64
+		if err := json.Unmarshal([]byte(record[column]), &id); err != nil {
+			t.Fatalf("invalid ID %q in %s", record[column], path)
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// readCSV reads every record of a CSV file.
+func readCSV(t *testing.T, path string) [][]string {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	if len(records) == 0 {
+		t.Fatalf("missing header in %s", path)
+	}
+	return records
+}
+
+// equalIDs reports whether both lists have the same IDs, in any order.
+func equalIDs(a, b []int64) bool {
+	return slices.Equal(slices.Sorted(slices.Values(a)), slices.Sorted(slices.Values(b)))
+}
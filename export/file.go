@@ -0,0 +1,166 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/teamwork/twapi-go-sdk/projects"
+)
+
+// schemas are the CSV columns of each entity: the JSON attributes of its type,
+// in the order they are declared.
+var schemas = map[Entity][]string{
+	EntityProject:           columns(reflect.TypeFor[projects.Project]()),
+	EntityTasklists:         columns(reflect.TypeFor[projects.Tasklist]()),
+	EntityTasks:             columns(reflect.TypeFor[projects.Task]()),
+	EntityMilestones:        columns(reflect.TypeFor[projects.Milestone]()),
+	EntityComments:          columns(reflect.TypeFor[projects.Comment]()),
+	EntityMessages:          columns(reflect.TypeFor[projects.Message]()),
+	EntityMessageReplies:    columns(reflect.TypeFor[projects.MessageReply]()),
+	EntityNotebooks:         columns(reflect.TypeFor[projects.Notebook]()),
+	EntityLinks:             columns(reflect.TypeFor[projects.Link]()),
+	EntityTimelogs:          columns(reflect.TypeFor[projects.Timelog]()),
+	EntityTags:              columns(reflect.TypeFor[projects.Tag]()),
+	EntityCustomFieldValues: columns(reflect.TypeFor[projects.CustomFieldValue]()),
+}
+
+// columns returns the JSON attributes of a struct type, following embedded
+// structs like encoding/json does.
+func columns(t reflect.Type) []string {
+	var names []string
+	for field := range t.Fields() {
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			names = append(names, columns(field.Type)...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// file is the file items of an entity are appended to.
+type file struct {
+	path    string
+	format  Format
+	columns []string
+	file    *os.File
+	offset  int64
+}
+
+// openFile opens the file of an entity, truncated to the offset the checkpoint
+// recorded, which drops the items of an interrupted job. Without an offset, the
+// file is created anew, starting with the CSV header.
+func openFile(path string, format Format, columns []string, offset int64) (*file, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open export file: %w", err)
+	}
+	if err := f.Truncate(offset); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to truncate export file: %w", err)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to seek export file: %w", err)
+	}
+
+	result := &file{path: path, format: format, columns: columns, file: f, offset: offset}
+	if offset == 0 && format == FormatCSV {
+		var header bytes.Buffer
+		writer := csv.NewWriter(&header)
+		_ = writer.Write(columns)
+		writer.Flush()
+		if _, err := result.append(header.Bytes()); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// write appends the items and syncs the file, returning the offset of its end.
+func (f *file) write(items []any) (int64, error) {
+	var data bytes.Buffer
+	writer := csv.NewWriter(&data)
+	for _, item := range items {
+		encoded, err := json.Marshal(item)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode item: %w", err)
+		}
+		if f.format == FormatNDJSON {
+			data.Write(encoded)
+			data.WriteByte('\n')
+			continue
+		}
+		row, err := f.row(encoded)
+		if err != nil {
+			return 0, err
+		}
+		_ = writer.Write(row)
+	}
+	writer.Flush()
+	return f.append(data.Bytes())
+}
+
+// row returns the CSV cells of an item encoded as JSON.
+func (f *file) row(encoded []byte) ([]string, error) {
+	var attributes map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &attributes); err != nil {
+		return nil, fmt.Errorf("failed to decode item: %w", err)
+	}
+	row := make([]string, len(f.columns))
+	for i, column := range f.columns {
+		value := attributes[column]
+		switch {
+		case len(value) == 0, string(value) == "null":
+		case value[0] == '"':
+			var text string
+			if err := json.Unmarshal(value, &text); err != nil {
+				return nil, fmt.Errorf("failed to decode item: %w", err)
+			}
+			row[i] = text
+		default:
+			row[i] = string(value)
+		}
+	}
+	return row, nil
+}
+
+// append writes the data at the end of the file and syncs it, so the offset
+// recorded in the checkpoint is never ahead of the file.
+func (f *file) append(data []byte) (int64, error) {
+	if _, err := f.file.Write(data); err != nil {
+		return 0, fmt.Errorf("failed to write export file: %w", err)
+	}
+	if err := f.file.Sync(); err != nil {
+		return 0, fmt.Errorf("failed to sync export file: %w", err)
+	}
+	f.offset += int64(len(data))
+	return f.offset, nil
+}
+
+// close closes the file.
+func (f *file) close() error {
+	return f.file.Close()
+}
@@ -1,6 +1,11 @@
 package twapi
 
-import "context"
+import (
+	"context"
+	"iter"
+)
+
+const defaultPrefetch = 4
 
 // Iterate allows scanning through paginated results from the Teamwork API.
 func Iterate[T HTTPRequester, R interface {
@@ -23,3 +28,203 @@ func Iterate[T HTTPRequester, R interface {
 	}
 	return next, nil
 }
+
+// All returns an iterator over the pages of a paginated list, starting from the
+// page selected by req. The following pages are only loaded as the iteration
+// progresses. When a page fails to load, the error is yielded and the iteration
+// stops.
+//
+//	for page, err := range twapi.All[projects.ProjectListRequest, *projects.ProjectListResponse](ctx, engine, req) {
+//		if err != nil {
+//			return err
+//		}
+//		// ...
+//	}
+func All[T HTTPRequester, R interface {
+	HTTPResponser
+	Iterate() *T
+}](ctx context.Context, e *Engine, req T) iter.Seq2[R, error] {
+	return func(yield func(R, error) bool) {
+		next, err := Iterate[T, R](ctx, e, req)
+		if err != nil {
+			var zero R
+			yield(zero, err)
+			return
+		}
+		for {
+			response, hasNext, err := next()
+			if err != nil {
+				yield(response, err)
+				return
+			}
+			if !yield(response, nil) || !hasNext {
+				return
+			}
+		}
+	}
+}
+
+// AllItems returns an iterator over the items of a paginated list, which items
+// extracts from each page loaded by All. The following pages are only loaded as
+// the iteration progresses. When a page fails to load, the error is yielded and
+// the iteration stops.
+//
+//	tasks := twapi.AllItems(ctx, engine, projects.NewTaskListRequest(),
+//		func(r *projects.TaskListResponse) []projects.Task { return r.Tasks },
+//	)
+//	for task, err := range tasks {
+//		if err != nil {
+//			return err
+//		}
+//		// ...
+//	}
+func AllItems[T HTTPRequester, R interface {
+	HTTPResponser
+	Iterate() *T
+}, I any](ctx context.Context, e *Engine, req T, items func(R) []I) iter.Seq2[I, error] {
+	return func(yield func(I, error) bool) {
+		for page, err := range All[T, R](ctx, e, req) {
+			if err != nil {
+				var zero I
+				yield(zero, err)
+				return
+			}
+			for _, item := range items(page) {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// IterateOption configures IterateConcurrent.
+type IterateOption func(*iterateOptions)
+
+type iterateOptions struct {
+	prefetch int
+}
+
+// WithPrefetch sets how many pages IterateConcurrent loads ahead of the page
+// being consumed. It bounds both the number of concurrent requests and the
+// number of pages held in memory. Defaults to 4.
+func WithPrefetch(pages int) IterateOption {
+	return func(o *iterateOptions) {
+		if pages > 0 {
+			o.prefetch = pages
+		}
+	}
+}
+
+// IterateConcurrent returns an iterator over the pages of a paginated list,
+// like All, but loads the following pages concurrently when the number of pages
+// is known up front. That is the case when the list response implements
+// IterateRemaining and the API reported ListMeta.Page.Count, so requests should
+// use ListCountModeExact or ListCountModeDefault. Under ListCountModeSkip the
+// pages are loaded one at a time, following ListMeta.Page.HasMore. Either way,
+// pages are yielded in order.
+//
+// When a page fails to load, the error is yielded and the iteration stops.
+func IterateConcurrent[T HTTPRequester, R interface {
+	HTTPResponser
+	Iterate() *T
+}](ctx context.Context, e *Engine, req T, opts ...IterateOption) iter.Seq2[R, error] {
+	options := iterateOptions{prefetch: defaultPrefetch}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return func(yield func(R, error) bool) {
+		response, err := Execute[T, R](ctx, e, req)
+		if err != nil {
+			yield(response, err)
+			return
+		}
+		if !yield(response, nil) {
+			return
+		}
+
+		if remaining, ok := any(response).(interface{ IterateRemaining() []T }); ok {
+			requests := remaining.IterateRemaining()
+			if len(requests) > 0 {
+				var ok bool
+				if response, ok = prefetch[T, R](ctx, e, requests, options.prefetch, yield); !ok {
+					return
+				}
+			}
+		}
+
+		// continue one page at a time when the number of pages is unknown, or
+		// when more items showed up while the known pages were loading
+		nextRequest := response.Iterate()
+		if nextRequest == nil {
+			return
+		}
+		for page, err := range All[T, R](ctx, e, *nextRequest) {
+			if !yield(page, err) {
+				return
+			}
+		}
+	}
+}
+
+// prefetch loads the pages of requests with up to limit requests in flight,
+// yielding them in order. It returns the last response and whether the
+// iteration should continue.
+func prefetch[T HTTPRequester, R HTTPResponser](
+	ctx context.Context,
+	e *Engine,
+	requests []T,
+	limit int,
+	yield func(R, error) bool,
+) (R, bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		response R
+		err      error
+	}
+	results := make([]chan result, len(requests))
+	for i := range results {
+		results[i] = make(chan result, 1)
+	}
+
+	// a slot is taken when a page starts loading and given back once the page
+	// is consumed, so at most limit pages are in flight or waiting in memory
+	slots := make(chan struct{}, limit)
+	go func() {
+		for i, req := range requests {
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			go func() {
+				response, err := Execute[T, R](ctx, e, req)
+				results[i] <- result{response: response, err: err}
+			}()
+		}
+	}()
+
+	var last R
+	for i := range requests {
+		select {
+		case res := <-results[i]:
+			<-slots
+			if res.err != nil {
+				yield(res.response, res.err)
+				return last, false
+			}
+			if !yield(res.response, nil) {
+				return last, false
+			}
+			last = res.response
+		case <-ctx.Done():
+			var zero R
+			yield(zero, ctx.Err())
+			return last, false
+		}
+	}
+	return last, true
+}
@@ -0,0 +1,247 @@
+package twapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+)
+
+// testListRequest requests a page of a paginated test list.
+type testListRequest struct {
+	page int64
+}
+
+func (r testListRequest) HTTPRequest(ctx context.Context, server string) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, http.MethodGet, server+"/items?page="+strconv.FormatInt(r.page, 10), nil)
+}
+
+// testListResponse is a page of a paginated test list.
+type testListResponse struct {
+	request testListRequest
+
+	Meta  twapi.ListMeta `json:"meta"`
+	Items []int64        `json:"items"`
+}
+
+func (r *testListResponse) HandleHTTPResponse(resp *http.Response) error {
+	if resp.StatusCode != http.StatusOK {
+		return twapi.NewHTTPError(resp, "failed to list items")
+	}
+	return json.NewDecoder(resp.Body).Decode(r)
+}
+
+func (r *testListResponse) SetRequest(req testListRequest) {
+	r.request = req
+}
+
+func (r *testListResponse) Iterate() *testListRequest {
+	if !r.Meta.Page.HasMore {
+		return nil
+	}
+	req := r.request
+	req.page++
+	return &req
+}
+
+func (r *testListResponse) IterateRemaining() []testListRequest {
+	var requests []testListRequest
+	for page := range r.Meta.RemainingPages(r.request.page, testListPageSize) {
+		requests = append(requests, testListRequest{page: page})
+	}
+	return requests
+}
+
+// testListPageSize is the number of items served in each page of the test list.
+const testListPageSize = 2
+
+// testListHandler serves pages of two items, up to the given number of pages.
+// Requests for the failing page are answered with an internal server error.
+func testListHandler(pages, failing int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+		if page == failing {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"meta":{"page":{"count":%d,"hasMore":%t}},"items":[%d,%d]}`,
+			pages*2, page < pages, page*2-1, page*2)
+	})
+}
+
+func TestAll(t *testing.T) {
+	engine := newTestEngine(t, testListHandler(3, 0))
+
+	var items []int64
+	for page, err := range twapi.All[testListRequest, *testListResponse](t.Context(), engine, testListRequest{page: 1}) {
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		items = append(items, page.Items...)
+	}
+
+	if want := []int64{1, 2, 3, 4, 5, 6}; fmt.Sprint(items) != fmt.Sprint(want) {
+		t.Errorf("expected items %v but got %v", want, items)
+	}
+}
+
+func TestAllError(t *testing.T) {
+	engine := newTestEngine(t, testListHandler(3, 2))
+
+	var pages, failures int
+	for _, err := range twapi.All[testListRequest, *testListResponse](t.Context(), engine, testListRequest{page: 1}) {
+		if err != nil {
+			failures++
+			if !errors.As(err, new(*twapi.HTTPError)) {
+				t.Errorf("expected an HTTP error but got %s", err)
+			}
+			continue
+		}
+		pages++
+	}
+
+	if pages != 1 || failures != 1 {
+		t.Errorf("expected 1 page and 1 failure but got %d pages and %d failures", pages, failures)
+	}
+}
+
+func TestAllBreak(t *testing.T) {
+	var requests int
+	engine := newTestEngine(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		testListHandler(3, 0).ServeHTTP(w, r)
+	}))
+
+	for range twapi.All[testListRequest, *testListResponse](t.Context(), engine, testListRequest{page: 1}) {
+		break
+	}
+
+	if requests != 1 {
+		t.Errorf("expected a single request when breaking out of the loop but got %d", requests)
+	}
+}
+
+func TestAllItems(t *testing.T) {
+	var requests int
+	engine := newTestEngine(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		testListHandler(3, 0).ServeHTTP(w, r)
+	}))
+
+	var items []int64
+	for item, err := range twapi.AllItems(t.Context(), engine, testListRequest{page: 1}, func(r *testListResponse) []int64 {
+		return r.Items
+	}) {
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		items = append(items, item)
+		if len(items) == 3 {
+			break
+		}
+	}
+
+	if want := []int64{1, 2, 3}; fmt.Sprint(items) != fmt.Sprint(want) {
+		t.Errorf("expected items %v but got %v", want, items)
+	}
+	if requests != 2 {
+		t.Errorf("expected the pages to be loaded as the items are consumed but got %d requests", requests)
+	}
+}
+
+func TestIterateConcurrent(t *testing.T) {
+	tests := []struct {
+		name         string
+		countKnown   bool
+		prefetch     int
+		wantInFlight func(int32) bool
+	}{{
+		name:         "prefetches pages when the count is known",
+		countKnown:   true,
+		prefetch:     3,
+		wantInFlight: func(n int32) bool { return n > 1 && n <= 3 },
+	}, {
+		name:         "falls back to sequential paging when the count is skipped",
+		countKnown:   false,
+		prefetch:     3,
+		wantInFlight: func(n int32) bool { return n == 1 },
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const pages = 8
+
+			var inFlight, maxInFlight atomic.Int32
+			engine := newTestEngine(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				current := inFlight.Add(1)
+				defer inFlight.Add(-1)
+				for {
+					if old := maxInFlight.Load(); current <= old || maxInFlight.CompareAndSwap(old, current) {
+						break
+					}
+				}
+				time.Sleep(20 * time.Millisecond)
+
+				page, _ := strconv.ParseInt(r.URL.Query().Get("page"), 10, 64)
+				count := "null"
+				if tt.countKnown {
+					count = strconv.Itoa(pages * testListPageSize)
+				}
+				_, _ = fmt.Fprintf(w, `{"meta":{"page":{"count":%s,"hasMore":%t}},"items":[%d,%d]}`,
+					count, page < pages, page*2-1, page*2)
+			}))
+
+			var items []int64
+			iterator := twapi.IterateConcurrent[testListRequest, *testListResponse](
+				t.Context(),
+				engine,
+				testListRequest{page: 1},
+				twapi.WithPrefetch(tt.prefetch),
+			)
+			for page, err := range iterator {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				items = append(items, page.Items...)
+			}
+
+			want := make([]int64, pages*testListPageSize)
+			for i := range want {
+				want[i] = int64(i + 1)
+			}
+			if fmt.Sprint(items) != fmt.Sprint(want) {
+				t.Errorf("expected items %v but got %v", want, items)
+			}
+			if got := maxInFlight.Load(); !tt.wantInFlight(got) {
+				t.Errorf("unexpected number of concurrent requests: %d", got)
+			}
+		})
+	}
+}
+
+func TestIterateConcurrentError(t *testing.T) {
+	engine := newTestEngine(t, testListHandler(6, 4))
+
+	var pages []int64
+	var failures int
+	for page, err := range twapi.IterateConcurrent[testListRequest, *testListResponse](
+		t.Context(), engine, testListRequest{page: 1},
+	) {
+		if err != nil {
+			failures++
+			continue
+		}
+		pages = append(pages, page.request.page)
+	}
+
+	if fmt.Sprint(pages) != "[1 2 3]" || failures != 1 {
+		t.Errorf("expected pages [1 2 3] and 1 failure but got %v and %d failures", pages, failures)
+	}
+}
@@ -0,0 +1,145 @@
+// Package redact removes the credentials from the HTTP requests and responses
+// the SDK logs, prints or stores. The request logging, the dry run, the cache
+// and the cassettes all go through it, so they redact the same credentials.
+package redact
+
+import (
+	"maps"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Placeholder replaces the credentials removed from a value.
+const Placeholder = "REDACTED"
+
+// schemeHeaders lists the headers whose value starts with an authentication
+// scheme, which is kept when the credentials are redacted.
+var schemeHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+}
+
+// secretHeaders lists the headers whose whole value is a credential.
+var secretHeaders = []string{
+	"Authentication-Info",
+	"Proxy-Authentication-Info",
+	"X-Amz-Security-Token",
+}
+
+// cookieHeaders lists the headers carrying cookies, where only the tw-auth
+// session cookie is a credential.
+var cookieHeaders = []string{
+	"Cookie",
+	"Set-Cookie",
+}
+
+// queryParameters lists the query parameters, in lower case, that carry the
+// signature or credentials of a pre-signed URL.
+var queryParameters = []string{
+	"x-amz-signature",
+	"x-amz-credential",
+	"x-amz-security-token",
+	"x-goog-signature",
+	"x-goog-credential",
+	"signature",
+	"sig",
+	"access_token",
+}
+
+// bodyFields lists the fields of JSON and form bodies carrying credentials,
+// such as those of the OAuth2 token exchanges.
+var bodyFields = `access_token|refresh_token|id_token|client_secret|code_verifier|password`
+
+// jsonField matches the string value of a credential field in a JSON body, up
+// to the end of the body when it was truncated.
+var jsonField = regexp.MustCompile(`(?i)("(?:` + bodyFields + `)"\s*:\s*)"(?:[^"\\]|\\.)*"?`)
+
+// formField matches the value of a credential field in a form body.
+var formField = regexp.MustCompile(`(?i)((?:^|&)(?:` + bodyFields + `)=)[^&]*`)
+
+// twAuthCookie matches the value of the tw-auth session cookie in Cookie and
+// Set-Cookie headers.
+var twAuthCookie = regexp.MustCompile(`(?i)(\btw-auth=)[^;,\s]*`)
+
+// Header returns a copy of the header without credentials. The Authorization
+// and Proxy-Authorization headers keep only their scheme, such as
+// "Bearer REDACTED", and the value of the tw-auth cookie is removed.
+func Header(header http.Header) http.Header {
+	result := header.Clone()
+	for _, name := range schemeHeaders {
+		values := result[name]
+		for i, value := range values {
+			if scheme, _, ok := strings.Cut(value, " "); ok {
+				values[i] = scheme + " " + Placeholder
+			} else {
+				values[i] = Placeholder
+			}
+		}
+	}
+	for _, name := range secretHeaders {
+		values := result[name]
+		for i := range values {
+			values[i] = Placeholder
+		}
+	}
+	for _, name := range cookieHeaders {
+		values := result[name]
+		for i, value := range values {
+			values[i] = twAuthCookie.ReplaceAllString(value, "${1}"+Placeholder)
+		}
+	}
+	return result
+}
+
+// Remove deletes the headers carrying credentials, modifying the header in
+// place. It is used when the header is kept beyond the request, where even a
+// redacted value is of no use.
+func Remove(header http.Header) {
+	for _, names := range [][]string{schemeHeaders, secretHeaders, cookieHeaders} {
+		for _, name := range names {
+			header.Del(name)
+		}
+	}
+}
+
+// Query returns a copy of the query where the signature and credentials of
+// pre-signed URLs are redacted.
+func Query(query url.Values) url.Values {
+	result := maps.Clone(query)
+	for name := range result {
+		if sensitive(name) {
+			result[name] = []string{Placeholder}
+		}
+	}
+	return result
+}
+
+// URL returns the URL without credentials: the password of the user
+// information, and the signature and credentials of pre-signed URLs. The query
+// is only encoded again when it has credentials.
+func URL(u *url.URL) string {
+	redacted := *u
+	query := redacted.Query()
+	if slices.ContainsFunc(slices.Collect(maps.Keys(query)), sensitive) {
+		redacted.RawQuery = Query(query).Encode()
+	}
+	return redacted.Redacted()
+}
+
+// sensitive reports whether the query parameter carries credentials.
+func sensitive(name string) bool {
+	return slices.ContainsFunc(queryParameters, func(parameter string) bool {
+		return strings.EqualFold(name, parameter)
+	})
+}
+
+// Body returns the body without the values of the known credential fields,
+// such as access_token or client_secret, in JSON and form bodies. It also
+// works on truncated bodies. Other sensitive values are not redacted.
+func Body(body string) string {
+	body = jsonField.ReplaceAllString(body, `${1}"`+Placeholder+`"`)
+	return formField.ReplaceAllString(body, "${1}"+Placeholder)
+}
@@ -1,6 +1,9 @@
 package twapi
 
-import "net/url"
+import (
+	"iter"
+	"net/url"
+)
 
 // ListCountMode selects whether a v3 list endpoint computes the exact number of
 // items matching the request filters, exposed as ListMeta.Page.Count in the
@@ -77,3 +80,22 @@ type ListMetaPage struct {
 	// current page.
 	HasMore bool `json:"hasMore"`
 }
+
+// RemainingPages returns an iterator over the page numbers following page, up to
+// the last page matching the request filters when listing pageSize items per
+// page. It yields nothing when Page.Count is nil, which is the case when the
+// request asked the API to skip the count query, or when the page size is
+// unknown. List responses use it to implement IterateRemaining.
+func (l ListMeta) RemainingPages(page, pageSize int64) iter.Seq[int64] {
+	return func(yield func(int64) bool) {
+		if l.Page.Count == nil || pageSize <= 0 {
+			return
+		}
+		pages := (*l.Page.Count + pageSize - 1) / pageSize
+		for next := max(page, 1) + 1; next <= pages; next++ {
+			if !yield(next) {
+				return
+			}
+		}
+	}
+}
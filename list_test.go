@@ -3,6 +3,7 @@ package twapi_test
 import (
 	"encoding/json"
 	"net/url"
+	"slices"
 	"testing"
 
 	twapi "github.com/teamwork/twapi-go-sdk"
@@ -90,3 +91,50 @@ func TestListMetaResolveCount(t *testing.T) {
 		})
 	}
 }
+
+func TestListMetaRemainingPages(t *testing.T) {
+	count := int64(101)
+
+	tests := []struct {
+		name     string
+		count    *int64
+		page     int64
+		pageSize int64
+		want     []int64
+	}{{
+		name:     "first page",
+		count:    &count,
+		page:     1,
+		pageSize: 25,
+		want:     []int64{2, 3, 4, 5},
+	}, {
+		name:     "unset page defaults to the first one",
+		count:    &count,
+		pageSize: 50,
+		want:     []int64{2, 3},
+	}, {
+		name:     "last page",
+		count:    &count,
+		page:     3,
+		pageSize: 50,
+	}, {
+		name:     "unknown count",
+		page:     1,
+		pageSize: 50,
+	}, {
+		name:  "unknown page size",
+		count: &count,
+		page:  1,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := twapi.ListMeta{Page: twapi.ListMetaPage{Count: tt.count, HasMore: true}}
+
+			got := slices.Collect(meta.RemainingPages(tt.page, tt.pageSize))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("expected pages %v but got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package twapi
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/teamwork/twapi-go-sdk/internal/redact"
+)
+
+const (
+	defaultLogMaxHeaderSize = 1 << 10
+	defaultLogMaxBodySize   = 4 << 10
+)
+
+// RequestLoggingOptions configures what WithRequestLogging adds to each log
+// entry. Zero values are replaced by the defaults documented on each field.
+type RequestLoggingOptions struct {
+	// Headers logs the request and response headers.
+	Headers bool
+
+	// MaxHeaderSize caps the bytes logged for each header value. Defaults to
+	// 1KiB.
+	MaxHeaderSize int
+
+	// Bodies logs the request and response bodies. Bodies are captured while
+	// they are read, so they are never buffered beyond MaxBodySize.
+	//
+	// Bodies are not redacted, except for the values of the known credential
+	// fields of JSON and form bodies, such as access_token, refresh_token or
+	// client_secret. Any other sensitive data they hold is logged.
+	Bodies bool
+
+	// MaxBodySize caps the bytes logged for each body. Defaults to 4KiB.
+	MaxBodySize int
+}
+
+// WithRequestLogging logs every request sent by the Engine through the Engine
+// logger at the given level, with the method, URL, duration, status code and
+// response size. Headers and bodies are only logged when enabled in opts.
+//
+// Credentials are redacted from the logs: the Authorization header keeps only
+// its scheme, the tw-auth cookie value is removed, and so are the signature and
+// credentials of pre-signed URLs and the token fields of the bodies. See
+// RequestLoggingOptions.Bodies for what bodies may still hold. The entry is written once the response body
+// is closed, so the duration and size cover the whole response. Requests that
+// fail without a response are logged straight away.
+//
+// Like WithMiddleware, it wraps the client configured so far, and is ignored if
+// WithHTTPClient is used afterwards.
+func WithRequestLogging(level slog.Level, opts RequestLoggingOptions) EngineOption {
+	if opts.MaxHeaderSize <= 0 {
+		opts.MaxHeaderSize = defaultLogMaxHeaderSize
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = defaultLogMaxBodySize
+	}
+	return func(e *Engine) {
+		// the logger is read on every request, so WithLogger may come later
+		logging := &requestLogger{engine: e, level: level, opts: opts}
+		e.client = &httpClientMiddleware{
+			client:     e.client,
+			middleware: logging.middleware,
+		}
+	}
+}
+
+// requestLogger implements the WithRequestLogging middleware.
+type requestLogger struct {
+	engine *Engine
+	level  slog.Level
+	opts   RequestLoggingOptions
+}
+
+// middleware logs the exchanges of the next client.
+func (l *requestLogger) middleware(next HTTPClient) HTTPClient {
+	return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		ctx := req.Context()
+		logger := l.engine.logger
+		if !logger.Enabled(ctx, l.level) {
+			return next.Do(req)
+		}
+
+		var requestBody *cappedBuffer
+		if l.opts.Bodies && req.Body != nil && req.Body != http.NoBody {
+			requestBody = &cappedBuffer{limit: l.opts.MaxBodySize}
+			req = req.Clone(ctx)
+			req.Body = &teeBody{ReadCloser: req.Body, capture: requestBody}
+		}
+
+		start := time.Now()
+		resp, err := next.Do(req)
+
+		attrs := []slog.Attr{
+			slog.String("method", req.Method),
+			slog.String("url", redact.URL(req.URL)),
+		}
+		if l.opts.Headers {
+			attrs = append(attrs, l.headerAttr("request_headers", req.Header))
+		}
+		if requestBody != nil {
+			attrs = append(attrs, slog.String("request_body", redact.Body(requestBody.String())))
+		}
+
+		if err != nil {
+			attrs = append(attrs,
+				slog.Duration("duration", time.Since(start)),
+				slog.String("error", err.Error()),
+			)
+			logger.LogAttrs(ctx, l.level, "request failed", attrs...)
+			return resp, err
+		}
+
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+		if l.opts.Headers {
+			attrs = append(attrs, l.headerAttr("response_headers", resp.Header))
+		}
+		body := &loggedBody{ReadCloser: resp.Body}
+		if l.opts.Bodies {
+			body.capture = &cappedBuffer{limit: l.opts.MaxBodySize}
+		}
+		body.done = func() {
+			attrs = append(attrs,
+				slog.Duration("duration", time.Since(start)),
+				slog.Int64("size", body.size),
+			)
+			if body.capture != nil {
+				attrs = append(attrs, slog.String("response_body", redact.Body(body.capture.String())))
+			}
+			logger.LogAttrs(ctx, l.level, "request completed", attrs...)
+		}
+		resp.Body = body
+		return resp, nil
+	})
+}
+
+// headerAttr groups the redacted and size-capped header values.
+func (l *requestLogger) headerAttr(key string, header http.Header) slog.Attr {
+	redactedHeader := redact.Header(header)
+	attrs := make([]any, 0, len(redactedHeader))
+	for name, values := range redactedHeader {
+		capped := make([]string, len(values))
+		for i, value := range values {
+			capped[i] = truncate(value, l.opts.MaxHeaderSize)
+		}
+		attrs = append(attrs, slog.String(name, strings.Join(capped, ", ")))
+	}
+	return slog.Group(key, attrs...)
+}
+
+// truncate caps the value to size bytes, marking the removed part.
+func truncate(value string, size int) string {
+	if len(value) <= size {
+		return value
+	}
+	return value[:size] + "…(truncated)"
+}
+
+// cappedBuffer keeps the first bytes written to it, up to its limit, and
+// discards the rest.
+type cappedBuffer struct {
+	mutex     sync.Mutex
+	buffer    bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write implements io.Writer. It never fails.
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if remaining := b.limit - b.buffer.Len(); remaining < len(p) {
+		b.truncated = true
+		b.buffer.Write(p[:max(remaining, 0)])
+	} else {
+		b.buffer.Write(p)
+	}
+	return len(p), nil
+}
+
+// String returns the captured bytes, marking a truncated capture.
+func (b *cappedBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.truncated {
+		return b.buffer.String() + "…(truncated)"
+	}
+	return b.buffer.String()
+}
+
+// teeBody captures a request body as it is sent.
+type teeBody struct {
+	io.ReadCloser
+	capture io.Writer
+}
+
+// Read implements io.Reader.
+func (t *teeBody) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	_, _ = t.capture.Write(p[:n])
+	return n, err
+}
+
+// loggedBody counts and optionally captures a response body as it is read,
+// calling done once when it is fully read or closed.
+type loggedBody struct {
+	io.ReadCloser
+	capture *cappedBuffer
+	size    int64
+	once    sync.Once
+	done    func()
+}
+
+// Read implements io.Reader.
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	if b.capture != nil {
+		_, _ = b.capture.Write(p[:n])
+	}
+	if err == io.EOF {
+		b.once.Do(b.done)
+	}
+	return n, err
+}
+
+// Close implements io.Closer.
+func (b *loggedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
@@ -0,0 +1,104 @@
+package twapi_test
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+)
+
+func TestWithRequestLogging(t *testing.T) {
+	tests := []struct {
+		name        string
+		level       slog.Level
+		opts        twapi.RequestLoggingOptions
+		request     testRequest
+		wantLogged  []string
+		wantMissing []string
+	}{{
+		name:    "summary",
+		level:   slog.LevelInfo,
+		request: testRequest{path: "/projects.json"},
+		wantLogged: []string{
+			`"msg":"request completed"`,
+			`"method":"GET"`,
+			`/projects.json"`,
+			`"status":200`,
+			`"size":29`,
+			`"duration":`,
+		},
+		wantMissing: []string{`request_headers`, `response_body`},
+	}, {
+		name:    "headers",
+		level:   slog.LevelInfo,
+		opts:    twapi.RequestLoggingOptions{Headers: true},
+		request: testRequest{path: "/projects.json"},
+		wantLogged: []string{
+			`"Authorization":"Bearer REDACTED"`,
+			`"Set-Cookie":"tw-auth=REDACTED; Path=/; HttpOnly"`,
+		},
+		wantMissing: []string{"test-token", "cookie-secret"},
+	}, {
+		name:    "bodies",
+		level:   slog.LevelInfo,
+		opts:    twapi.RequestLoggingOptions{Bodies: true, MaxBodySize: 10},
+		request: testRequest{method: http.MethodPost, path: "/projects.json", body: `{"name":"Apollo"}`},
+		wantLogged: []string{
+			`"request_body":"{\"name\":\"A…(truncated)"`,
+			`"response_body":"{\"project\"…(truncated)"`,
+		},
+	}, {
+		name:        "credentials in bodies",
+		level:       slog.LevelInfo,
+		opts:        twapi.RequestLoggingOptions{Bodies: true},
+		request:     testRequest{method: http.MethodPost, path: "/token", body: "grant_type=refresh_token&refresh_token=secret"},
+		wantLogged:  []string{`"request_body":"grant_type=refresh_token&refresh_token=REDACTED"`},
+		wantMissing: []string{"secret"},
+	}, {
+		name:        "disabled level",
+		level:       slog.LevelDebug,
+		opts:        twapi.RequestLoggingOptions{Headers: true, Bodies: true},
+		request:     testRequest{path: "/projects.json"},
+		wantMissing: []string{"request completed"},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+			engine := newTestEngine(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.Copy(io.Discard, r.Body)
+				http.SetCookie(w, &http.Cookie{Name: "tw-auth", Value: "cookie-secret", Path: "/", HttpOnly: true})
+				_, _ = io.WriteString(w, `{"project":{"name":"Apollo"}}`)
+			}), twapi.WithRequestLogging(tt.level, tt.opts), twapi.WithLogger(logger))
+
+			resp, err := twapi.ExecuteRaw(t.Context(), engine, tt.request)
+			if err != nil {
+				t.Fatalf("failed to execute request: %v", err)
+			}
+			if _, err := io.ReadAll(resp.Body); err != nil {
+				t.Fatalf("failed to read response body: %v", err)
+			}
+			_ = resp.Body.Close()
+
+			if got := strings.Count(logs.String(), "\n"); got > 1 {
+				t.Errorf("expected at most one log entry, got %d:\n%s", got, logs.String())
+			}
+			for _, want := range tt.wantLogged {
+				if !strings.Contains(logs.String(), want) {
+					t.Errorf("expected logs to contain %s, got:\n%s", want, logs.String())
+				}
+			}
+			for _, missing := range tt.wantMissing {
+				if strings.Contains(logs.String(), missing) {
+					t.Errorf("expected logs not to contain %s, got:\n%s", missing, logs.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package mirror
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"maps"
+	"slices"
+
+	"github.com/teamwork/twapi-go-sdk/projects"
+)
+
+// Item is the type of an item kept in the store.
+type Item interface {
+	projects.Project |
+		projects.Company |
+		projects.User |
+		projects.Tag |
+		projects.Tasklist |
+		projects.Milestone |
+		projects.Task |
+		projects.Timelog
+}
+
+// entityOf returns the entity of the item type.
+func entityOf[T Item]() Entity {
+	var item T
+	switch any(item).(type) {
+	case projects.Project:
+		return EntityProject
+	case projects.Company:
+		return EntityCompany
+	case projects.User:
+		return EntityUser
+	case projects.Tag:
+		return EntityTag
+	case projects.Tasklist:
+		return EntityTasklist
+	case projects.Milestone:
+		return EntityMilestone
+	case projects.Task:
+		return EntityTask
+	default:
+		return EntityTimelog
+	}
+}
+
+// Get returns the item with the identifier, reporting false when it is not in
+// the store.
+func Get[T Item](s *Store, id int64) (T, bool, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var item T
+	entity := entityOf[T]()
+	rec, ok, err := s.read(key{entity: entity, id: id})
+	if err != nil || !ok {
+		return item, false, err
+	}
+	if err := json.Unmarshal(rec.Value, &item); err != nil {
+		return item, false, fmt.Errorf("failed to decode %s %d: %w", entity, id, err)
+	}
+	return item, true, nil
+}
+
+// All returns an iterator over every item of the type in the store, ordered by
+// identifier.
+func All[T Item](s *Store) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		entity := entityOf[T]()
+		s.mutex.RLock()
+		var ids []int64
+		for k := range s.locations {
+			if k.entity == entity {
+				ids = append(ids, k.id)
+			}
+		}
+		s.mutex.RUnlock()
+
+		slices.Sort(ids)
+		yieldItems(s, ids, yield)
+	}
+}
+
+// InProject returns an iterator over the items of the type that belong to the
+// project, ordered by identifier. Tasks belong to the project of their
+// tasklist, so the tasklist must be in the store for the task to be found.
+// Companies and users do not belong to a project.
+func InProject[T Item](s *Store, projectID int64) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		s.mutex.RLock()
+		ids := slices.Sorted(maps.Keys(s.byProject[entityOf[T]()][projectID]))
+		s.mutex.RUnlock()
+
+		yieldItems(s, ids, yield)
+	}
+}
+
+// TasksAssignedTo returns an iterator over the tasks assigned to the user,
+// ordered by identifier. Tasks assigned to a team or a company the user is part
+// of are not included.
+func TasksAssignedTo(s *Store, userID int64) iter.Seq2[projects.Task, error] {
+	return func(yield func(projects.Task, error) bool) {
+		s.mutex.RLock()
+		ids := slices.Sorted(maps.Keys(s.byAssignee[userID]))
+		s.mutex.RUnlock()
+
+		yieldItems(s, ids, yield)
+	}
+}
+
+// yieldItems decodes and yields the items with the identifiers. Items deleted
+// since the identifiers were collected are skipped.
+func yieldItems[T Item](s *Store, ids []int64, yield func(T, error) bool) {
+	for _, id := range ids {
+		item, ok, err := Get[T](s, id)
+		if err != nil {
+			yield(item, err)
+			return
+		}
+		if ok && !yield(item, nil) {
+			return
+		}
+	}
+}
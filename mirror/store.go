@@ -0,0 +1,540 @@
+// Package mirror keeps a durable local copy of the entities of an
+// installation, so reports and dashboards can read them without calling the
+// API.
+//
+// A Store is a directory holding an append-only log of the items, in a format
+// only needing the standard library. Opening the store reads the log once to
+// index where every item is, by identifier, by project and by task assignee;
+// items are read from disk when queried:
+//
+//	store, err := mirror.Open("mirror")
+//	if err != nil {
+//		return err
+//	}
+//	defer store.Close()
+//
+//	if err := store.Update(ctx, engine); err != nil {
+//		return err
+//	}
+//	for task, err := range mirror.InProject[projects.Task](store, projectID) {
+//		...
+//	}
+package mirror
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	dataFile  = "data.log"
+	stateFile = "state.json"
+
+	// headerSize is the size of the header of a record: the CRC-32 checksum
+	// and the length of the body.
+	headerSize = 8
+
+	// maxRecordSize is the largest body of a record. It bounds what a
+	// corrupted length in the log can make the store allocate.
+	maxRecordSize = 64 << 20
+
+	// compactRatio is how many times the live records the log can grow to
+	// before it is compacted after a synchronization.
+	compactRatio = 2
+)
+
+// Entity is a kind of item kept in the store.
+type Entity string
+
+// List of entities.
+const (
+	EntityProject   Entity = "project"
+	EntityCompany   Entity = "company"
+	EntityUser      Entity = "user"
+	EntityTag       Entity = "tag"
+	EntityTasklist  Entity = "tasklist"
+	EntityMilestone Entity = "milestone"
+	EntityTask      Entity = "task"
+	EntityTimelog   Entity = "timelog"
+)
+
+// Entities lists every entity, in the order they are synchronized. Tasks come
+// after tasklists, which they are indexed by project through.
+var Entities = []Entity{
+	EntityProject,
+	EntityCompany,
+	EntityUser,
+	EntityTag,
+	EntityTasklist,
+	EntityMilestone,
+	EntityTask,
+	EntityTimelog,
+}
+
+// key identifies an item in the store.
+type key struct {
+	entity Entity
+	id     int64
+}
+
+// location is where the latest record of an item is in the log, with the
+// values it is indexed by.
+type location struct {
+	offset    int64
+	length    int64
+	projectID int64
+	assignees []int64
+}
+
+// record is the body of a log record, encoded as JSON. A deleted item is
+// recorded without value.
+type record struct {
+	Entity    Entity          `json:"entity"`
+	ID        int64           `json:"id"`
+	Deleted   bool            `json:"deleted,omitempty"`
+	ProjectID int64           `json:"projectId,omitempty"`
+	Assignees []int64         `json:"assignees,omitempty"`
+	Value     json.RawMessage `json:"value,omitempty"`
+
+	// tasklistID is the tasklist of a task, which the project of the task is
+	// resolved from when the record is written.
+	tasklistID int64
+}
+
+// state is the progress of the synchronization, stored next to the log.
+type state struct {
+	// Marks contains the latest update time synchronized for the entities
+	// updated incrementally.
+	Marks map[Entity]time.Time `json:"marks,omitempty"`
+}
+
+// Option defines a function type that can modify the Store configuration.
+type Option func(*Store)
+
+// WithEntities restricts the entities synchronized by Backfill and Update. By
+// default, every entity of Entities is synchronized.
+func WithEntities(entities ...Entity) Option {
+	return func(s *Store) {
+		s.entities = entities
+	}
+}
+
+// WithPageSize sets the number of items loaded per page when synchronizing.
+// Defaults to 100.
+func WithPageSize(pageSize int64) Option {
+	return func(s *Store) {
+		s.pageSize = pageSize
+	}
+}
+
+// Store is a local copy of the entities of an installation. It is safe for
+// concurrent use, but the directory must only be opened by one process at a
+// time.
+type Store struct {
+	dir      string
+	entities []Entity
+	pageSize int64
+
+	mutex      sync.RWMutex
+	file       *os.File
+	size       int64
+	live       int64
+	locations  map[key]location
+	byProject  map[Entity]map[int64]map[int64]struct{}
+	byAssignee map[int64]map[int64]struct{}
+	state      state
+}
+
+// Open opens the store in the directory, creating it when it does not exist. A
+// record torn by a crash at the end of the log is dropped, but any other
+// invalid record makes Open return an error wrapping ErrCorruptLog, leaving the
+// log untouched.
+func Open(dir string, opts ...Option) (*Store, error) {
+	s := &Store{
+		dir:      dir,
+		entities: Entities,
+		pageSize: 100,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create mirror directory: %w", err)
+	}
+	if err := s.loadState(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	s.file, err = os.OpenFile(filepath.Join(dir, dataFile), os.O_RDWR|os.O_CREATE, 0o640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mirror log: %w", err)
+	}
+	if err := s.load(); err != nil {
+		_ = s.file.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close closes the store.
+func (s *Store) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("failed to close mirror log: %w", err)
+	}
+	return nil
+}
+
+// Count returns the number of items of the entity in the store.
+func (s *Store) Count(entity Entity) int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var count int
+	for k := range s.locations {
+		if k.entity == entity {
+			count++
+		}
+	}
+	return count
+}
+
+// load indexes the records of the log. A record running past the end of the
+// log, as left by a crash while it was appended, is truncated. Any other
+// invalid record makes load return an error wrapping ErrCorruptLog without
+// modifying the log.
+func (s *Store) load() error {
+	s.reset()
+
+	info, err := s.file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read mirror log: %w", err)
+	}
+	size := info.Size()
+
+	reader := bufio.NewReader(io.NewSectionReader(s.file, 0, size))
+	var offset int64
+	for offset < size {
+		body, err := readRecord(reader)
+		switch {
+		case errors.Is(err, errTornRecord):
+			// a corrupted length also runs past the end of the log, but
+			// complete records follow it
+			if s.recordAfter(offset+1, size) {
+				return fmt.Errorf("%w: invalid length of the record at offset %d", ErrCorruptLog, offset)
+			}
+		case errors.Is(err, errChecksum):
+			// only the last record can be torn
+			if offset+int64(headerSize+len(body)) < size {
+				return fmt.Errorf("%w: invalid checksum of the record at offset %d", ErrCorruptLog, offset)
+			}
+		case errors.Is(err, errRecordTooLarge):
+			return fmt.Errorf("%w: invalid length of the record at offset %d", ErrCorruptLog, offset)
+		case err != nil:
+			return fmt.Errorf("failed to read mirror log: %w", err)
+		}
+		if err != nil {
+			break
+		}
+
+		var rec record
+		if err := json.Unmarshal(body, &rec); err != nil {
+			return fmt.Errorf("%w: failed to decode the record at offset %d: %s", ErrCorruptLog, offset, err)
+		}
+		length := int64(headerSize + len(body))
+		s.index(rec, offset, length)
+		offset += length
+	}
+
+	if offset < size {
+		if err := s.file.Truncate(offset); err != nil {
+			return fmt.Errorf("failed to truncate mirror log: %w", err)
+		}
+	}
+	if _, err := s.file.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek mirror log: %w", err)
+	}
+	s.size = offset
+	return nil
+}
+
+// recordAfter reports whether a valid record starts anywhere between the
+// offset and the end of the log. It tells a corrupted record apart from a torn
+// one, which no record follows.
+func (s *Store) recordAfter(offset, size int64) bool {
+	for ; offset+headerSize < size; offset++ {
+		body, err := readRecord(io.NewSectionReader(s.file, offset, size-offset))
+		if err == nil && len(body) > 0 && json.Valid(body) {
+			return true
+		}
+	}
+	return false
+}
+
+// reset empties the indexes.
+func (s *Store) reset() {
+	s.size = 0
+	s.live = 0
+	s.locations = make(map[key]location)
+	s.byProject = make(map[Entity]map[int64]map[int64]struct{})
+	s.byAssignee = make(map[int64]map[int64]struct{})
+}
+
+// index records where the latest record of an item is. The caller must hold
+// the mutex.
+func (s *Store) index(rec record, offset, length int64) {
+	k := key{entity: rec.Entity, id: rec.ID}
+	if previous, ok := s.locations[k]; ok {
+		s.live -= previous.length
+		delete(s.byProject[k.entity][previous.projectID], k.id)
+		for _, userID := range previous.assignees {
+			delete(s.byAssignee[userID], k.id)
+		}
+		delete(s.locations, k)
+	}
+	if rec.Deleted {
+		return
+	}
+
+	s.locations[k] = location{
+		offset:    offset,
+		length:    length,
+		projectID: rec.ProjectID,
+		assignees: rec.Assignees,
+	}
+	s.live += length
+	if rec.ProjectID > 0 {
+		if s.byProject[k.entity] == nil {
+			s.byProject[k.entity] = make(map[int64]map[int64]struct{})
+		}
+		if s.byProject[k.entity][rec.ProjectID] == nil {
+			s.byProject[k.entity][rec.ProjectID] = make(map[int64]struct{})
+		}
+		s.byProject[k.entity][rec.ProjectID][k.id] = struct{}{}
+	}
+	for _, userID := range rec.Assignees {
+		if s.byAssignee[userID] == nil {
+			s.byAssignee[userID] = make(map[int64]struct{})
+		}
+		s.byAssignee[userID][k.id] = struct{}{}
+	}
+}
+
+// read returns the record of an item. The caller must hold the mutex.
+func (s *Store) read(k key) (record, bool, error) {
+	loc, ok := s.locations[k]
+	if !ok {
+		return record{}, false, nil
+	}
+	data := make([]byte, loc.length)
+	if _, err := s.file.ReadAt(data, loc.offset); err != nil {
+		return record{}, false, fmt.Errorf("failed to read %s %d: %w", k.entity, k.id, err)
+	}
+	body, err := readRecord(bytes.NewReader(data))
+	if err != nil {
+		return record{}, false, fmt.Errorf("failed to read %s %d: %w", k.entity, k.id, err)
+	}
+	var rec record
+	if err := json.Unmarshal(body, &rec); err != nil {
+		return record{}, false, fmt.Errorf("failed to decode %s %d: %w", k.entity, k.id, err)
+	}
+	return rec, true, nil
+}
+
+// write appends a record to the log, unless it stores the same item as the
+// latest one. The caller must hold the mutex.
+func (s *Store) write(rec record) error {
+	k := key{entity: rec.Entity, id: rec.ID}
+	previous, ok, err := s.read(k)
+	if err != nil {
+		return err
+	}
+	switch {
+	case rec.Deleted && !ok:
+		return nil
+	case !rec.Deleted && ok && previous.ProjectID == rec.ProjectID && bytes.Equal(previous.Value, rec.Value):
+		return nil
+	}
+
+	body, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s %d: %w", rec.Entity, rec.ID, err)
+	}
+	if len(body) > maxRecordSize {
+		return fmt.Errorf("failed to write %s %d: %w", rec.Entity, rec.ID, errRecordTooLarge)
+	}
+	data := appendRecord(nil, body)
+	if _, err := s.file.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s %d: %w", rec.Entity, rec.ID, err)
+	}
+	s.index(rec, s.size, int64(len(data)))
+	s.size += int64(len(data))
+	return nil
+}
+
+// flush commits the records written to disk, and compacts the log once it
+// grew too large. The caller must hold the mutex.
+func (s *Store) flush() error {
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync mirror log: %w", err)
+	}
+	if s.size > compactRatio*s.live {
+		return s.compact()
+	}
+	return nil
+}
+
+// Compact rewrites the log with only the latest record of each item, which
+// reclaims the space of the updated and deleted items. It is called
+// automatically once the log grew enough after a synchronization.
+func (s *Store) Compact() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.compact()
+}
+
+// compact rewrites the log into a temporary file that replaces it, so a
+// failure leaves the previous log intact. The caller must hold the mutex.
+func (s *Store) compact() error {
+	path := filepath.Join(s.dir, dataFile)
+	file, err := os.CreateTemp(s.dir, dataFile+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create compacted mirror log: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+
+	writer := bufio.NewWriter(file)
+	for _, loc := range s.locations {
+		if _, err := io.Copy(writer, io.NewSectionReader(s.file, loc.offset, loc.length)); err != nil {
+			_ = file.Close()
+			return fmt.Errorf("failed to write compacted mirror log: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write compacted mirror log: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to sync compacted mirror log: %w", err)
+	}
+	// the compacted log is renamed while open, so the store never holds a
+	// handle to a log that is no longer in the directory
+	if err := os.Rename(file.Name(), path); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to replace mirror log: %w", err)
+	}
+	_ = s.file.Close()
+	s.file = file
+	return s.load()
+}
+
+// loadState reads the progress of the synchronization.
+func (s *Store) loadState() error {
+	data, err := os.ReadFile(filepath.Join(s.dir, stateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read mirror state: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.state); err != nil {
+		return fmt.Errorf("failed to decode mirror state: %w", err)
+	}
+	return nil
+}
+
+// saveState writes the progress of the synchronization to a temporary file
+// that replaces the previous one. The caller must hold the mutex.
+func (s *Store) saveState() error {
+	data, err := json.Marshal(s.state)
+	if err != nil {
+		return fmt.Errorf("failed to encode mirror state: %w", err)
+	}
+	file, err := os.CreateTemp(s.dir, stateFile+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create mirror state: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write mirror state: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close mirror state: %w", err)
+	}
+	if err := os.Rename(file.Name(), filepath.Join(s.dir, stateFile)); err != nil {
+		return fmt.Errorf("failed to replace mirror state: %w", err)
+	}
+	return nil
+}
+
+// ErrCorruptLog is returned when a record of the log is corrupted, rather than
+// torn by a crash at the end of the log.
+var ErrCorruptLog = errors.New("corrupted mirror log")
+
+// errTornRecord is returned when the log ends with an incomplete record, as
+// left by a crash while it was written.
+var errTornRecord = errors.New("torn mirror log record")
+
+// errRecordTooLarge is returned when the length of a record exceeds
+// maxRecordSize.
+var errRecordTooLarge = errors.New("mirror log record too large")
+
+// errChecksum is returned when the body of a record does not match its
+// checksum.
+var errChecksum = errors.New("invalid mirror log record checksum")
+
+// appendRecord appends the header and the body of a record.
+func appendRecord(data, body []byte) []byte {
+	data = binary.LittleEndian.AppendUint32(data, crc32.ChecksumIEEE(body))
+	data = binary.LittleEndian.AppendUint32(data, uint32(len(body)))
+	return append(data, body...)
+}
+
+// readRecord reads a record, returning its body once its checksum verified.
+// The body is also returned with errChecksum, so the caller knows where the
+// record ends.
+func readRecord(reader io.Reader) ([]byte, error) {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, errTornRecord
+		}
+		return nil, err
+	}
+	length := binary.LittleEndian.Uint32(header[4:])
+	if length > maxRecordSize {
+		return nil, errRecordTooLarge
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, errTornRecord
+		}
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(body) != binary.LittleEndian.Uint32(header[:4]) {
+		return body, errChecksum
+	}
+	return body, nil
+}
@@ -0,0 +1,224 @@
+package mirror_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/mirror"
+	"github.com/teamwork/twapi-go-sdk/projects"
+	"github.com/teamwork/twapi-go-sdk/twapitest"
+)
+
+// entities are the entities served by the fake server.
+var entities = []mirror.Entity{
+	mirror.EntityProject,
+	mirror.EntityUser,
+	mirror.EntityTag,
+	mirror.EntityTasklist,
+	mirror.EntityMilestone,
+	mirror.EntityTask,
+	mirror.EntityTimelog,
+}
+
+func TestStore(t *testing.T) {
+	ctx := t.Context()
+	server := twapitest.NewServer(t)
+	engine := server.Engine()
+	me := server.Me()
+
+	apollo := server.AddProject(projects.Project{Name: "Apollo"})
+	gemini := server.AddProject(projects.Project{Name: "Gemini"})
+	launch := server.AddTasklist(projects.Tasklist{Name: "Launch", Project: twapi.Relationship{ID: apollo.ID, Type: "projects"}})
+	training := server.AddTasklist(projects.Tasklist{Name: "Training", Project: twapi.Relationship{ID: gemini.ID, Type: "projects"}})
+	fuel := server.AddTask(projects.Task{
+		Name:      "Fuel the rocket",
+		Tasklist:  twapi.Relationship{ID: launch.ID, Type: "tasklists"},
+		Assignees: []twapi.Relationship{{ID: me.ID, Type: "users"}},
+	})
+	countdown := server.AddTask(projects.Task{
+		Name:     "Start the countdown",
+		Tasklist: twapi.Relationship{ID: launch.ID, Type: "tasklists"},
+	})
+	swim := server.AddTask(projects.Task{
+		Name:      "Swim",
+		Tasklist:  twapi.Relationship{ID: training.ID, Type: "tasklists"},
+		Assignees: []twapi.Relationship{{ID: me.ID, Type: "users"}},
+	})
+
+	dir := t.TempDir()
+	store, err := mirror.Open(dir, mirror.WithEntities(entities...), mirror.WithPageSize(2))
+	if err != nil {
+		t.Fatalf("failed to open store: %s", err)
+	}
+	if err := store.Backfill(ctx, engine); err != nil {
+		t.Fatalf("failed to backfill store: %s", err)
+	}
+
+	if count := store.Count(mirror.EntityTask); count != 3 {
+		t.Errorf("expected 3 tasks but got %d", count)
+	}
+	if ids := taskIDs(t, mirror.InProject[projects.Task](store, apollo.ID)); !slices.Equal(ids, []int64{fuel.ID, countdown.ID}) {
+		t.Errorf("expected the tasks of the project to be %v but got %v", []int64{fuel.ID, countdown.ID}, ids)
+	}
+	if ids := taskIDs(t, mirror.TasksAssignedTo(store, me.ID)); !slices.Equal(ids, []int64{fuel.ID, swim.ID}) {
+		t.Errorf("expected the tasks assigned to be %v but got %v", []int64{fuel.ID, swim.ID}, ids)
+	}
+	if user, ok, err := mirror.Get[projects.User](store, me.ID); err != nil || !ok || user.Email != me.Email {
+		t.Errorf("expected user %q but got %q (found %t, error %v)", me.Email, user.Email, ok, err)
+	}
+
+	// the incremental update loads the task changed, but cannot see the task
+	// deleted
+	update := projects.NewTaskUpdateRequest(fuel.ID)
+	update.Name = new("Fuel the rocket twice")
+	update.Assignees = &projects.UserGroups{}
+	if _, err := projects.TaskUpdate(ctx, engine, update); err != nil {
+		t.Fatalf("failed to update task: %s", err)
+	}
+	if _, err := projects.TaskDelete(ctx, engine, projects.NewTaskDeleteRequest(countdown.ID)); err != nil {
+		t.Fatalf("failed to delete task: %s", err)
+	}
+	if err := store.Update(ctx, engine); err != nil {
+		t.Fatalf("failed to update store: %s", err)
+	}
+	if task, _, err := mirror.Get[projects.Task](store, fuel.ID); err != nil || task.Name != "Fuel the rocket twice" {
+		t.Errorf("expected the task to be updated but got %q (error %v)", task.Name, err)
+	}
+	if ids := taskIDs(t, mirror.TasksAssignedTo(store, me.ID)); !slices.Equal(ids, []int64{swim.ID}) {
+		t.Errorf("expected the tasks assigned to be %v but got %v", []int64{swim.ID}, ids)
+	}
+	if _, ok, _ := mirror.Get[projects.Task](store, countdown.ID); !ok {
+		t.Errorf("expected the deleted task to remain until the next backfill")
+	}
+
+	if err := store.Backfill(ctx, engine); err != nil {
+		t.Fatalf("failed to backfill store: %s", err)
+	}
+	if _, ok, _ := mirror.Get[projects.Task](store, countdown.ID); ok {
+		t.Errorf("expected the deleted task to be removed by the backfill")
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("failed to close store: %s", err)
+	}
+
+	// a record torn by a crash is dropped when reopening
+	file, err := os.OpenFile(filepath.Join(dir, "data.log"), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("failed to open log: %s", err)
+	}
+	if _, err := file.Write([]byte{1, 2, 3, 4, 200, 0, 0, 0, '{'}); err != nil {
+		t.Fatalf("failed to write log: %s", err)
+	}
+	_ = file.Close()
+
+	store, err = mirror.Open(dir)
+	if err != nil {
+		t.Fatalf("failed to reopen store: %s", err)
+	}
+	t.Cleanup(func() {
+		_ = store.Close()
+	})
+	if ids := taskIDs(t, mirror.All[projects.Task](store)); !slices.Equal(ids, []int64{fuel.ID, swim.ID}) {
+		t.Errorf("expected the tasks to be %v after reopening but got %v", []int64{fuel.ID, swim.ID}, ids)
+	}
+	if err := store.Compact(); err != nil {
+		t.Fatalf("failed to compact store: %s", err)
+	}
+	if ids := taskIDs(t, mirror.InProject[projects.Task](store, gemini.ID)); !slices.Equal(ids, []int64{swim.ID}) {
+		t.Errorf("expected the tasks of the project to be %v after compacting but got %v", []int64{swim.ID}, ids)
+	}
+}
+
+func TestOpenCorruptLog(t *testing.T) {
+	server := twapitest.NewServer(t)
+	server.AddProject(projects.Project{Name: "Apollo"})
+	server.AddProject(projects.Project{Name: "Gemini"})
+
+	dir := t.TempDir()
+	store, err := mirror.Open(dir, mirror.WithEntities(mirror.EntityProject))
+	if err != nil {
+		t.Fatalf("failed to open store: %s", err)
+	}
+	if err := store.Backfill(t.Context(), server.Engine()); err != nil {
+		t.Fatalf("failed to backfill store: %s", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("failed to close store: %s", err)
+	}
+
+	path := filepath.Join(dir, "data.log")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read log: %s", err)
+	}
+	corrupted := slices.Clone(data)
+	corrupted[len(corrupted)-2] ^= 0xff
+	if err := os.WriteFile(path, corrupted, 0o640); err != nil {
+		t.Fatalf("failed to write log: %s", err)
+	}
+
+	// a corrupted last record is dropped
+	store, err = mirror.Open(dir, mirror.WithEntities(mirror.EntityProject))
+	if err != nil {
+		t.Fatalf("failed to reopen store: %s", err)
+	}
+	if count := store.Count(mirror.EntityProject); count != 1 {
+		t.Errorf("expected the corrupted last project to be dropped but got %d projects", count)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("failed to close store: %s", err)
+	}
+
+	// a corrupted record followed by others is reported, and not truncated
+	tests := []struct {
+		name    string
+		corrupt func(data []byte)
+	}{{
+		name:    "body",
+		corrupt: func(data []byte) { data[10] ^= 0xff },
+	}, {
+		name:    "length within the log",
+		corrupt: func(data []byte) { data[4]++ },
+	}, {
+		name:    "length past the end of the log",
+		corrupt: func(data []byte) { binary.LittleEndian.PutUint32(data[4:], uint32(len(data))) },
+	}, {
+		name:    "length too large",
+		corrupt: func(data []byte) { data[7] = 0xff },
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			corrupted := slices.Clone(data)
+			tt.corrupt(corrupted)
+			if err := os.WriteFile(path, corrupted, 0o640); err != nil {
+				t.Fatalf("failed to write log: %s", err)
+			}
+			if _, err := mirror.Open(dir, mirror.WithEntities(mirror.EntityProject)); !errors.Is(err, mirror.ErrCorruptLog) {
+				t.Fatalf("expected a corrupted log error but got %v", err)
+			}
+			if stored, err := os.ReadFile(path); err != nil || !bytes.Equal(stored, corrupted) {
+				t.Errorf("expected the corrupted log to be left untouched but got %d bytes instead of %d", len(stored), len(corrupted))
+			}
+		})
+	}
+}
+
+func taskIDs(t *testing.T, tasks iter.Seq2[projects.Task, error]) []int64 {
+	t.Helper()
+
+	var ids []int64
+	for task, err := range tasks {
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		ids = append(ids, task.ID)
+	}
+	return ids
+}
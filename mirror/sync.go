@@ -0,0 +1,313 @@
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"time"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/projects"
+)
+
+// overlap is how long before the latest update synchronized the incremental
+// loads start, so the items updated while the previous load was running are
+// not missed. The items loaded again are only written when they changed.
+const overlap = time.Minute
+
+// loader loads the items of an entity, updated after a moment when provided.
+// It returns the latest update time of the items loaded.
+type loader func(ctx context.Context, engine *twapi.Engine, pageSize int64, since *time.Time) ([]record, time.Time, error)
+
+// loaders contains how each entity is loaded.
+var loaders = map[Entity]loader{
+	EntityProject:   loadProjects,
+	EntityCompany:   loadCompanies,
+	EntityUser:      loadUsers,
+	EntityTag:       loadTags,
+	EntityTasklist:  loadTasklists,
+	EntityMilestone: loadMilestones,
+	EntityTask:      loadTasks,
+	EntityTimelog:   loadTimelogs,
+}
+
+// incremental contains the entities whose list supports the updatedAfter
+// filter. The other entities are loaded fully on every synchronization.
+var incremental = map[Entity]bool{
+	EntityProject: true,
+	EntityTask:    true,
+}
+
+// Backfill loads every item of the entities synchronized, replacing the copy
+// in the store and removing the items that no longer exist.
+func (s *Store) Backfill(ctx context.Context, engine *twapi.Engine) error {
+	return s.sync(ctx, engine, true)
+}
+
+// Update loads the projects and tasks updated since the previous
+// synchronization, using the updatedAfter filter. The other entities cannot be
+// filtered by update time, so they are loaded fully, as well as the entities
+// never synchronized before.
+//
+// The projects and tasks deleted are not reported by the updatedAfter filter,
+// so they remain in the store until the next Backfill.
+func (s *Store) Update(ctx context.Context, engine *twapi.Engine) error {
+	return s.sync(ctx, engine, false)
+}
+
+// sync synchronizes the entities one after the other. The progress is saved
+// after each entity, so an interrupted synchronization keeps the entities
+// already done.
+func (s *Store) sync(ctx context.Context, engine *twapi.Engine, backfill bool) error {
+	for _, entity := range s.entities {
+		load, ok := loaders[entity]
+		if !ok {
+			return fmt.Errorf("unknown entity %q", entity)
+		}
+
+		s.mutex.RLock()
+		mark, marked := s.state.Marks[entity]
+		s.mutex.RUnlock()
+
+		full := backfill || !incremental[entity] || !marked
+		var since *time.Time
+		if !full {
+			since = new(mark.Add(-overlap))
+		}
+
+		records, latest, err := load(ctx, engine, s.pageSize, since)
+		if err != nil {
+			return fmt.Errorf("failed to load %s items: %w", entity, err)
+		}
+		if err := s.apply(entity, records, latest, full); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply writes the records loaded for the entity. A full load removes the
+// items not loaded.
+func (s *Store) apply(entity Entity, records []record, latest time.Time, full bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	seen := make(map[int64]struct{}, len(records))
+	for _, rec := range records {
+		if rec.tasklistID > 0 {
+			rec.ProjectID = s.locations[key{entity: EntityTasklist, id: rec.tasklistID}].projectID
+		}
+		if err := s.write(rec); err != nil {
+			return err
+		}
+		seen[rec.ID] = struct{}{}
+	}
+	if full {
+		var removed []int64
+		for k := range s.locations {
+			if _, ok := seen[k.id]; k.entity == entity && !ok {
+				removed = append(removed, k.id)
+			}
+		}
+		for _, id := range removed {
+			if err := s.write(record{Entity: entity, ID: id, Deleted: true}); err != nil {
+				return err
+			}
+		}
+	}
+
+	// the log is committed before the state, so the state is never ahead of
+	// the items stored
+	if err := s.flush(); err != nil {
+		return err
+	}
+	if incremental[entity] {
+		if s.state.Marks == nil {
+			s.state.Marks = make(map[Entity]time.Time)
+		}
+		if latest.After(s.state.Marks[entity]) {
+			s.state.Marks[entity] = latest
+		}
+		return s.saveState()
+	}
+	return nil
+}
+
+// newRecord encodes an item as a record.
+func newRecord(entity Entity, id, projectID int64, item any) (record, error) {
+	value, err := json.Marshal(item)
+	if err != nil {
+		return record{}, fmt.Errorf("failed to encode %s %d: %w", entity, id, err)
+	}
+	return record{Entity: entity, ID: id, ProjectID: projectID, Value: value}, nil
+}
+
+// collect builds the records of the items, returning the latest update time of
+// the items.
+func collect[T any](
+	entity Entity,
+	items iter.Seq2[T, error],
+	build func(T) (id, projectID int64, updatedAt *time.Time),
+) ([]record, time.Time, error) {
+	var records []record
+	var latest time.Time
+	for item, err := range items {
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		id, projectID, updatedAt := build(item)
+		rec, err := newRecord(entity, id, projectID, item)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		records = append(records, rec)
+		if updatedAt != nil && updatedAt.After(latest) {
+			latest = *updatedAt
+		}
+	}
+	return records, latest, nil
+}
+
+// loadProjects loads the projects, including the archived ones.
+func loadProjects(ctx context.Context, engine *twapi.Engine, pageSize int64, since *time.Time) ([]record, time.Time, error) {
+	req := projects.NewProjectListRequest()
+	req.Filters.UpdatedAfter = since
+	req.Filters.IncludeArchivedProjects = new(true)
+	req.Filters.PageSize = pageSize
+
+	return collect(EntityProject, twapi.AllItems(ctx, engine, req, func(r *projects.ProjectListResponse) []projects.Project {
+		return r.Projects
+	}),
+		func(project projects.Project) (int64, int64, *time.Time) {
+			return project.ID, project.ID, project.UpdatedAt
+		},
+	)
+}
+
+// loadCompanies loads the companies.
+func loadCompanies(ctx context.Context, engine *twapi.Engine, pageSize int64, _ *time.Time) ([]record, time.Time, error) {
+	req := projects.NewCompanyListRequest()
+	req.Filters.PageSize = pageSize
+
+	return collect(EntityCompany, twapi.AllItems(ctx, engine, req, func(r *projects.CompanyListResponse) []projects.Company {
+		return r.Companies
+	}),
+		func(company projects.Company) (int64, int64, *time.Time) {
+			return company.ID, 0, nil
+		},
+	)
+}
+
+// loadUsers loads the users.
+func loadUsers(ctx context.Context, engine *twapi.Engine, pageSize int64, _ *time.Time) ([]record, time.Time, error) {
+	req := projects.NewUserListRequest()
+	req.Filters.PageSize = pageSize
+
+	return collect(EntityUser, twapi.AllItems(ctx, engine, req, func(r *projects.UserListResponse) []projects.User {
+		return r.Users
+	}),
+		func(user projects.User) (int64, int64, *time.Time) {
+			return user.ID, 0, nil
+		},
+	)
+}
+
+// loadTags loads the tags. The tags not belonging to a project are not indexed
+// by project.
+func loadTags(ctx context.Context, engine *twapi.Engine, pageSize int64, _ *time.Time) ([]record, time.Time, error) {
+	req := projects.NewTagListRequest()
+	req.Filters.PageSize = pageSize
+
+	return collect(EntityTag, twapi.AllItems(ctx, engine, req, func(r *projects.TagListResponse) []projects.Tag {
+		return r.Tags
+	}),
+		func(tag projects.Tag) (int64, int64, *time.Time) {
+			var projectID int64
+			if tag.Project != nil {
+				projectID = tag.Project.ID
+			}
+			return tag.ID, projectID, nil
+		},
+	)
+}
+
+// loadTasklists loads the tasklists, including the completed ones.
+func loadTasklists(ctx context.Context, engine *twapi.Engine, pageSize int64, _ *time.Time) ([]record, time.Time, error) {
+	req := projects.NewTasklistListRequest()
+	req.Filters.ShowCompleted = new(true)
+	req.Filters.PageSize = pageSize
+
+	return collect(EntityTasklist, twapi.AllItems(ctx, engine, req, func(r *projects.TasklistListResponse) []projects.Tasklist {
+		return r.Tasklists
+	}),
+		func(tasklist projects.Tasklist) (int64, int64, *time.Time) {
+			return tasklist.ID, tasklist.Project.ID, tasklist.UpdatedAt
+		},
+	)
+}
+
+// loadMilestones loads the milestones.
+func loadMilestones(ctx context.Context, engine *twapi.Engine, pageSize int64, _ *time.Time) ([]record, time.Time, error) {
+	req := projects.NewMilestoneListRequest()
+	req.Filters.PageSize = pageSize
+
+	return collect(EntityMilestone, twapi.AllItems(ctx, engine, req, func(r *projects.MilestoneListResponse) []projects.Milestone {
+		return r.Milestones
+	}),
+		func(milestone projects.Milestone) (int64, int64, *time.Time) {
+			return milestone.ID, milestone.Project.ID, milestone.UpdatedAt
+		},
+	)
+}
+
+// loadTasks loads the tasks, including the completed ones. A task is indexed by
+// the project of its tasklist, resolved when it is written, and by the users it
+// is assigned to.
+func loadTasks(ctx context.Context, engine *twapi.Engine, pageSize int64, since *time.Time) ([]record, time.Time, error) {
+	req := projects.NewTaskListRequest()
+	req.Filters.UpdatedAfter = since
+	req.Filters.IncludeCompletedTasks = new(true)
+	req.Filters.IncludeTasksFromCompletedTasklists = new(true)
+	req.Filters.PageSize = pageSize
+
+	var records []record
+	var latest time.Time
+	items := twapi.AllItems(ctx, engine, req, func(r *projects.TaskListResponse) []projects.Task {
+		return r.Tasks
+	})
+	for task, err := range items {
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		rec, err := newRecord(EntityTask, task.ID, 0, task)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		rec.tasklistID = task.Tasklist.ID
+		for _, assignee := range task.Assignees {
+			if assignee.Type == "users" {
+				rec.Assignees = append(rec.Assignees, assignee.ID)
+			}
+		}
+		records = append(records, rec)
+		if task.UpdatedAt.After(latest) {
+			latest = task.UpdatedAt
+		}
+	}
+	return records, latest, nil
+}
+
+// loadTimelogs loads the timelogs.
+func loadTimelogs(ctx context.Context, engine *twapi.Engine, pageSize int64, _ *time.Time) ([]record, time.Time, error) {
+	req := projects.NewTimelogListRequest()
+	req.Filters.PageSize = pageSize
+
+	return collect(EntityTimelog, twapi.AllItems(ctx, engine, req, func(r *projects.TimelogListResponse) []projects.Timelog {
+		return r.Timelogs
+	}),
+		func(timelog projects.Timelog) (int64, int64, *time.Time) {
+			return timelog.ID, timelog.Project.ID, timelog.UpdatedAt
+		},
+	)
+}
@@ -364,6 +364,20 @@ func (a *ActivityListResponse) Iterate() *ActivityListRequest {
 	return &req
 }
 
+// IterateRemaining returns the requests for every page after this one, so they
+// can be loaded concurrently. It returns nil when the number of pages is
+// unknown, such as when the request asked the API to skip the count query with
+// twapi.ListCountModeSkip, in which case Iterate must be used instead.
+func (a *ActivityListResponse) IterateRemaining() []ActivityListRequest {
+	var requests []ActivityListRequest
+	for page := range a.Meta.RemainingPages(a.request.Filters.Page, a.request.Filters.PageSize) {
+		req := a.request
+		req.Filters.Page = page
+		requests = append(requests, req)
+	}
+	return requests
+}
+
 // ActivityList retrieves multiple activities using the provided request and
 // returns the response.
 func ActivityList(
@@ -1208,6 +1208,20 @@ func (a *AllocationListResponse) Iterate() *AllocationListRequest {
 	return &req
 }
 
+// IterateRemaining returns the requests for every page after this one, so they
+// can be loaded concurrently. It returns nil when the number of pages is
+// unknown, such as when the request asked the API to skip the count query with
+// twapi.ListCountModeSkip, in which case Iterate must be used instead.
+func (a *AllocationListResponse) IterateRemaining() []AllocationListRequest {
+	var requests []AllocationListRequest
+	for page := range a.Meta.RemainingPages(a.request.Filters.Page, a.request.Filters.PageSize) {
+		req := a.request
+		req.Filters.Page = page
+		requests = append(requests, req)
+	}
+	return requests
+}
+
 // AllocationList retrieves multiple allocations using the provided request and
 // returns the response.
 func AllocationList(
@@ -827,6 +827,20 @@ func (t *CommentListResponse) Iterate() *CommentListRequest {
 	return &req
 }
 
+// IterateRemaining returns the requests for every page after this one, so they
+// can be loaded concurrently. It returns nil when the number of pages is
+// unknown, such as when the request asked the API to skip the count query with
+// twapi.ListCountModeSkip, in which case Iterate must be used instead.
+func (t *CommentListResponse) IterateRemaining() []CommentListRequest {
+	var requests []CommentListRequest
+	for page := range t.Meta.RemainingPages(t.request.Filters.Page, t.request.Filters.PageSize) {
+		req := t.request
+		req.Filters.Page = page
+		requests = append(requests, req)
+	}
+	return requests
+}
+
 // CommentList retrieves multiple comments using the provided request and
 // returns the response.
 func CommentList(
@@ -757,6 +757,20 @@ func (c *CompanyListResponse) Iterate() *CompanyListRequest {
 	return &req
 }
 
+// IterateRemaining returns the requests for every page after this one, so they
+// can be loaded concurrently. It returns nil when the number of pages is
+// unknown, such as when the request asked the API to skip the count query with
+// twapi.ListCountModeSkip, in which case Iterate must be used instead.
+func (c *CompanyListResponse) IterateRemaining() []CompanyListRequest {
+	var requests []CompanyListRequest
+	for page := range c.Meta.RemainingPages(c.request.Filters.Page, c.request.Filters.PageSize) {
+		req := c.request
+		req.Filters.Page = page
+		requests = append(requests, req)
+	}
+	return requests
+}
+
 // CompanyList retrieves multiple clients/companies using the provided request
 // and returns the response.
 func CompanyList(
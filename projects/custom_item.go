@@ -691,6 +691,20 @@ func (c *CustomItemListResponse) Iterate() *CustomItemListRequest {
 	return &req
 }
 
+// IterateRemaining returns the requests for every page after this one, so they
+// can be loaded concurrently. It returns nil when the number of pages is
+// unknown, such as when the request asked the API to skip the count query with
+// twapi.ListCountModeSkip, in which case Iterate must be used instead.
+func (c *CustomItemListResponse) IterateRemaining() []CustomItemListRequest {
+	var requests []CustomItemListRequest
+	for page := range c.Meta.RemainingPages(c.request.Filters.Page, c.request.Filters.PageSize) {
+		req := c.request
+		req.Filters.Page = page
+		requests = append(requests, req)
+	}
+	return requests
+}
+
 // CustomItemList retrieves the custom item types on a project using the
 // provided request.
 func CustomItemList(
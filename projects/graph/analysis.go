@@ -0,0 +1,246 @@
+package graph
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/projects"
+)
+
+// ErrCycle is returned when an analysis needs an acyclic graph, but tasks
+// depend on each other.
+var ErrCycle = errors.New("dependency cycle between tasks")
+
+// Cycles returns the groups of tasks depending on each other, directly or
+// through other tasks of the group. Each group is in ascending order, and the
+// groups are ordered by their first task. It returns nil when the graph is
+// acyclic.
+func (g *Graph) Cycles() [][]int64 {
+	// Tarjan's algorithm finds the strongly connected components, which are
+	// cycles when they contain several tasks or a task depending on itself
+	var (
+		index   int
+		indexes = make(map[int64]int, len(g.ids))
+		lowest  = make(map[int64]int, len(g.ids))
+		stacked = make(map[int64]bool)
+		stack   []int64
+		cycles  [][]int64
+	)
+
+	var visit func(id int64)
+	visit = func(id int64) {
+		indexes[id], lowest[id] = index, index
+		index++
+		stack = append(stack, id)
+		stacked[id] = true
+
+		for _, edge := range g.successors[id] {
+			if _, visited := indexes[edge.To]; !visited {
+				visit(edge.To)
+				lowest[id] = min(lowest[id], lowest[edge.To])
+			} else if stacked[edge.To] {
+				lowest[id] = min(lowest[id], indexes[edge.To])
+			}
+		}
+		if lowest[id] != indexes[id] {
+			return
+		}
+
+		var component []int64
+		for {
+			last := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			stacked[last] = false
+			component = append(component, last)
+			if last == id {
+				break
+			}
+		}
+		if len(component) > 1 || g.dependsOn(id, id) {
+			slices.Sort(component)
+			cycles = append(cycles, component)
+		}
+	}
+	for _, id := range g.ids {
+		if _, visited := indexes[id]; !visited {
+			visit(id)
+		}
+	}
+
+	slices.SortFunc(cycles, func(a, b []int64) int { return cmp.Compare(a[0], b[0]) })
+	return cycles
+}
+
+// dependsOn reports whether the task has the other one as direct predecessor.
+func (g *Graph) dependsOn(id, predecessorID int64) bool {
+	return slices.ContainsFunc(g.predecessors[id], func(edge Edge) bool {
+		return edge.From == predecessorID
+	})
+}
+
+// TopologicalOrder returns the identifiers of the tasks, every task coming
+// after its predecessors. Among the tasks whose predecessors are all ordered,
+// the lowest identifier comes first, so the order is stable. It returns an
+// error wrapping ErrCycle when tasks depend on each other.
+func (g *Graph) TopologicalOrder() ([]int64, error) {
+	pending := make(map[int64]int, len(g.ids))
+	var ready []int64
+	for _, id := range g.ids {
+		pending[id] = len(g.predecessors[id])
+		if pending[id] == 0 {
+			ready = append(ready, id)
+		}
+	}
+
+	order := make([]int64, 0, len(g.ids))
+	for len(ready) > 0 {
+		id := ready[0]
+		ready = ready[1:]
+		order = append(order, id)
+		for _, edge := range g.successors[id] {
+			pending[edge.To]--
+			if pending[edge.To] == 0 {
+				position, _ := slices.BinarySearch(ready, edge.To)
+				ready = slices.Insert(ready, position, edge.To)
+			}
+		}
+	}
+
+	if len(order) < len(g.ids) {
+		return nil, fmt.Errorf("%w: %v", ErrCycle, g.Cycles()[0])
+	}
+	return order, nil
+}
+
+// Duration returns how long the task takes: its estimated time, or the days it
+// is scheduled over when it has no estimate. Completed tasks take no time.
+func (g *Graph) Duration(id int64) time.Duration {
+	task, ok := g.tasks[id]
+	if !ok || completed(task) {
+		return 0
+	}
+	if task.EstimatedMinutes > 0 {
+		return time.Duration(task.EstimatedMinutes) * time.Minute
+	}
+	if task.StartAt == nil || task.DueAt == nil {
+		return 0
+	}
+	start, due := time.Time(*task.StartAt), time.Time(*task.DueAt)
+	if due.Before(start) {
+		return 0
+	}
+	days := int64(due.Sub(start).Hours()/24) + 1
+	return time.Duration(days) * g.workingDay
+}
+
+// Path is a chain of dependent tasks.
+type Path struct {
+	// Tasks contains the identifiers of the tasks, every task coming after the
+	// predecessor it waits for.
+	Tasks []int64
+
+	// Duration is how long the tasks take, once the work that can be done in
+	// parallel is accounted for.
+	Duration time.Duration
+}
+
+// CriticalPath returns the chain of dependent tasks that takes the longest,
+// which delays the completion of all the tasks when any of its tasks is late.
+// Every task is scheduled as early as its predecessors allow, taking the time
+// returned by Duration. It returns an error wrapping ErrCycle when tasks depend
+// on each other.
+func (g *Graph) CriticalPath() (Path, error) {
+	order, err := g.TopologicalOrder()
+	if err != nil {
+		return Path{}, err
+	}
+
+	// finish is the earliest time each task can be completed, and binding the
+	// predecessor that the completion waits for, if any
+	finish := make(map[int64]time.Duration, len(order))
+	binding := make(map[int64]int64, len(order))
+	var last int64
+	for _, id := range order {
+		var start time.Duration
+		for _, edge := range g.predecessors[id] {
+			if edge.Type == projects.TaskPredecessorTypeStart && finish[edge.From] > start {
+				start, binding[id] = finish[edge.From], edge.From
+			}
+		}
+		finish[id] = start + g.Duration(id)
+		for _, edge := range g.predecessors[id] {
+			if edge.Type == projects.TaskPredecessorTypeFinish && finish[edge.From] > finish[id] {
+				finish[id], binding[id] = finish[edge.From], edge.From
+			}
+		}
+		if last == 0 || finish[id] > finish[last] {
+			last = id
+		}
+	}
+	if last == 0 {
+		return Path{}, nil
+	}
+
+	path := Path{Duration: finish[last]}
+	for id, ok := last, true; ok; id, ok = binding[id] {
+		path.Tasks = append(path.Tasks, id)
+	}
+	slices.Reverse(path.Tasks)
+	return path, nil
+}
+
+// Violation is a dependency the schedule of the tasks does not respect.
+type Violation struct {
+	Edge
+
+	// PredecessorDueAt is the date the predecessor is due.
+	PredecessorDueAt twapi.Date
+
+	// ScheduledAt is the date of the task the dependency constrains: its start
+	// date when the dependency prevents it from starting, falling back to its due
+	// date, and its due date when the dependency prevents it from being
+	// completed.
+	ScheduledAt twapi.Date
+}
+
+// Violations returns the dependencies of the tasks scheduled before their
+// predecessors are due, ordered by task and then by predecessor. The completed
+// tasks and the tasks or predecessors without the dates to compare are
+// skipped.
+func (g *Graph) Violations() []Violation {
+	var violations []Violation
+	for _, id := range g.ids {
+		task := g.tasks[id]
+		if completed(task) {
+			continue
+		}
+		for _, edge := range g.predecessors[id] {
+			predecessor := g.tasks[edge.From]
+			if predecessor.DueAt == nil {
+				continue
+			}
+			scheduledAt := task.DueAt
+			if edge.Type == projects.TaskPredecessorTypeStart && task.StartAt != nil {
+				scheduledAt = task.StartAt
+			}
+			if scheduledAt == nil || !time.Time(*scheduledAt).Before(time.Time(*predecessor.DueAt)) {
+				continue
+			}
+			violations = append(violations, Violation{
+				Edge:             edge,
+				PredecessorDueAt: *predecessor.DueAt,
+				ScheduledAt:      *scheduledAt,
+			})
+		}
+	}
+	return violations
+}
+
+// completed reports whether the task was completed.
+func completed(task projects.Task) bool {
+	return task.CompletedAt != nil || task.Status == "completed"
+}
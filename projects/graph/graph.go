@@ -0,0 +1,178 @@
+// Package graph reasons over the dependencies between the tasks of a project.
+//
+// The tasks are loaded with their predecessors into a directed graph, where an
+// edge goes from a predecessor to the task depending on it. A dependency either
+// prevents the task from starting (TaskPredecessorTypeStart) or from being
+// completed (TaskPredecessorTypeFinish) until the predecessor is completed:
+//
+//	g, err := graph.Load(ctx, engine, projectID)
+//	if err != nil {
+//		return err
+//	}
+//	path, err := g.CriticalPath()
+//	if err != nil {
+//		return err // errors.Is(err, graph.ErrCycle) when tasks depend on each other
+//	}
+//	fmt.Println(path.Tasks, path.Duration)
+//
+// The graph is built once and is not modified afterwards, so it is safe for
+// concurrent use.
+package graph
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/projects"
+)
+
+// pageSize is the number of tasks loaded per page.
+const pageSize = 100
+
+// Edge is a dependency between two tasks.
+type Edge struct {
+	// From is the predecessor, which must be completed first.
+	From int64
+
+	// To is the task depending on the predecessor.
+	To int64
+
+	// Type is what the dependency prevents until the predecessor is completed:
+	// starting the task or completing it.
+	Type projects.TaskPredecessorType
+}
+
+// Option defines a function type that can modify the Graph configuration.
+type Option func(*Graph)
+
+// WithWorkingDay sets how long a day of work is, to convert the days a task is
+// scheduled over into a duration when it has no estimate. Defaults to 8 hours.
+func WithWorkingDay(workingDay time.Duration) Option {
+	return func(g *Graph) {
+		g.workingDay = workingDay
+	}
+}
+
+// Graph is the dependency graph of a set of tasks.
+type Graph struct {
+	workingDay time.Duration
+
+	ids          []int64
+	tasks        map[int64]projects.Task
+	predecessors map[int64][]Edge
+	successors   map[int64][]Edge
+	subtasks     map[int64][]int64
+}
+
+// Load loads the tasks of the project, including the completed ones, with their
+// predecessors and builds their graph.
+func Load(ctx context.Context, engine *twapi.Engine, projectID int64, opts ...Option) (*Graph, error) {
+	req := projects.NewTaskListRequest()
+	req.Path.ProjectID = projectID
+	req.Filters.IncludeRelatedTasks = true
+	req.Filters.IncludeCompletedPredecessors = true
+	req.Filters.IncludeCompletedTasks = new(true)
+	req.Filters.IncludeTasksFromCompletedTasklists = new(true)
+	req.Filters.PageSize = pageSize
+
+	var tasks []projects.Task
+	items := twapi.AllItems(ctx, engine, req, func(r *projects.TaskListResponse) []projects.Task {
+		return r.Tasks
+	})
+	for task, err := range items {
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tasks of project %d: %w", projectID, err)
+		}
+		tasks = append(tasks, task)
+	}
+	return New(tasks, opts...), nil
+}
+
+// New builds the graph of the tasks, which must have been loaded with the
+// IncludeRelatedTasks filter. The predecessors that are not part of the tasks,
+// such as tasks of other projects, are left out of the graph.
+func New(tasks []projects.Task, opts ...Option) *Graph {
+	g := &Graph{
+		workingDay:   8 * time.Hour,
+		tasks:        make(map[int64]projects.Task, len(tasks)),
+		predecessors: make(map[int64][]Edge),
+		successors:   make(map[int64][]Edge),
+		subtasks:     make(map[int64][]int64),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	for _, task := range tasks {
+		g.tasks[task.ID] = task
+	}
+	for _, task := range g.tasks {
+		g.ids = append(g.ids, task.ID)
+		for _, predecessor := range task.Predecessors {
+			if _, ok := g.tasks[predecessor.ID]; !ok {
+				continue
+			}
+			edge := Edge{From: predecessor.ID, To: task.ID, Type: predecessorType(predecessor)}
+			g.predecessors[task.ID] = append(g.predecessors[task.ID], edge)
+			g.successors[predecessor.ID] = append(g.successors[predecessor.ID], edge)
+		}
+		if task.ParentTask != nil {
+			g.subtasks[task.ParentTask.ID] = append(g.subtasks[task.ParentTask.ID], task.ID)
+		}
+	}
+
+	slices.Sort(g.ids)
+	for _, edges := range g.predecessors {
+		slices.SortFunc(edges, func(a, b Edge) int { return cmp.Compare(a.From, b.From) })
+	}
+	for _, edges := range g.successors {
+		slices.SortFunc(edges, func(a, b Edge) int { return cmp.Compare(a.To, b.To) })
+	}
+	for _, ids := range g.subtasks {
+		slices.Sort(ids)
+	}
+	return g
+}
+
+// predecessorType returns the type of dependency recorded in the metadata of
+// the predecessor. A dependency without type prevents the task from starting,
+// which is the default of the API.
+func predecessorType(predecessor twapi.Relationship) projects.TaskPredecessorType {
+	if value, ok := predecessor.Meta["type"].(string); ok && value == string(projects.TaskPredecessorTypeFinish) {
+		return projects.TaskPredecessorTypeFinish
+	}
+	return projects.TaskPredecessorTypeStart
+}
+
+// Tasks returns the identifiers of the tasks in the graph, in ascending order.
+func (g *Graph) Tasks() []int64 {
+	return slices.Clone(g.ids)
+}
+
+// Task returns the task with the identifier, reporting false when it is not in
+// the graph.
+func (g *Graph) Task(id int64) (projects.Task, bool) {
+	task, ok := g.tasks[id]
+	return task, ok
+}
+
+// Predecessors returns the dependencies of the task, ordered by predecessor.
+func (g *Graph) Predecessors(id int64) []Edge {
+	return slices.Clone(g.predecessors[id])
+}
+
+// Successors returns the dependencies on the task, ordered by successor.
+func (g *Graph) Successors(id int64) []Edge {
+	return slices.Clone(g.successors[id])
+}
+
+// Subtasks returns the identifiers of the subtasks of the task, in ascending
+// order. Subtasks are found through their ParentTask, so completed subtasks are
+// included even if the API left them out of SubTaskIDs.
+func (g *Graph) Subtasks(id int64) []int64 {
+	return slices.Clone(g.subtasks[id])
+}
@@ -0,0 +1,153 @@
+package graph_test
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/projects"
+	"github.com/teamwork/twapi-go-sdk/projects/graph"
+	"github.com/teamwork/twapi-go-sdk/twapitest"
+)
+
+func TestLoad(t *testing.T) {
+	server := twapitest.NewServer(t)
+	project := server.AddProject(projects.Project{Name: "Apollo"})
+	tasklist := server.AddTasklist(projects.Tasklist{Name: "Launch", Project: twapi.Relationship{ID: project.ID, Type: "projects"}})
+	inTasklist := twapi.Relationship{ID: tasklist.ID, Type: "tasklists"}
+
+	design := server.AddTask(projects.Task{
+		Name:             "Design the rocket",
+		Tasklist:         inTasklist,
+		EstimatedMinutes: 120,
+		DueAt:            date(2026, 3, 2),
+	})
+	build := server.AddTask(projects.Task{
+		Name:             "Build the rocket",
+		Tasklist:         inTasklist,
+		EstimatedMinutes: 60,
+		StartAt:          date(2026, 3, 1),
+		DueAt:            date(2026, 3, 3),
+		Predecessors:     []twapi.Relationship{predecessor(design.ID, projects.TaskPredecessorTypeStart)},
+	})
+	fuel := server.AddTask(projects.Task{
+		Name:             "Fuel the rocket",
+		Tasklist:         inTasklist,
+		EstimatedMinutes: 150,
+	})
+	launch := server.AddTask(projects.Task{
+		Name:             "Launch the rocket",
+		Tasklist:         inTasklist,
+		EstimatedMinutes: 30,
+		Predecessors: []twapi.Relationship{
+			predecessor(build.ID, projects.TaskPredecessorTypeStart),
+			predecessor(fuel.ID, projects.TaskPredecessorTypeFinish),
+		},
+	})
+	sketch := server.AddTask(projects.Task{
+		Name:             "Sketch the rocket",
+		Tasklist:         inTasklist,
+		EstimatedMinutes: 600,
+		ParentTask:       &twapi.Relationship{ID: design.ID, Type: "tasks"},
+		Status:           "completed",
+	})
+
+	g, err := graph.Load(t.Context(), server.Engine(), project.ID)
+	if err != nil {
+		t.Fatalf("failed to load graph: %s", err)
+	}
+
+	if subtasks := g.Subtasks(design.ID); !slices.Equal(subtasks, []int64{sketch.ID}) {
+		t.Errorf("expected the completed subtask %d but got %v", sketch.ID, subtasks)
+	}
+	wantEdges := []graph.Edge{
+		{From: build.ID, To: launch.ID, Type: projects.TaskPredecessorTypeStart},
+		{From: fuel.ID, To: launch.ID, Type: projects.TaskPredecessorTypeFinish},
+	}
+	if edges := g.Predecessors(launch.ID); !reflect.DeepEqual(edges, wantEdges) {
+		t.Errorf("expected predecessors %v but got %v", wantEdges, edges)
+	}
+
+	order, err := g.TopologicalOrder()
+	if err != nil {
+		t.Fatalf("failed to order tasks: %s", err)
+	}
+	if want := []int64{design.ID, build.ID, fuel.ID, launch.ID, sketch.ID}; !slices.Equal(order, want) {
+		t.Errorf("expected order %v but got %v", want, order)
+	}
+
+	// launching waits for building to start, and for fueling, which is shorter
+	// than designing and building, to complete
+	path, err := g.CriticalPath()
+	if err != nil {
+		t.Fatalf("failed to compute critical path: %s", err)
+	}
+	want := graph.Path{Tasks: []int64{design.ID, build.ID, launch.ID}, Duration: 210 * time.Minute}
+	if !reflect.DeepEqual(path, want) {
+		t.Errorf("expected critical path %v but got %v", want, path)
+	}
+
+	wantViolations := []graph.Violation{{
+		Edge:             graph.Edge{From: design.ID, To: build.ID, Type: projects.TaskPredecessorTypeStart},
+		PredecessorDueAt: *design.DueAt,
+		ScheduledAt:      *build.StartAt,
+	}}
+	if violations := g.Violations(); !reflect.DeepEqual(violations, wantViolations) {
+		t.Errorf("expected violations %v but got %v", wantViolations, violations)
+	}
+}
+
+func TestCycles(t *testing.T) {
+	g := graph.New([]projects.Task{
+		{ID: 1, Predecessors: []twapi.Relationship{predecessor(3, projects.TaskPredecessorTypeStart)}},
+		{ID: 2, Predecessors: []twapi.Relationship{predecessor(1, projects.TaskPredecessorTypeFinish)}},
+		{ID: 3, Predecessors: []twapi.Relationship{predecessor(2, projects.TaskPredecessorTypeStart)}},
+		{ID: 4, Predecessors: []twapi.Relationship{predecessor(4, projects.TaskPredecessorTypeStart)}},
+		// a predecessor outside the tasks is left out
+		{ID: 5, Predecessors: []twapi.Relationship{predecessor(99, projects.TaskPredecessorTypeStart)}},
+	})
+
+	if cycles, want := g.Cycles(), [][]int64{{1, 2, 3}, {4}}; !reflect.DeepEqual(cycles, want) {
+		t.Errorf("expected cycles %v but got %v", want, cycles)
+	}
+	if _, err := g.TopologicalOrder(); !errors.Is(err, graph.ErrCycle) {
+		t.Errorf("expected a cycle error but got %v", err)
+	}
+	if _, err := g.CriticalPath(); !errors.Is(err, graph.ErrCycle) {
+		t.Errorf("expected a cycle error but got %v", err)
+	}
+	if edges := g.Predecessors(5); len(edges) != 0 {
+		t.Errorf("expected no predecessor but got %v", edges)
+	}
+}
+
+func TestDuration(t *testing.T) {
+	g := graph.New([]projects.Task{
+		{ID: 1, EstimatedMinutes: 90, StartAt: date(2026, 3, 1), DueAt: date(2026, 3, 5)},
+		{ID: 2, StartAt: date(2026, 3, 1), DueAt: date(2026, 3, 3)},
+		{ID: 3, DueAt: date(2026, 3, 3)},
+		{ID: 4, EstimatedMinutes: 90, Status: "completed"},
+	}, graph.WithWorkingDay(6*time.Hour))
+
+	for id, want := range map[int64]time.Duration{
+		1: 90 * time.Minute,
+		2: 18 * time.Hour,
+		3: 0,
+		4: 0,
+	} {
+		if duration := g.Duration(id); duration != want {
+			t.Errorf("expected task %d to take %s but got %s", id, want, duration)
+		}
+	}
+}
+
+func date(year int, month time.Month, day int) *twapi.Date {
+	return new(twapi.Date(time.Date(year, month, day, 0, 0, 0, 0, time.UTC)))
+}
+
+func predecessor(id int64, predecessorType projects.TaskPredecessorType) twapi.Relationship {
+	return twapi.Relationship{ID: id, Type: "tasks", Meta: map[string]any{"type": string(predecessorType)}}
+}
@@ -0,0 +1,49 @@
+package projects_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/projects"
+	"github.com/teamwork/twapi-go-sdk/session"
+)
+
+func TestTaskListAllItems(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/projects/api/v3/tasks.json" {
+			http.NotFound(w, r)
+			return
+		}
+		switch page := r.URL.Query().Get("page"); page {
+		case "1":
+			_, _ = fmt.Fprint(w, `{"meta":{"page":{"hasMore":true}},"tasks":[{"id":1},{"id":2}]}`)
+		case "2":
+			_, _ = fmt.Fprint(w, `{"meta":{"page":{"hasMore":false}},"tasks":[{"id":3}]}`)
+		default:
+			t.Errorf("unexpected page %q", page)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	engine := twapi.NewEngine(session.NewBearerToken("token", server.URL))
+
+	var ids []int64
+	items := twapi.AllItems(t.Context(), engine, projects.NewTaskListRequest(), func(r *projects.TaskListResponse) []projects.Task {
+		return r.Tasks
+	})
+	for task, err := range items {
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		ids = append(ids, task.ID)
+	}
+
+	if want := []int64{1, 2, 3}; !slices.Equal(ids, want) {
+		t.Errorf("expected task IDs %v but got %v", want, ids)
+	}
+}
@@ -529,6 +529,20 @@ func (s *JobRoleListResponse) Iterate() *JobRoleListRequest {
 	return &req
 }
 
+// IterateRemaining returns the requests for every page after this one, so they
+// can be loaded concurrently. It returns nil when the number of pages is
+// unknown, such as when the request asked the API to skip the count query with
+// twapi.ListCountModeSkip, in which case Iterate must be used instead.
+func (s *JobRoleListResponse) IterateRemaining() []JobRoleListRequest {
+	var requests []JobRoleListRequest
+	for page := range s.Meta.RemainingPages(s.request.Filters.Page, s.request.Filters.PageSize) {
+		req := s.request
+		req.Filters.Page = page
+		requests = append(requests, req)
+	}
+	return requests
+}
+
 // JobRoleList retrieves multiple job roles using the provided request and
 // returns the response.
 func JobRoleList(
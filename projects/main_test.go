@@ -778,6 +778,26 @@ func createLink(t testEngine, projectID int64) (int64, func(), error) {
 	}, nil
 }
 
+func createWebhook(t testEngine, projectID int64) (int64, func(), error) {
+	webhookRequest := projects.NewWebhookCreateRequest(
+		projects.WebhookEventTaskCreated,
+		fmt.Sprintf("https://example.com/webhooks/test%d%d", time.Now().UnixNano(), rand.Intn(100)),
+	)
+	webhookRequest.Path.ProjectID = projectID
+	webhookResponse, err := projects.WebhookCreate(t.Context(), engine, webhookRequest)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create webhook for test: %w", err)
+	}
+	id := int64(webhookResponse.ID)
+	return id, func() {
+		ctx := context.Background() // t.Context is always canceled in cleanup
+		_, err := projects.WebhookDelete(ctx, engine, projects.NewWebhookDeleteRequest(id))
+		if err != nil {
+			t.Errorf("failed to delete webhook after test: %s", err)
+		}
+	}, nil
+}
+
 type testEngine interface {
 	Context() context.Context
 	Errorf(string, ...any)
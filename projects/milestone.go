@@ -629,6 +629,20 @@ func (m *MilestoneListResponse) Iterate() *MilestoneListRequest {
 	return &req
 }
 
+// IterateRemaining returns the requests for every page after this one, so they
+// can be loaded concurrently. It returns nil when the number of pages is
+// unknown, such as when the request asked the API to skip the count query with
+// twapi.ListCountModeSkip, in which case Iterate must be used instead.
+func (m *MilestoneListResponse) IterateRemaining() []MilestoneListRequest {
+	var requests []MilestoneListRequest
+	for page := range m.Meta.RemainingPages(m.request.Filters.Page, m.request.Filters.PageSize) {
+		req := m.request
+		req.Filters.Page = page
+		requests = append(requests, req)
+	}
+	return requests
+}
+
 // MilestoneList retrieves multiple milestones using the provided request and
 // returns the response.
 func MilestoneList(
@@ -1275,6 +1275,20 @@ func (p *ProjectListResponse) Iterate() *ProjectListRequest {
 	return &req
 }
 
+// IterateRemaining returns the requests for every page after this one, so they
+// can be loaded concurrently. It returns nil when the number of pages is
+// unknown, such as when the request asked the API to skip the count query with
+// twapi.ListCountModeSkip, in which case Iterate must be used instead.
+func (p *ProjectListResponse) IterateRemaining() []ProjectListRequest {
+	var requests []ProjectListRequest
+	for page := range p.Meta.RemainingPages(p.request.Filters.Page, p.request.Filters.PageSize) {
+		req := p.request
+		req.Filters.Page = page
+		requests = append(requests, req)
+	}
+	return requests
+}
+
 // ProjectList retrieves multiple projects using the provided request
 // and returns the response.
 func ProjectList(
@@ -489,6 +489,20 @@ func (p *ProjectCategoryListResponse) Iterate() *ProjectCategoryListRequest {
 	return &req
 }
 
+// IterateRemaining returns the requests for every page after this one, so they
+// can be loaded concurrently. It returns nil when the number of pages is
+// unknown, such as when the request asked the API to skip the count query with
+// twapi.ListCountModeSkip, in which case Iterate must be used instead.
+func (p *ProjectCategoryListResponse) IterateRemaining() []ProjectCategoryListRequest {
+	var requests []ProjectCategoryListRequest
+	for page := range p.Meta.RemainingPages(p.request.Filters.Page, p.request.Filters.PageSize) {
+		req := p.request
+		req.Filters.Page = page
+		requests = append(requests, req)
+	}
+	return requests
+}
+
 // ProjectCategoryList retrieves multiple project categories using the provided
 // request and returns the response.
 func ProjectCategoryList(
@@ -421,6 +421,20 @@ func (r *RateInstallationUserListResponse) Iterate() *RateInstallationUserListRe
 	return &req
 }
 
+// IterateRemaining returns the requests for every page after this one, so they
+// can be loaded concurrently. It returns nil when the number of pages is
+// unknown, such as when the request asked the API to skip the count query with
+// twapi.ListCountModeSkip, in which case Iterate must be used instead.
+func (r *RateInstallationUserListResponse) IterateRemaining() []RateInstallationUserListRequest {
+	var requests []RateInstallationUserListRequest
+	for page := range r.Meta.RemainingPages(r.request.Filters.Page, r.request.Filters.PageSize) {
+		req := r.request
+		req.Filters.Page = page
+		requests = append(requests, req)
+	}
+	return requests
+}
+
 // RateInstallationUserList retrieves installation user rates using the provided
 // request and returns the response.
 func RateInstallationUserList(
@@ -1139,6 +1153,20 @@ func (r *RateProjectUserListResponse) Iterate() *RateProjectUserListRequest {
 	return &req
 }
 
+// IterateRemaining returns the requests for every page after this one, so they
+// can be loaded concurrently. It returns nil when the number of pages is
+// unknown, such as when the request asked the API to skip the count query with
+// twapi.ListCountModeSkip, in which case Iterate must be used instead.
+func (r *RateProjectUserListResponse) IterateRemaining() []RateProjectUserListRequest {
+	var requests []RateProjectUserListRequest
+	for page := range r.Meta.RemainingPages(r.request.Filters.Page, r.request.Filters.PageSize) {
+		req := r.request
+		req.Filters.Page = page
+		requests = append(requests, req)
+	}
+	return requests
+}
+
 // RateProjectUserList retrieves project user rates using the provided request
 // and returns the response.
 func RateProjectUserList(
@@ -1522,6 +1550,20 @@ func (r *RateProjectUserHistoryGetResponse) Iterate() *RateProjectUserHistoryGet
 	return &req
 }
 
+// IterateRemaining returns the requests for every page after this one, so they
+// can be loaded concurrently. It returns nil when the number of pages is
+// unknown, such as when the request asked the API to skip the count query with
+// twapi.ListCountModeSkip, in which case Iterate must be used instead.
+func (r *RateProjectUserHistoryGetResponse) IterateRemaining() []RateProjectUserHistoryGetRequest {
+	var requests []RateProjectUserHistoryGetRequest
+	for page := range r.Meta.RemainingPages(r.request.Filters.Page, r.request.Filters.PageSize) {
+		req := r.request
+		req.Filters.Page = page
+		requests = append(requests, req)
+	}
+	return requests
+}
+
 // RateProjectUserHistoryGet retrieves project user rate history using the
 // provided request and returns the response.
 func RateProjectUserHistoryGet(
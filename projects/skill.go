@@ -518,6 +518,20 @@ func (s *SkillListResponse) Iterate() *SkillListRequest {
 	return &req
 }
 
+// IterateRemaining returns the requests for every page after this one, so they
+// can be loaded concurrently. It returns nil when the number of pages is
+// unknown, such as when the request asked the API to skip the count query with
+// twapi.ListCountModeSkip, in which case Iterate must be used instead.
+func (s *SkillListResponse) IterateRemaining() []SkillListRequest {
+	var requests []SkillListRequest
+	for page := range s.Meta.RemainingPages(s.request.Filters.Page, s.request.Filters.PageSize) {
+		req := s.request
+		req.Filters.Page = page
+		requests = append(requests, req)
+	}
+	return requests
+}
+
 // SkillList retrieves multiple skills using the provided request and returns
 // the response.
 func SkillList(
@@ -3,6 +3,7 @@ package projects_test
 import (
 	"context"
 	"net/url"
+	"slices"
 	"testing"
 
 	"github.com/teamwork/twapi-go-sdk"
@@ -91,3 +92,31 @@ func TestTasklistBudgetListIterate(t *testing.T) {
 		t.Fatal("expected no next request when hasMore=false")
 	}
 }
+
+func TestTasklistBudgetListIterateRemaining(t *testing.T) {
+	resp := &projects.TasklistBudgetListResponse{}
+	req := projects.NewTasklistBudgetListRequest(987)
+	req.Filters.Page = 2
+	req.Filters.PageSize = 10
+
+	count := int64(45)
+	resp.Meta.Page.Count = &count
+	resp.SetRequest(req)
+
+	var pages []int64
+	for _, next := range resp.IterateRemaining() {
+		if next.Path.ProjectBudgetID != 987 {
+			t.Errorf("expected project budget 987 but got %d", next.Path.ProjectBudgetID)
+		}
+		pages = append(pages, next.Filters.Page)
+	}
+	if want := []int64{3, 4, 5}; !slices.Equal(pages, want) {
+		t.Errorf("expected pages %v but got %v", want, pages)
+	}
+
+	req.Filters.CountMode = twapi.ListCountModeSkip
+	resp.SetRequest(req)
+	if remaining := resp.IterateRemaining(); remaining != nil {
+		t.Errorf("expected no requests when the count is skipped but got %d", len(remaining))
+	}
+}
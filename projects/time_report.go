@@ -471,6 +471,20 @@ func (r *TimeReportListResponse) Iterate() *TimeReportListRequest {
 	return &req
 }
 
+// IterateRemaining returns the requests for every page after this one, so they
+// can be loaded concurrently. It returns nil when the number of pages is
+// unknown, such as when the request asked the API to skip the count query with
+// twapi.ListCountModeSkip, in which case Iterate must be used instead.
+func (r *TimeReportListResponse) IterateRemaining() []TimeReportListRequest {
+	var requests []TimeReportListRequest
+	for page := range r.Meta.RemainingPages(r.request.Filters.Page, r.request.Filters.PageSize) {
+		req := r.request
+		req.Filters.Page = page
+		requests = append(requests, req)
+	}
+	return requests
+}
+
 // TimeReportList retrieves a grouped time report using the provided request and
 // returns the response.
 func TimeReportList(
@@ -760,6 +760,20 @@ func (t *TimelogListResponse) Iterate() *TimelogListRequest {
 	return &req
 }
 
+// IterateRemaining returns the requests for every page after this one, so they
+// can be loaded concurrently. It returns nil when the number of pages is
+// unknown, such as when the request asked the API to skip the count query with
+// twapi.ListCountModeSkip, in which case Iterate must be used instead.
+func (t *TimelogListResponse) IterateRemaining() []TimelogListRequest {
+	var requests []TimelogListRequest
+	for page := range t.Meta.RemainingPages(t.request.Filters.Page, t.request.Filters.PageSize) {
+		req := t.request
+		req.Filters.Page = page
+		requests = append(requests, req)
+	}
+	return requests
+}
+
 // TimelogList retrieves multiple timelogs using the provided request and
 // returns the response.
 func TimelogList(
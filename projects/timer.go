@@ -776,6 +776,20 @@ func (u *TimerListResponse) Iterate() *TimerListRequest {
 	return &req
 }
 
+// IterateRemaining returns the requests for every page after this one, so they
+// can be loaded concurrently. It returns nil when the number of pages is
+// unknown, such as when the request asked the API to skip the count query with
+// twapi.ListCountModeSkip, in which case Iterate must be used instead.
+func (u *TimerListResponse) IterateRemaining() []TimerListRequest {
+	var requests []TimerListRequest
+	for page := range u.Meta.RemainingPages(u.request.Filters.Page, u.request.Filters.PageSize) {
+		req := u.request
+		req.Filters.Page = page
+		requests = append(requests, req)
+	}
+	return requests
+}
+
 // TimerList retrieves multiple timers using the provided request and returns
 // the response.
 func TimerList(
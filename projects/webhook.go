@@ -0,0 +1,516 @@
+package projects
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+)
+
+var (
+	_ twapi.HTTPRequester = (*WebhookCreateRequest)(nil)
+	_ twapi.HTTPResponser = (*WebhookCreateResponse)(nil)
+	_ twapi.HTTPRequester = (*WebhookUpdateRequest)(nil)
+	_ twapi.HTTPResponser = (*WebhookUpdateResponse)(nil)
+	_ twapi.HTTPRequester = (*WebhookDeleteRequest)(nil)
+	_ twapi.HTTPResponser = (*WebhookDeleteResponse)(nil)
+	_ twapi.HTTPRequester = (*WebhookGetRequest)(nil)
+	_ twapi.HTTPResponser = (*WebhookGetResponse)(nil)
+	_ twapi.HTTPRequester = (*WebhookListRequest)(nil)
+	_ twapi.HTTPResponser = (*WebhookListResponse)(nil)
+)
+
+// Webhook is a subscription that makes Teamwork.com send an HTTP request to a
+// URL every time a given event happens, such as a task being created or a
+// comment being posted. Webhooks keep integrations in sync without polling the
+// API, and can be registered for the whole installation or for a single
+// project. Each request is signed with the webhook token, so the receiver can
+// verify that it was sent by Teamwork.com.
+//
+// More information can be found at:
+// https://support.teamwork.com/projects/webhooks/webhooks-overview
+type Webhook struct {
+	// ID is the unique identifier of the webhook.
+	ID LegacyNumber `json:"id"`
+
+	// Event is the event that triggers the webhook.
+	Event WebhookEvent `json:"event"`
+
+	// URL is the address the webhook requests are sent to.
+	URL string `json:"url"`
+
+	// Token is the secret used to sign the webhook requests.
+	Token string `json:"token"`
+
+	// ContentType is the format of the body of the webhook requests.
+	ContentType WebhookContentType `json:"contentType"`
+
+	// Version is the version of the payload sent in the webhook requests.
+	Version string `json:"version"`
+
+	// Status indicates whether the webhook requests are being sent.
+	Status WebhookStatus `json:"status"`
+
+	// ProjectID is the unique identifier of the project the webhook is scoped
+	// to. It is zero for webhooks triggered by events of any project.
+	ProjectID LegacyNumber `json:"projectId"`
+}
+
+// WebhookEvent contains all possible webhook events.
+type WebhookEvent string
+
+// List of webhook events.
+const (
+	WebhookEventTaskCreated   WebhookEvent = "TASK.CREATED"
+	WebhookEventTaskUpdated   WebhookEvent = "TASK.UPDATED"
+	WebhookEventTaskDeleted   WebhookEvent = "TASK.DELETED"
+	WebhookEventTaskCompleted WebhookEvent = "TASK.COMPLETED"
+	WebhookEventTaskReopened  WebhookEvent = "TASK.REOPENED"
+	WebhookEventTaskMoved     WebhookEvent = "TASK.MOVED"
+
+	WebhookEventTasklistCreated WebhookEvent = "TASKLIST.CREATED"
+	WebhookEventTasklistUpdated WebhookEvent = "TASKLIST.UPDATED"
+	WebhookEventTasklistDeleted WebhookEvent = "TASKLIST.DELETED"
+
+	WebhookEventCommentCreated WebhookEvent = "COMMENT.CREATED"
+	WebhookEventCommentUpdated WebhookEvent = "COMMENT.UPDATED"
+	WebhookEventCommentDeleted WebhookEvent = "COMMENT.DELETED"
+
+	WebhookEventTimelogCreated WebhookEvent = "TIME.CREATED"
+	WebhookEventTimelogUpdated WebhookEvent = "TIME.UPDATED"
+	WebhookEventTimelogDeleted WebhookEvent = "TIME.DELETED"
+
+	WebhookEventMilestoneCreated   WebhookEvent = "MILESTONE.CREATED"
+	WebhookEventMilestoneUpdated   WebhookEvent = "MILESTONE.UPDATED"
+	WebhookEventMilestoneDeleted   WebhookEvent = "MILESTONE.DELETED"
+	WebhookEventMilestoneCompleted WebhookEvent = "MILESTONE.COMPLETED"
+	WebhookEventMilestoneReopened  WebhookEvent = "MILESTONE.REOPENED"
+
+	WebhookEventMessageCreated WebhookEvent = "MESSAGE.CREATED"
+	WebhookEventMessageUpdated WebhookEvent = "MESSAGE.UPDATED"
+	WebhookEventMessageDeleted WebhookEvent = "MESSAGE.DELETED"
+
+	WebhookEventProjectCreated   WebhookEvent = "PROJECT.CREATED"
+	WebhookEventProjectUpdated   WebhookEvent = "PROJECT.UPDATED"
+	WebhookEventProjectDeleted   WebhookEvent = "PROJECT.DELETED"
+	WebhookEventProjectCompleted WebhookEvent = "PROJECT.COMPLETED"
+	WebhookEventProjectArchived  WebhookEvent = "PROJECT.ARCHIVED"
+	WebhookEventProjectReopened  WebhookEvent = "PROJECT.REOPENED"
+)
+
+// WebhookContentType contains all possible formats of the webhook requests.
+type WebhookContentType string
+
+// List of webhook content types.
+const (
+	WebhookContentTypeJSON WebhookContentType = "application/json"
+	WebhookContentTypeForm WebhookContentType = "application/x-www-form-urlencoded"
+)
+
+// WebhookStatus contains all possible webhook statuses.
+type WebhookStatus string
+
+// List of webhook statuses.
+const (
+	WebhookStatusActive   WebhookStatus = "ACTIVE"
+	WebhookStatusInactive WebhookStatus = "INACTIVE"
+)
+
+// WebhookCreateRequestPath contains the path parameters for creating a
+// webhook.
+type WebhookCreateRequestPath struct {
+	// ProjectID is the optional unique identifier of the project the webhook is
+	// scoped to. When zero, the webhook is triggered by events of any project.
+	ProjectID int64
+}
+
+// WebhookCreateRequest represents the request body for creating a new webhook.
+//
+// https://apidocs.teamwork.com/docs/teamwork/v1/webhooks/post-webhooks-json
+type WebhookCreateRequest struct {
+	// Path contains the path parameters for the request.
+	Path WebhookCreateRequestPath `json:"-"`
+
+	// Event is the event that triggers the webhook. This field is required.
+	Event WebhookEvent `json:"event"`
+
+	// URL is the address the webhook requests are sent to. This field is
+	// required.
+	URL string `json:"url"`
+
+	// Token is the optional secret used to sign the webhook requests.
+	Token *string `json:"token,omitempty"`
+
+	// ContentType is the optional format of the body of the webhook requests.
+	// Defaults to WebhookContentTypeForm.
+	ContentType *WebhookContentType `json:"contentType,omitempty"`
+
+	// Version is the optional version of the payload sent in the webhook
+	// requests.
+	Version *string `json:"version,omitempty"`
+}
+
+// NewWebhookCreateRequest creates a new WebhookCreateRequest with the provided
+// event and URL. The event and URL are required to create a new webhook.
+func NewWebhookCreateRequest(event WebhookEvent, url string) WebhookCreateRequest {
+	return WebhookCreateRequest{
+		Event: event,
+		URL:   url,
+	}
+}
+
+// HTTPRequest creates an HTTP request for the WebhookCreateRequest.
+func (w WebhookCreateRequest) HTTPRequest(ctx context.Context, server string) (*http.Request, error) {
+	uri := server + "/webhooks.json"
+	if w.Path.ProjectID > 0 {
+		uri = fmt.Sprintf("%s/projects/%d/webhooks.json", server, w.Path.ProjectID)
+	}
+
+	payload := struct {
+		Webhook WebhookCreateRequest `json:"webhook"`
+	}{Webhook: w}
+
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(payload); err != nil {
+		return nil, fmt.Errorf("failed to encode create webhook request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return req, nil
+}
+
+// WebhookCreateResponse represents the response body for creating a new
+// webhook.
+//
+// https://apidocs.teamwork.com/docs/teamwork/v1/webhooks/post-webhooks-json
+type WebhookCreateResponse struct {
+	// ID is the unique identifier of the created webhook.
+	ID LegacyNumber `json:"id"`
+}
+
+// HandleHTTPResponse handles the HTTP response for the WebhookCreateResponse.
+// If some unexpected HTTP status code is returned by the API, a twapi.HTTPError
+// is returned.
+func (w *WebhookCreateResponse) HandleHTTPResponse(resp *http.Response) error {
+	if resp.StatusCode != http.StatusCreated {
+		return twapi.NewHTTPError(resp, "failed to create webhook")
+	}
+	if err := json.NewDecoder(resp.Body).Decode(w); err != nil {
+		return fmt.Errorf("failed to decode create webhook response: %w", err)
+	}
+	if w.ID == 0 {
+		return fmt.Errorf("create webhook response does not contain a valid identifier")
+	}
+	return nil
+}
+
+// WebhookCreate creates a new webhook using the provided request and returns
+// the response.
+func WebhookCreate(
+	ctx context.Context,
+	engine *twapi.Engine,
+	req WebhookCreateRequest,
+) (*WebhookCreateResponse, error) {
+	return twapi.Execute[WebhookCreateRequest, *WebhookCreateResponse](ctx, engine, req)
+}
+
+// WebhookUpdateRequestPath contains the path parameters for updating a
+// webhook.
+type WebhookUpdateRequestPath struct {
+	// ID is the unique identifier of the webhook to be updated.
+	ID int64
+}
+
+// WebhookUpdateRequest represents the request body for updating a webhook.
+// Besides the identifier, all other fields are optional. When a field is not
+// provided, it will not be modified.
+//
+// https://apidocs.teamwork.com/docs/teamwork/v1/webhooks/put-webhooks-id-json
+type WebhookUpdateRequest struct {
+	// Path contains the path parameters for the request.
+	Path WebhookUpdateRequestPath `json:"-"`
+
+	// Event is the event that triggers the webhook.
+	Event *WebhookEvent `json:"event,omitempty"`
+
+	// URL is the address the webhook requests are sent to.
+	URL *string `json:"url,omitempty"`
+
+	// Token is the secret used to sign the webhook requests.
+	Token *string `json:"token,omitempty"`
+
+	// ContentType is the format of the body of the webhook requests.
+	ContentType *WebhookContentType `json:"contentType,omitempty"`
+
+	// Version is the version of the payload sent in the webhook requests.
+	Version *string `json:"version,omitempty"`
+}
+
+// NewWebhookUpdateRequest creates a new WebhookUpdateRequest with the provided
+// webhook ID. The ID is required to update a webhook.
+func NewWebhookUpdateRequest(webhookID int64) WebhookUpdateRequest {
+	return WebhookUpdateRequest{
+		Path: WebhookUpdateRequestPath{
+			ID: webhookID,
+		},
+	}
+}
+
+// HTTPRequest creates an HTTP request for the WebhookUpdateRequest.
+func (w WebhookUpdateRequest) HTTPRequest(ctx context.Context, server string) (*http.Request, error) {
+	uri := server + "/webhooks/" + strconv.FormatInt(w.Path.ID, 10) + ".json"
+
+	payload := struct {
+		Webhook WebhookUpdateRequest `json:"webhook"`
+	}{Webhook: w}
+
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(payload); err != nil {
+		return nil, fmt.Errorf("failed to encode update webhook request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, uri, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return req, nil
+}
+
+// WebhookUpdateResponse represents the response body for updating a webhook.
+//
+// https://apidocs.teamwork.com/docs/teamwork/v1/webhooks/put-webhooks-id-json
+type WebhookUpdateResponse struct{}
+
+// HandleHTTPResponse handles the HTTP response for the WebhookUpdateResponse.
+// If some unexpected HTTP status code is returned by the API, a twapi.HTTPError
+// is returned.
+func (w *WebhookUpdateResponse) HandleHTTPResponse(resp *http.Response) error {
+	if resp.StatusCode != http.StatusOK {
+		return twapi.NewHTTPError(resp, "failed to update webhook")
+	}
+	if err := json.NewDecoder(resp.Body).Decode(w); err != nil {
+		return fmt.Errorf("failed to decode update webhook response: %w", err)
+	}
+	return nil
+}
+
+// WebhookUpdate updates a webhook using the provided request and returns the
+// response.
+func WebhookUpdate(
+	ctx context.Context,
+	engine *twapi.Engine,
+	req WebhookUpdateRequest,
+) (*WebhookUpdateResponse, error) {
+	return twapi.Execute[WebhookUpdateRequest, *WebhookUpdateResponse](ctx, engine, req)
+}
+
+// WebhookDeleteRequestPath contains the path parameters for deleting a
+// webhook.
+type WebhookDeleteRequestPath struct {
+	// ID is the unique identifier of the webhook to be deleted.
+	ID int64
+}
+
+// WebhookDeleteRequest represents the request body for deleting a webhook.
+//
+// https://apidocs.teamwork.com/docs/teamwork/v1/webhooks/delete-webhooks-id-json
+type WebhookDeleteRequest struct {
+	// Path contains the path parameters for the request.
+	Path WebhookDeleteRequestPath
+}
+
+// NewWebhookDeleteRequest creates a new WebhookDeleteRequest with the provided
+// webhook ID.
+func NewWebhookDeleteRequest(webhookID int64) WebhookDeleteRequest {
+	return WebhookDeleteRequest{
+		Path: WebhookDeleteRequestPath{
+			ID: webhookID,
+		},
+	}
+}
+
+// HTTPRequest creates an HTTP request for the WebhookDeleteRequest.
+func (w WebhookDeleteRequest) HTTPRequest(ctx context.Context, server string) (*http.Request, error) {
+	uri := server + "/webhooks/" + strconv.FormatInt(w.Path.ID, 10) + ".json"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// WebhookDeleteResponse represents the response body for deleting a webhook.
+//
+// https://apidocs.teamwork.com/docs/teamwork/v1/webhooks/delete-webhooks-id-json
+type WebhookDeleteResponse struct{}
+
+// HandleHTTPResponse handles the HTTP response for the WebhookDeleteResponse.
+// If some unexpected HTTP status code is returned by the API, a twapi.HTTPError
+// is returned.
+func (w *WebhookDeleteResponse) HandleHTTPResponse(resp *http.Response) error {
+	if resp.StatusCode != http.StatusOK {
+		return twapi.NewHTTPError(resp, "failed to delete webhook")
+	}
+	if err := json.NewDecoder(resp.Body).Decode(w); err != nil {
+		return fmt.Errorf("failed to decode delete webhook response: %w", err)
+	}
+	return nil
+}
+
+// WebhookDelete deletes a webhook using the provided request and returns the
+// response.
+func WebhookDelete(
+	ctx context.Context,
+	engine *twapi.Engine,
+	req WebhookDeleteRequest,
+) (*WebhookDeleteResponse, error) {
+	return twapi.Execute[WebhookDeleteRequest, *WebhookDeleteResponse](ctx, engine, req)
+}
+
+// WebhookGetRequestPath contains the path parameters for loading a single
+// webhook.
+type WebhookGetRequestPath struct {
+	// ID is the unique identifier of the webhook to be retrieved.
+	ID int64
+}
+
+// WebhookGetRequest represents the request body for loading a single webhook.
+//
+// https://apidocs.teamwork.com/docs/teamwork/v1/webhooks/get-webhooks-id-json
+type WebhookGetRequest struct {
+	// Path contains the path parameters for the request.
+	Path WebhookGetRequestPath
+}
+
+// NewWebhookGetRequest creates a new WebhookGetRequest with the provided
+// webhook ID. The ID is required to load a webhook.
+func NewWebhookGetRequest(webhookID int64) WebhookGetRequest {
+	return WebhookGetRequest{
+		Path: WebhookGetRequestPath{
+			ID: webhookID,
+		},
+	}
+}
+
+// HTTPRequest creates an HTTP request for the WebhookGetRequest.
+func (w WebhookGetRequest) HTTPRequest(ctx context.Context, server string) (*http.Request, error) {
+	uri := server + "/webhooks/" + strconv.FormatInt(w.Path.ID, 10) + ".json"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// WebhookGetResponse contains all the information related to a webhook.
+//
+// https://apidocs.teamwork.com/docs/teamwork/v1/webhooks/get-webhooks-id-json
+type WebhookGetResponse struct {
+	Webhook Webhook `json:"webhook"`
+}
+
+// HandleHTTPResponse handles the HTTP response for the WebhookGetResponse. If
+// some unexpected HTTP status code is returned by the API, a twapi.HTTPError is
+// returned.
+func (w *WebhookGetResponse) HandleHTTPResponse(resp *http.Response) error {
+	if resp.StatusCode != http.StatusOK {
+		return twapi.NewHTTPError(resp, "failed to retrieve webhook")
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(w); err != nil {
+		return fmt.Errorf("failed to decode retrieve webhook response: %w", err)
+	}
+	return nil
+}
+
+// WebhookGet retrieves a single webhook using the provided request and returns
+// the response.
+func WebhookGet(
+	ctx context.Context,
+	engine *twapi.Engine,
+	req WebhookGetRequest,
+) (*WebhookGetResponse, error) {
+	return twapi.Execute[WebhookGetRequest, *WebhookGetResponse](ctx, engine, req)
+}
+
+// WebhookListRequestPath contains the path parameters for loading multiple
+// webhooks.
+type WebhookListRequestPath struct {
+	// ProjectID is the optional unique identifier of the project to retrieve the
+	// webhooks from. When zero, the webhooks of the installation are retrieved.
+	ProjectID int64
+}
+
+// WebhookListRequest represents the request body for loading multiple webhooks.
+//
+// https://apidocs.teamwork.com/docs/teamwork/v1/webhooks/get-webhooks-json
+type WebhookListRequest struct {
+	// Path contains the path parameters for the request.
+	Path WebhookListRequestPath
+}
+
+// NewWebhookListRequest creates a new WebhookListRequest with default values.
+func NewWebhookListRequest() WebhookListRequest {
+	return WebhookListRequest{}
+}
+
+// HTTPRequest creates an HTTP request for the WebhookListRequest.
+func (w WebhookListRequest) HTTPRequest(ctx context.Context, server string) (*http.Request, error) {
+	uri := server + "/webhooks.json"
+	if w.Path.ProjectID > 0 {
+		uri = fmt.Sprintf("%s/projects/%d/webhooks.json", server, w.Path.ProjectID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// WebhookListResponse contains information by multiple webhooks matching the
+// request.
+//
+// https://apidocs.teamwork.com/docs/teamwork/v1/webhooks/get-webhooks-json
+type WebhookListResponse struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+// HandleHTTPResponse handles the HTTP response for the WebhookListResponse. If
+// some unexpected HTTP status code is returned by the API, a twapi.HTTPError is
+// returned.
+func (w *WebhookListResponse) HandleHTTPResponse(resp *http.Response) error {
+	if resp.StatusCode != http.StatusOK {
+		return twapi.NewHTTPError(resp, "failed to list webhooks")
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(w); err != nil {
+		return fmt.Errorf("failed to decode list webhooks response: %w", err)
+	}
+	return nil
+}
+
+// WebhookList retrieves multiple webhooks using the provided request and
+// returns the response.
+func WebhookList(
+	ctx context.Context,
+	engine *twapi.Engine,
+	req WebhookListRequest,
+) (*WebhookListResponse, error) {
+	return twapi.Execute[WebhookListRequest, *WebhookListResponse](ctx, engine, req)
+}
@@ -0,0 +1,218 @@
+package projects_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/projects"
+	"github.com/teamwork/twapi-go-sdk/session"
+)
+
+func ExampleWebhookCreate() {
+	address, stop, err := startWebhookServer() // mock server for demonstration purposes
+	if err != nil {
+		fmt.Printf("failed to start server: %s", err)
+		return
+	}
+	defer stop()
+
+	ctx := context.Background()
+	engine := twapi.NewEngine(session.NewBearerToken("your_token", fmt.Sprintf("http://%s", address)))
+
+	webhookRequest := projects.NewWebhookCreateRequest(projects.WebhookEventTaskCreated, "https://example.com/webhooks")
+	webhookRequest.Path.ProjectID = 777
+	webhookRequest.Token = new("secret")
+	webhookRequest.ContentType = new(projects.WebhookContentTypeJSON)
+
+	webhookResponse, err := projects.WebhookCreate(ctx, engine, webhookRequest)
+	if err != nil {
+		fmt.Printf("failed to create webhook: %s", err)
+	} else {
+		fmt.Printf("created webhook with identifier %d\n", webhookResponse.ID)
+	}
+
+	// Output: created webhook with identifier 12345
+}
+
+func ExampleWebhookUpdate() {
+	address, stop, err := startWebhookServer() // mock server for demonstration purposes
+	if err != nil {
+		fmt.Printf("failed to start server: %s", err)
+		return
+	}
+	defer stop()
+
+	ctx := context.Background()
+	engine := twapi.NewEngine(session.NewBearerToken("your_token", fmt.Sprintf("http://%s", address)))
+
+	webhookRequest := projects.NewWebhookUpdateRequest(12345)
+	webhookRequest.URL = new("https://example.com/hooks")
+
+	_, err = projects.WebhookUpdate(ctx, engine, webhookRequest)
+	if err != nil {
+		fmt.Printf("failed to update webhook: %s", err)
+	} else {
+		fmt.Println("webhook updated!")
+	}
+
+	// Output: webhook updated!
+}
+
+func ExampleWebhookDelete() {
+	address, stop, err := startWebhookServer() // mock server for demonstration purposes
+	if err != nil {
+		fmt.Printf("failed to start server: %s", err)
+		return
+	}
+	defer stop()
+
+	ctx := context.Background()
+	engine := twapi.NewEngine(session.NewBearerToken("your_token", fmt.Sprintf("http://%s", address)))
+
+	_, err = projects.WebhookDelete(ctx, engine, projects.NewWebhookDeleteRequest(12345))
+	if err != nil {
+		fmt.Printf("failed to delete webhook: %s", err)
+	} else {
+		fmt.Println("webhook deleted!")
+	}
+
+	// Output: webhook deleted!
+}
+
+func ExampleWebhookGet() {
+	address, stop, err := startWebhookServer() // mock server for demonstration purposes
+	if err != nil {
+		fmt.Printf("failed to start server: %s", err)
+		return
+	}
+	defer stop()
+
+	ctx := context.Background()
+	engine := twapi.NewEngine(session.NewBearerToken("your_token", fmt.Sprintf("http://%s", address)))
+
+	webhookResponse, err := projects.WebhookGet(ctx, engine, projects.NewWebhookGetRequest(12345))
+	if err != nil {
+		fmt.Printf("failed to retrieve webhook: %s", err)
+	} else {
+		fmt.Printf("retrieved webhook with identifier %d for %s\n", webhookResponse.Webhook.ID, webhookResponse.Webhook.Event)
+	}
+
+	// Output: retrieved webhook with identifier 12345 for TASK.CREATED
+}
+
+func ExampleWebhookList() {
+	address, stop, err := startWebhookServer() // mock server for demonstration purposes
+	if err != nil {
+		fmt.Printf("failed to start server: %s", err)
+		return
+	}
+	defer stop()
+
+	ctx := context.Background()
+	engine := twapi.NewEngine(session.NewBearerToken("your_token", fmt.Sprintf("http://%s", address)))
+
+	webhooksRequest := projects.NewWebhookListRequest()
+	webhooksRequest.Path.ProjectID = 777
+
+	webhooksResponse, err := projects.WebhookList(ctx, engine, webhooksRequest)
+	if err != nil {
+		fmt.Printf("failed to list webhooks: %s", err)
+	} else {
+		for _, webhook := range webhooksResponse.Webhooks {
+			fmt.Printf("retrieved webhook with identifier %d\n", webhook.ID)
+		}
+	}
+
+	// Output: retrieved webhook with identifier 12345
+	// retrieved webhook with identifier 12346
+}
+
+func startWebhookServer() (string, func(), error) {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to start server: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /projects/{id}/webhooks", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "Unsupported Media Type", http.StatusUnsupportedMediaType)
+			return
+		}
+		if r.PathValue("id") != "777" {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintln(w, `{"STATUS":"OK","id":"12345"}`)
+	})
+	mux.HandleFunc("PUT /webhooks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "Unsupported Media Type", http.StatusUnsupportedMediaType)
+			return
+		}
+		if r.PathValue("id") != "12345" {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintln(w, `{"STATUS":"OK"}`)
+	})
+	mux.HandleFunc("DELETE /webhooks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "12345" {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintln(w, `{"STATUS":"OK"}`)
+	})
+	mux.HandleFunc("GET /webhooks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "12345" {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintln(w, `{"STATUS":"OK","webhook":{"id":"12345","event":"TASK.CREATED"}}`)
+	})
+	mux.HandleFunc("GET /projects/{id}/webhooks", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "777" {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintln(w, `{"STATUS":"OK","webhooks":[{"id":"12345"},{"id":"12346"}]}`)
+	})
+
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer your_token" {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			r.URL.Path = strings.TrimSuffix(r.URL.Path, ".json")
+			mux.ServeHTTP(w, r)
+		}),
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		_ = server.Serve(ln)
+	}()
+	go func() {
+		<-stop
+		_ = server.Shutdown(context.Background())
+	}()
+
+	return ln.Addr().String(), func() {
+		close(stop)
+	}, nil
+}
@@ -0,0 +1,182 @@
+package projects_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/teamwork/twapi-go-sdk/projects"
+)
+
+func TestWebhookCreate(t *testing.T) {
+	if engine == nil {
+		t.Skip("Skipping test because the engine is not initialized")
+	}
+
+	tests := []struct {
+		name  string
+		input projects.WebhookCreateRequest
+	}{{
+		name: "only required fields",
+		input: projects.NewWebhookCreateRequest(
+			projects.WebhookEventTaskCreated,
+			fmt.Sprintf("https://example.com/webhooks/test%d%d", time.Now().UnixNano(), rand.Intn(100)),
+		),
+	}, {
+		name: "all fields",
+		input: projects.WebhookCreateRequest{
+			Path: projects.WebhookCreateRequestPath{
+				ProjectID: testResources.ProjectID,
+			},
+			Event:       projects.WebhookEventCommentCreated,
+			URL:         fmt.Sprintf("https://example.com/webhooks/test%d%d", time.Now().UnixNano(), rand.Intn(100)),
+			Token:       new("secret"),
+			ContentType: new(projects.WebhookContentTypeJSON),
+			Version:     new("2"),
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := t.Context()
+			ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			t.Cleanup(cancel)
+
+			webhookResponse, err := projects.WebhookCreate(ctx, engine, tt.input)
+			t.Cleanup(func() {
+				if err != nil {
+					return
+				}
+				ctx = context.Background() // t.Context is always canceled in cleanup
+				_, err := projects.WebhookDelete(ctx, engine, projects.NewWebhookDeleteRequest(int64(webhookResponse.ID)))
+				if err != nil {
+					t.Errorf("failed to delete webhook after test: %s", err)
+				}
+			})
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+			} else if webhookResponse.ID == 0 {
+				t.Error("expected a valid webhook ID but got 0")
+			}
+		})
+	}
+}
+
+func TestWebhookUpdate(t *testing.T) {
+	if engine == nil {
+		t.Skip("Skipping test because the engine is not initialized")
+	}
+
+	webhookID, webhookCleanup, err := createWebhook(t, testResources.ProjectID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer webhookCleanup()
+
+	tests := []struct {
+		name  string
+		input projects.WebhookUpdateRequest
+	}{{
+		name: "all fields",
+		input: projects.WebhookUpdateRequest{
+			Path: projects.WebhookUpdateRequestPath{
+				ID: webhookID,
+			},
+			Event:       new(projects.WebhookEventTaskUpdated),
+			URL:         new(fmt.Sprintf("https://example.com/webhooks/test%d%d", time.Now().UnixNano(), rand.Intn(100))),
+			Token:       new("secret"),
+			ContentType: new(projects.WebhookContentTypeJSON),
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := t.Context()
+			ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			t.Cleanup(cancel)
+
+			if _, err := projects.WebhookUpdate(ctx, engine, tt.input); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func TestWebhookDelete(t *testing.T) {
+	if engine == nil {
+		t.Skip("Skipping test because the engine is not initialized")
+	}
+
+	webhookID, _, err := createWebhook(t, testResources.ProjectID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := t.Context()
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	t.Cleanup(cancel)
+
+	if _, err = projects.WebhookDelete(ctx, engine, projects.NewWebhookDeleteRequest(webhookID)); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestWebhookGet(t *testing.T) {
+	if engine == nil {
+		t.Skip("Skipping test because the engine is not initialized")
+	}
+
+	webhookID, webhookCleanup, err := createWebhook(t, testResources.ProjectID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer webhookCleanup()
+
+	ctx := t.Context()
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	t.Cleanup(cancel)
+
+	if _, err = projects.WebhookGet(ctx, engine, projects.NewWebhookGetRequest(webhookID)); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestWebhookList(t *testing.T) {
+	if engine == nil {
+		t.Skip("Skipping test because the engine is not initialized")
+	}
+
+	_, webhookCleanup, err := createWebhook(t, testResources.ProjectID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer webhookCleanup()
+
+	tests := []struct {
+		name  string
+		input projects.WebhookListRequest
+	}{{
+		name: "all webhooks",
+	}, {
+		name: "webhooks of a project",
+		input: projects.WebhookListRequest{
+			Path: projects.WebhookListRequestPath{
+				ProjectID: testResources.ProjectID,
+			},
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := t.Context()
+			ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			t.Cleanup(cancel)
+
+			if _, err := projects.WebhookList(ctx, engine, tt.input); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		})
+	}
+}
@@ -462,6 +462,20 @@ func (w *WorkflowListResponse) Iterate() *WorkflowListRequest {
 	return &req
 }
 
+// IterateRemaining returns the requests for every page after this one, so they
+// can be loaded concurrently. It returns nil when the number of pages is
+// unknown, such as when the request asked the API to skip the count query with
+// twapi.ListCountModeSkip, in which case Iterate must be used instead.
+func (w *WorkflowListResponse) IterateRemaining() []WorkflowListRequest {
+	var requests []WorkflowListRequest
+	for page := range w.Meta.RemainingPages(w.request.Filters.Page, w.request.Filters.PageSize) {
+		req := w.request
+		req.Filters.Page = page
+		requests = append(requests, req)
+	}
+	return requests
+}
+
 // WorkflowList retrieves multiple workflows using the provided request and
 // returns the response.
 func WorkflowList(
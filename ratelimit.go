@@ -0,0 +1,141 @@
+package twapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// WithRateLimit limits the requests sent by the Engine to rps per second, with
+// bursts of up to burst requests. The limiter is a token bucket shared by every
+// goroutine using the Engine, so it caps the rate of the whole process against
+// the installation.
+//
+// The limiter adapts itself to the rate limit reported by the API. When the
+// X-RateLimit-Remaining header shows fewer requests left than tokens in the
+// bucket, the bucket is drained to match, and once the limit is exhausted no
+// request is sent until the X-RateLimit-Reset window ends. The time spent
+// waiting is logged at debug level through the Engine logger.
+func WithRateLimit(rps float64, burst int) EngineOption {
+	return func(e *Engine) {
+		if rps <= 0 {
+			e.limiter = nil
+			return
+		}
+		burst = max(burst, 1)
+		e.limiter = &rateLimiter{
+			rate:   rps,
+			burst:  float64(burst),
+			tokens: float64(burst),
+			last:   time.Now(),
+		}
+	}
+}
+
+// rateLimiter is a token bucket. Tokens are reserved ahead of time, so the
+// bucket may go negative and each caller waits for the token it reserved to
+// refill.
+type rateLimiter struct {
+	mu           sync.Mutex
+	rate         float64
+	burst        float64
+	tokens       float64
+	last         time.Time
+	blockedUntil time.Time
+}
+
+// wait blocks until the caller is allowed to send a request, returning the time
+// spent waiting. It fails straight away when the wait would not complete before
+// the context deadline.
+func (l *rateLimiter) wait(ctx context.Context) (time.Duration, error) {
+	if l == nil {
+		return 0, nil
+	}
+
+	delay := l.reserve(time.Now())
+	if delay <= 0 {
+		return 0, nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+		l.cancel()
+		return 0, fmt.Errorf("rate limit wait of %s would exceed context deadline: %w", delay, context.DeadlineExceeded)
+	}
+	if err := sleep(ctx, delay); err != nil {
+		l.cancel()
+		return 0, err
+	}
+	return delay, nil
+}
+
+// reserve takes a token from the bucket and returns how long the caller must
+// wait before using it.
+func (l *rateLimiter) reserve(now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(now)
+	l.tokens--
+
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	return max(delay, l.blockedUntil.Sub(now))
+}
+
+// cancel gives back a token reserved by a caller that stopped waiting.
+func (l *rateLimiter) cancel() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens = min(l.tokens+1, l.burst)
+}
+
+// observe adapts the bucket to the rate limit headers of a response.
+func (l *rateLimiter) observe(resp *http.Response) {
+	if l == nil || resp == nil {
+		return
+	}
+
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(now)
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if delay, ok := serverDelay(resp.Header, now); ok {
+			l.block(now.Add(delay))
+		}
+	}
+
+	remaining, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Remaining"), 10, 64)
+	if err != nil || remaining < 0 {
+		return
+	}
+	if remaining == 0 {
+		if reset, ok := rateLimitReset(resp.Header, now); ok {
+			l.block(now.Add(reset))
+		}
+	}
+	l.tokens = min(l.tokens, float64(remaining))
+}
+
+// block holds every request until the given time.
+func (l *rateLimiter) block(until time.Time) {
+	if until.After(l.blockedUntil) {
+		l.blockedUntil = until
+	}
+}
+
+// refill adds the tokens accumulated since the last update.
+func (l *rateLimiter) refill(now time.Time) {
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens = min(l.tokens+elapsed.Seconds()*l.rate, l.burst)
+		l.last = now
+	}
+}
@@ -0,0 +1,93 @@
+package twapi_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+)
+
+func TestRateLimit(t *testing.T) {
+	engine := newTestEngine(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}), twapi.WithRateLimit(20, 2))
+
+	const requests = 6
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range requests {
+		wg.Go(func() {
+			resp, err := twapi.ExecuteRaw(t.Context(), engine, testRequest{path: "/limited"})
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				return
+			}
+			_ = resp.Body.Close()
+		})
+	}
+	wg.Wait()
+
+	// the burst is served straight away, and the remaining requests are spread
+	// at 20 per second
+	if elapsed, want := time.Since(start), 200*time.Millisecond; elapsed < want {
+		t.Errorf("expected requests to take at least %s but took %s", want, elapsed)
+	}
+}
+
+func TestRateLimitAdaptsToHeaders(t *testing.T) {
+	var calls atomic.Int32
+	engine := newTestEngine(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", "1")
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}), twapi.WithRateLimit(100, 10))
+
+	for i := range 2 {
+		start := time.Now()
+		resp, err := twapi.ExecuteRaw(t.Context(), engine, testRequest{path: "/limited"})
+		if err != nil {
+			t.Fatalf("request %d: unexpected error: %s", i, err)
+		}
+		_ = resp.Body.Close()
+
+		if elapsed := time.Since(start); i == 1 && elapsed < 900*time.Millisecond {
+			t.Errorf("expected the second request to wait for the reset but took %s", elapsed)
+		}
+	}
+}
+
+func TestRateLimitContextDeadline(t *testing.T) {
+	var calls atomic.Int32
+	engine := newTestEngine(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}), twapi.WithRateLimit(0.1, 1))
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+	t.Cleanup(cancel)
+
+	for i := range 2 {
+		resp, err := twapi.ExecuteRaw(ctx, engine, testRequest{path: "/limited"})
+		if i == 0 {
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			_ = resp.Body.Close()
+			continue
+		}
+		if err == nil {
+			_ = resp.Body.Close()
+			t.Fatal("expected an error when the wait exceeds the deadline")
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected 1 request to reach the server but got %d", got)
+	}
+}
@@ -0,0 +1,177 @@
+package twapi
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryMaxAttempts = 3
+	defaultRetryMinBackoff  = 500 * time.Millisecond
+	defaultRetryMaxBackoff  = 30 * time.Second
+)
+
+// RetryPolicy configures how the Engine retries requests that failed with a
+// transient error. Zero values are replaced by the defaults documented on each
+// field.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times a request is sent, including
+	// the first attempt. Defaults to 3.
+	MaxAttempts int
+
+	// MinBackoff is the base delay between attempts. It doubles on every retry
+	// and is jittered to spread the load of concurrent callers. Defaults to
+	// 500ms.
+	MinBackoff time.Duration
+
+	// MaxBackoff caps the delay computed from MinBackoff. It does not cap the
+	// delay requested by the API through the Retry-After or X-RateLimit-Reset
+	// headers. Defaults to 30s.
+	MaxBackoff time.Duration
+
+	// Methods lists the HTTP methods that are safe to retry. Defaults to GET and
+	// HEAD. PUT and DELETE are idempotent in the Teamwork API and can be added
+	// when the caller is happy to send them more than once.
+	Methods []string
+
+	// StatusCodes lists the HTTP status codes considered transient. Defaults to
+	// 429, 502, 503 and 504.
+	StatusCodes []int
+}
+
+// WithRetryPolicy enables retries for transient failures. Each attempt rebuilds
+// the request from the HTTPRequester, so request bodies are never reused after
+// being consumed. The delay between attempts honours the Retry-After and
+// X-RateLimit-Reset headers returned by the API, and no retry is scheduled when
+// it would not complete before the request context deadline.
+func WithRetryPolicy(policy RetryPolicy) EngineOption {
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = defaultRetryMaxAttempts
+	}
+	if policy.MinBackoff <= 0 {
+		policy.MinBackoff = defaultRetryMinBackoff
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = defaultRetryMaxBackoff
+	}
+	if policy.MaxBackoff < policy.MinBackoff {
+		policy.MaxBackoff = policy.MinBackoff
+	}
+	if len(policy.Methods) == 0 {
+		policy.Methods = []string{http.MethodGet, http.MethodHead}
+	}
+	if len(policy.StatusCodes) == 0 {
+		policy.StatusCodes = []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		}
+	}
+	return func(e *Engine) {
+		e.retry = &policy
+	}
+}
+
+// next decides whether the attempt that produced resp or err should be
+// retried, and how long to wait before doing so. A nil policy never retries.
+func (p *RetryPolicy) next(
+	ctx context.Context,
+	req *http.Request,
+	resp *http.Response,
+	err error,
+	attempt int,
+) (time.Duration, bool) {
+	if p == nil || attempt >= p.MaxAttempts || !slices.Contains(p.Methods, req.Method) {
+		return 0, false
+	}
+
+	var delay time.Duration
+	switch {
+	case err != nil:
+		if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return 0, false
+		}
+		delay = p.backoff(attempt)
+	case slices.Contains(p.StatusCodes, resp.StatusCode):
+		var ok bool
+		if delay, ok = serverDelay(resp.Header, time.Now()); !ok {
+			delay = p.backoff(attempt)
+		}
+	default:
+		return 0, false
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Now().Add(delay).After(deadline) {
+		return 0, false
+	}
+	return delay, true
+}
+
+// backoff returns the jittered exponential delay for the given attempt,
+// starting at 1. Half of the delay is fixed and the other half random, so
+// concurrent callers spread out without retrying immediately.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.MaxBackoff
+	if shift := attempt - 1; shift < 32 {
+		if d := p.MinBackoff << shift; d > 0 && d < p.MaxBackoff {
+			delay = d
+		}
+	}
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// serverDelay extracts the delay requested by the API. Retry-After takes
+// precedence and may be either a number of seconds or an HTTP date. Otherwise,
+// when the rate limit is exhausted, X-RateLimit-Reset tells when it refills.
+func serverDelay(header http.Header, now time.Time) (time.Duration, bool) {
+	if retryAfter := header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.ParseInt(retryAfter, 10, 64); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if at, err := http.ParseTime(retryAfter); err == nil {
+			return max(at.Sub(now), 0), true
+		}
+	}
+	if header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, ok := rateLimitReset(header, now); ok {
+			return reset, true
+		}
+	}
+	return 0, false
+}
+
+// rateLimitReset parses the X-RateLimit-Reset header, returning how long until
+// the rate limit window refills. The header holds a number of seconds, although
+// values large enough to be a Unix timestamp are accepted as such.
+func rateLimitReset(header http.Header, now time.Time) (time.Duration, bool) {
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil || reset < 0 {
+		return 0, false
+	}
+	const unixThreshold = 1_000_000_000
+	if reset >= unixThreshold {
+		return max(time.Unix(reset, 0).Sub(now), 0), true
+	}
+	return time.Duration(reset) * time.Second, true
+}
+
+// sleep waits for the given delay, returning early with the context error if
+// the context is done first.
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
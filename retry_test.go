@@ -0,0 +1,152 @@
+package twapi_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	policy := twapi.RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  time.Millisecond,
+		MaxBackoff:  5 * time.Millisecond,
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		policy       twapi.RetryPolicy
+		statuses     []int
+		wantAttempts int32
+		wantStatus   int
+	}{{
+		name:         "recovers from a transient error",
+		statuses:     []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+		wantAttempts: 3,
+		wantStatus:   http.StatusOK,
+	}, {
+		name:         "returns the last response once attempts are exhausted",
+		statuses:     []int{http.StatusGatewayTimeout, http.StatusGatewayTimeout, http.StatusGatewayTimeout},
+		wantAttempts: 3,
+		wantStatus:   http.StatusGatewayTimeout,
+	}, {
+		name:         "does not retry permanent errors",
+		statuses:     []int{http.StatusNotFound},
+		wantAttempts: 1,
+		wantStatus:   http.StatusNotFound,
+	}, {
+		name:         "does not retry non-idempotent methods",
+		method:       http.MethodPost,
+		statuses:     []int{http.StatusServiceUnavailable},
+		wantAttempts: 1,
+		wantStatus:   http.StatusServiceUnavailable,
+	}, {
+		name:   "retries methods the caller opted in to",
+		method: http.MethodPut,
+		policy: twapi.RetryPolicy{
+			Methods: []string{http.MethodGet, http.MethodPut},
+		},
+		statuses:     []int{http.StatusServiceUnavailable, http.StatusOK},
+		wantAttempts: 2,
+		wantStatus:   http.StatusOK,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			engine := newTestEngine(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempt := attempts.Add(1)
+				if body, _ := io.ReadAll(r.Body); r.Method != http.MethodGet && string(body) != "payload" {
+					t.Errorf("attempt %d: expected the request body to be replayed but got %q", attempt, body)
+				}
+				w.WriteHeader(tt.statuses[min(int(attempt), len(tt.statuses))-1])
+			}), twapi.WithRetryPolicy(mergeRetryPolicy(policy, tt.policy)))
+
+			resp, err := twapi.ExecuteRaw(t.Context(), engine, testRequest{
+				method: tt.method,
+				path:   "/retry",
+				body:   "payload",
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			defer func() { _ = resp.Body.Close() }()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("expected status %d but got %d", tt.wantStatus, resp.StatusCode)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("expected %d attempts but got %d", tt.wantAttempts, got)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyRetryAfter(t *testing.T) {
+	var attempts atomic.Int32
+	engine := newTestEngine(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}), twapi.WithRetryPolicy(twapi.RetryPolicy{MinBackoff: time.Millisecond}))
+
+	start := time.Now()
+	resp, err := twapi.ExecuteRaw(t.Context(), engine, testRequest{path: "/retry-after"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status %d but got %d", http.StatusOK, resp.StatusCode)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("expected to wait for Retry-After but only waited %s", elapsed)
+	}
+}
+
+func TestRetryPolicyContextDeadline(t *testing.T) {
+	var attempts atomic.Int32
+	engine := newTestEngine(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}), twapi.WithRetryPolicy(twapi.RetryPolicy{}))
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	resp, err := twapi.ExecuteRaw(ctx, engine, testRequest{path: "/rate-limited"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("expected status %d but got %d", http.StatusTooManyRequests, resp.StatusCode)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("expected a single attempt when the reset is past the deadline but got %d", got)
+	}
+}
+
+// mergeRetryPolicy overrides the fields of base set in override.
+func mergeRetryPolicy(base, override twapi.RetryPolicy) twapi.RetryPolicy {
+	if override.Methods != nil {
+		base.Methods = override.Methods
+	}
+	if override.StatusCodes != nil {
+		base.StatusCodes = override.StatusCodes
+	}
+	return base
+}
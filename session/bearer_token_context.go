@@ -10,7 +10,7 @@ import (
 	twapi "github.com/teamwork/twapi-go-sdk"
 )
 
-var _ twapi.Session = (*BearerTokenContext)(nil)
+var _ twapi.ContextSession = (*BearerTokenContext)(nil)
 
 type bearerTokenContextKey struct{}
 
@@ -45,11 +45,22 @@ func (b *BearerTokenContext) Authenticate(ctx context.Context, req *http.Request
 }
 
 // Server returns the server URL for the BearerTokenContext session. This is a
-// dummy implementation since it's not accessible at this point.
+// dummy implementation since it's not accessible at this point. The Engine uses
+// ServerContext instead.
 func (b *BearerTokenContext) Server() string {
 	return ""
 }
 
+// ServerContext implements the ContextSession interface for BearerTokenContext,
+// returning the server of the bearer token stored in the context.
+func (b *BearerTokenContext) ServerContext(ctx context.Context) (string, error) {
+	bearerToken, ok := fromBearerTokenContext(ctx)
+	if !ok || bearerToken == nil {
+		return "", errors.New("missing bearer token")
+	}
+	return bearerToken.server, nil
+}
+
 // WithBearerTokenContext returns a new context with the provided BearerToken.
 func WithBearerTokenContext(ctx context.Context, bearerToken *BearerToken) context.Context {
 	return context.WithValue(ctx, bearerTokenContextKey{}, bearerToken)
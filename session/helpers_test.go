@@ -0,0 +1,22 @@
+package session_test
+
+import (
+	"context"
+	"net/http"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+)
+
+// requester returns a twapi.HTTPRequester that sends a request with the given
+// method to path on the session server.
+func requester(method, path string) twapi.HTTPRequester {
+	return requesterFunc(func(ctx context.Context, server string) (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, method, server+path, nil)
+	})
+}
+
+type requesterFunc func(ctx context.Context, server string) (*http.Request, error)
+
+func (f requesterFunc) HTTPRequest(ctx context.Context, server string) (*http.Request, error) {
+	return f(ctx, server)
+}
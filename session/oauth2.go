@@ -1,11 +1,9 @@
 package session
 
 import (
+	"bufio"
 	"bytes"
 	"context"
-	"crypto/rand"
-	"crypto/sha256"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -16,6 +14,7 @@ import (
 	"slices"
 	"strings"
 	"sync"
+	"time"
 
 	twapi "github.com/teamwork/twapi-go-sdk"
 	"github.com/teamwork/twapi-go-sdk/internal/browser"
@@ -26,7 +25,7 @@ const (
 	defaultCallbackHost = "localhost:0"
 )
 
-var _ twapi.Session = (*OAuth2)(nil)
+var _ twapi.RefreshableSession = (*OAuth2)(nil)
 
 // OAuth2 represents a session for the Teamwork API using OAuth2 authentication.
 // It implements the Session interface, allowing it to be used with the Teamwork
@@ -38,10 +37,14 @@ type OAuth2 struct {
 	oauthServer        string
 	callbackServerAddr string
 	logger             *slog.Logger
+	store              TokenStore
+	redirectURL        string
+	authorizationMode  oauth2AuthorizationMode
 
 	oauthMutex  sync.Mutex
-	server      string
-	bearerToken string
+	tokenLoaded bool
+	token       *OAuth2Token
+	info        *oauth2ServerInfo
 }
 
 // OAuth2Option defines a function type that can modify the OAuth2 initial
@@ -93,6 +96,76 @@ func WithOAuth2Logger(logger *slog.Logger) OAuth2Option {
 	}
 }
 
+// WithOAuth2TokenStore sets where the OAuth2 session persists its token. A
+// token found in the store is used instead of asking the user to authorise the
+// application again, and every new or refreshed token is saved back. By
+// default, the token is only kept in memory for the lifetime of the session.
+func WithOAuth2TokenStore(store TokenStore) OAuth2Option {
+	return func(o *OAuth2) {
+		o.store = store
+	}
+}
+
+// OAuth2AuthorizationHandler drives the user through the authorization page
+// at authURL and returns the authorization code the authorization server
+// redirected back with. See WithOAuth2AuthorizationHandler.
+type OAuth2AuthorizationHandler func(ctx context.Context, authURL string) (code string, err error)
+
+// oauth2AuthorizationMode retrieves an authorization code for the given
+// authorization URL.
+type oauth2AuthorizationMode func(ctx context.Context, serverInfo *oauth2ServerInfo, authURL string) (string, error)
+
+// WithOAuth2AuthorizationHandler replaces the default authorization flow, which
+// opens a browser and waits for the redirect on a local callback server, with
+// the provided handler. It allows applications to present the authorization
+// page and receive the redirect themselves, such as web applications and
+// servers without a browser. The redirect URI sent to the authorization server
+// is set with WithOAuth2RedirectURL.
+func WithOAuth2AuthorizationHandler(handler OAuth2AuthorizationHandler) OAuth2Option {
+	return func(o *OAuth2) {
+		if handler == nil {
+			o.authorizationMode = nil
+			return
+		}
+		o.authorizationMode = func(ctx context.Context, _ *oauth2ServerInfo, authURL string) (string, error) {
+			return handler(ctx, authURL)
+		}
+	}
+}
+
+// WithOAuth2ManualAuthorization replaces the default authorization flow, which
+// opens a browser and waits for the redirect on a local callback server, with a
+// flow suited to headless machines and SSH sessions. The authorization URL is
+// written to out, for the user to open in a browser on any machine, and the
+// user then pastes either the authorization code or the whole URL they were
+// redirected to into in. The redirect URI sent to the authorization server is
+// set with WithOAuth2RedirectURL.
+//
+// The line is read in the background, so the flow stops as soon as the context
+// is cancelled. When in has a SetReadDeadline method, such as a net.Conn or a
+// pipe opened with os.Pipe, the read is interrupted. Otherwise, as with a
+// terminal on os.Stdin, the read stays blocked after the cancellation, until
+// the next line is typed; that line is consumed and discarded.
+func WithOAuth2ManualAuthorization(in io.Reader, out io.Writer) OAuth2Option {
+	return func(o *OAuth2) {
+		o.authorizationMode = func(ctx context.Context, serverInfo *oauth2ServerInfo, authURL string) (string, error) {
+			return o.retrieveCodeManually(ctx, serverInfo, authURL, in, out)
+		}
+	}
+}
+
+// WithOAuth2RedirectURL sets the redirect URI sent to the authorization server
+// when the session does not run the local callback server, which is the case
+// with WithOAuth2AuthorizationHandler and WithOAuth2ManualAuthorization. It
+// must match one of the redirect URIs registered for the application. By
+// default, it points to the callback server address set with
+// WithOAuth2CallbackServerAddr.
+func WithOAuth2RedirectURL(redirectURL string) OAuth2Option {
+	return func(o *OAuth2) {
+		o.redirectURL = redirectURL
+	}
+}
+
 // NewOAuth2 creates a new OAuth2 session with the provided client ID and
 // client secret.
 func NewOAuth2(clientID, clientSecret string, opts ...OAuth2Option) *OAuth2 {
@@ -110,79 +183,207 @@ func NewOAuth2(clientID, clientSecret string, opts ...OAuth2Option) *OAuth2 {
 	return oauth
 }
 
-// Authenticate implements the Session interface for OAuth2.
+// Authenticate implements the Session interface for OAuth2. The first call
+// loads the token from the token store, or asks the user to authorise the
+// application when there is none. An expired token is renewed with its refresh
+// token before being used.
 func (o *OAuth2) Authenticate(ctx context.Context, req *http.Request) error {
-	if o.bearerToken == "" {
-		if err := o.handshake(ctx); err != nil {
-			return fmt.Errorf("failed to authenticate with oauth2: %w", err)
-		}
+	token, err := o.validToken(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to authenticate with oauth2: %w", err)
 	}
 
 	if req.URL.Host == "" {
-		serverURL, err := url.Parse(o.server)
+		serverURL, err := url.Parse(token.Server)
 		if err != nil {
-			return fmt.Errorf("failed to parse server URL %q: %w", o.server, err)
+			return fmt.Errorf("failed to parse server URL %q: %w", token.Server, err)
 		}
 		req.URL.Scheme = serverURL.Scheme
 		req.URL.Host = serverURL.Host
 	}
 
-	req.Header.Set("Authorization", "Bearer "+o.bearerToken)
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
 	return nil
 }
 
+// Refresh implements the twapi.RefreshableSession interface for OAuth2. It
+// renews the access token with the refresh token after the API rejected the
+// request. When the token was already renewed since the request was
+// authenticated, such as by a concurrent request, it returns straight away.
+func (o *OAuth2) Refresh(ctx context.Context, rejected *http.Request) error {
+	o.oauthMutex.Lock()
+	defer o.oauthMutex.Unlock()
+
+	if o.token == nil {
+		return fmt.Errorf("missing oauth2 token")
+	}
+	if rejected != nil && rejected.Header.Get("Authorization") != "Bearer "+o.token.AccessToken {
+		return nil
+	}
+	return o.refresh(ctx)
+}
+
 // Server returns the server URL for the OAuth2 session. If the authentication
 // did not happen yet this may be empty.
 func (o *OAuth2) Server() string {
-	return o.server
+	o.oauthMutex.Lock()
+	defer o.oauthMutex.Unlock()
+
+	if o.token == nil {
+		return ""
+	}
+	return o.token.Server
 }
 
 // BearerToken returns the bearer token for the OAuth2 session. If the
 // authentication did not happen yet this may be empty.
 func (o *OAuth2) BearerToken() string {
-	return o.bearerToken
+	o.oauthMutex.Lock()
+	defer o.oauthMutex.Unlock()
+
+	if o.token == nil {
+		return ""
+	}
+	return o.token.AccessToken
 }
 
-func (o *OAuth2) handshake(ctx context.Context) error {
+// Token returns a copy of the current OAuth2 token, or nil if the
+// authentication did not happen yet.
+func (o *OAuth2) Token() *OAuth2Token {
 	o.oauthMutex.Lock()
 	defer o.oauthMutex.Unlock()
 
-	if o.bearerToken != "" && o.server != "" {
+	if o.token == nil {
 		return nil
 	}
+	token := *o.token
+	return &token
+}
+
+// validToken returns a token that can be used to authenticate requests,
+// loading, refreshing or requesting it as needed.
+func (o *OAuth2) validToken(ctx context.Context) (*OAuth2Token, error) {
+	o.oauthMutex.Lock()
+	defer o.oauthMutex.Unlock()
+
+	if !o.tokenLoaded && o.store != nil {
+		token, err := o.store.Load(ctx)
+		if err != nil {
+			o.logger.Warn("failed to load oauth2 token from store",
+				slog.String("error", err.Error()),
+			)
+		} else if token != nil && token.AccessToken != "" {
+			o.token = token
+		}
+	}
+	o.tokenLoaded = true
+
+	if o.token != nil && !o.token.Expired() {
+		return o.token, nil
+	}
+
+	if o.token != nil && o.token.RefreshToken != "" {
+		err := o.refresh(ctx)
+		if err == nil {
+			return o.token, nil
+		}
+		o.logger.Warn("failed to refresh oauth2 token, authorising again",
+			slog.String("error", err.Error()),
+		)
+	}
+
+	if err := o.handshake(ctx); err != nil {
+		return nil, err
+	}
+	return o.token, nil
+}
 
+// handshake runs the authorization code flow. The caller must hold the oauth
+// mutex.
+func (o *OAuth2) handshake(ctx context.Context) error {
 	serverInfo, err := o.serverInfo(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get oauth2 server info: %w", err)
 	}
 
-	codeChallenge, err := o.generateCodeChallenge()
+	codeVerifier, err := NewOAuth2Verifier()
 	if err != nil {
-		return fmt.Errorf("failed to generate code challenge: %w", err)
+		return fmt.Errorf("failed to generate code verifier: %w", err)
 	}
 
-	code, redirectURL, err := o.retrieveCode(ctx, serverInfo, codeChallenge)
+	code, redirectURL, err := o.retrieveCode(ctx, serverInfo, oauth2CodeChallenge(codeVerifier))
 	if err != nil {
 		return fmt.Errorf("failed to retrieve authorization code: %w", err)
 	}
 
-	if err := o.retrieveToken(ctx, serverInfo, redirectURL, codeChallenge, code); err != nil {
+	token, err := o.retrieveToken(ctx, serverInfo, redirectURL, codeVerifier, code)
+	if err != nil {
 		return fmt.Errorf("failed to retrieve access token: %w", err)
 	}
 
+	o.setToken(ctx, token)
+	return nil
+}
+
+// refresh renews the access token with the refresh token. The caller must hold
+// the oauth mutex.
+func (o *OAuth2) refresh(ctx context.Context) error {
+	if o.token == nil || o.token.RefreshToken == "" {
+		return fmt.Errorf("missing oauth2 refresh token")
+	}
+
+	serverInfo, err := o.serverInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get oauth2 server info: %w", err)
+	}
+
+	token, err := o.refreshToken(ctx, serverInfo, o.token)
+	if err != nil {
+		return fmt.Errorf("failed to refresh access token: %w", err)
+	}
+
+	o.setToken(ctx, token)
 	return nil
 }
 
+// retrieveCode asks the user to authorise the application, returning the
+// authorization code and the redirect URI it was issued for.
 func (o *OAuth2) retrieveCode(
 	ctx context.Context,
 	serverInfo *oauth2ServerInfo,
 	codeChallenge string,
 ) (string, string, error) {
-	authURL, err := url.Parse(serverInfo.AuthorizationEndpoint)
+	if o.authorizationMode == nil {
+		return o.retrieveCodeFromCallback(ctx, serverInfo, codeChallenge)
+	}
+
+	redirectURL := o.redirectURL
+	if redirectURL == "" {
+		redirectURL = defaultRedirectURL(o.callbackServerAddr)
+	}
+	authURL, err := o.authorizationURL(serverInfo, redirectURL, codeChallenge)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to parse authorization endpoint %q: %w", serverInfo.AuthorizationEndpoint, err)
+		return "", "", err
 	}
 
+	code, err := o.authorizationMode(ctx, serverInfo, authURL)
+	if err != nil {
+		return "", "", err
+	}
+	if code == "" {
+		return "", "", fmt.Errorf("empty authorization code")
+	}
+	return code, redirectURL, nil
+}
+
+// retrieveCodeFromCallback opens the authorization page in the browser and
+// waits for the authorization server to redirect back to a local callback
+// server.
+func (o *OAuth2) retrieveCodeFromCallback(
+	ctx context.Context,
+	serverInfo *oauth2ServerInfo,
+	codeChallenge string,
+) (string, string, error) {
 	listener, err := net.Listen("tcp", o.callbackServerAddr)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to start listener on %q: %w", o.callbackServerAddr, err)
@@ -202,13 +403,7 @@ func (o *OAuth2) retrieveCode(
 			return
 		}
 
-		var code string
-		for _, supported := range serverInfo.ResponseTypesSupported {
-			if code = r.URL.Query().Get(supported); code != "" {
-				break
-			}
-		}
-
+		code := codeFromQuery(serverInfo, r.URL.Query())
 		if code == "" {
 			http.Error(w, "Missing code parameter", http.StatusBadRequest)
 			serverResultChannel <- serverResult{err: fmt.Errorf("missing code parameter")}
@@ -237,15 +432,18 @@ func (o *OAuth2) retrieveCode(
 
 	redirectURL := fmt.Sprintf("http://%s/oauth2/callback", listener.Addr().String())
 
-	query := authURL.Query()
-	query.Set("client_id", o.clientID)
-	query.Set("response_type", "code")
-	query.Set("redirect_uri", redirectURL)
-	query.Set("code_challenge", codeChallenge)
-	query.Set("code_challenge_method", "S256")
-	authURL.RawQuery = query.Encode()
+	authURL, err := o.authorizationURL(serverInfo, redirectURL, codeChallenge)
+	if err != nil {
+		if err := server.Close(); err != nil {
+			o.logger.Error("failed to close oauth2 callback server",
+				slog.String("address", listener.Addr().String()),
+				slog.String("error", err.Error()),
+			)
+		}
+		return "", "", err
+	}
 
-	if err := browser.OpenURL(authURL.String()); err != nil {
+	if err := browser.OpenURL(authURL); err != nil {
 		return "", "", fmt.Errorf("failed to open browser for oauth2 authentication: %w", err)
 	}
 
@@ -278,35 +476,170 @@ func (o *OAuth2) retrieveCode(
 	return code, redirectURL, nil
 }
 
-func (o *OAuth2) retrieveToken(
+// retrieveCodeManually writes the authorization URL to out and reads the
+// authorization code, or the URL the user was redirected to, from in.
+func (o *OAuth2) retrieveCodeManually(
 	ctx context.Context,
 	serverInfo *oauth2ServerInfo,
-	redirectURL, codeChallenge, code string,
-) error {
-	if !slices.Contains(serverInfo.TokenEndpointAuthMethodsSupported, "client_secret_post") {
-		return fmt.Errorf("unsupported token endpoint authentication methods: %v",
-			serverInfo.TokenEndpointAuthMethodsSupported)
+	authURL string,
+	in io.Reader,
+	out io.Writer,
+) (string, error) {
+	_, err := fmt.Fprintf(out, "Open the following URL in a browser to authorise the application:\n\n%s\n\n"+
+		"Then paste the authorization code, or the URL you were redirected to: ", authURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to write authorization URL: %w", err)
+	}
+
+	type inputResult struct {
+		line string
+		err  error
+	}
+	inputResultChannel := make(chan inputResult, 1)
+	go func() {
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		inputResultChannel <- inputResult{line: line, err: err}
+	}()
+
+	var line string
+	select {
+	case result := <-inputResultChannel:
+		if result.err != nil {
+			return "", fmt.Errorf("failed to read authorization code: %w", result.err)
+		}
+		line = strings.TrimSpace(result.line)
+	case <-ctx.Done():
+		if deadliner, ok := in.(interface{ SetReadDeadline(time.Time) error }); ok {
+			_ = deadliner.SetReadDeadline(time.Now())
+		}
+		return "", ctx.Err()
 	}
 
+	if !strings.Contains(line, "?") {
+		return line, nil
+	}
+	redirect, err := url.Parse(line)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse redirect URL: %w", err)
+	}
+	query := redirect.Query()
+	if oauthErr := query.Get("error"); oauthErr != "" {
+		return "", fmt.Errorf("authorization denied: %s %s", oauthErr, query.Get("error_description"))
+	}
+	code := codeFromQuery(serverInfo, query)
+	if code == "" {
+		return "", fmt.Errorf("missing code parameter in redirect URL")
+	}
+	return code, nil
+}
+
+// authorizationURL builds the URL of the authorization page for the
+// application.
+func (o *OAuth2) authorizationURL(serverInfo *oauth2ServerInfo, redirectURL, codeChallenge string) (string, error) {
+	authURL, err := url.Parse(serverInfo.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse authorization endpoint %q: %w", serverInfo.AuthorizationEndpoint, err)
+	}
+	return buildAuthorizationURL(*authURL, o.clientID, redirectURL, codeChallenge, ""), nil
+}
+
+// buildAuthorizationURL adds the authorization request parameters to the
+// authorization endpoint. The state is omitted when empty.
+func buildAuthorizationURL(authURL url.URL, clientID, redirectURL, codeChallenge, state string) string {
+	query := authURL.Query()
+	query.Set("client_id", clientID)
+	query.Set("response_type", "code")
+	query.Set("redirect_uri", redirectURL)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	if state != "" {
+		query.Set("state", state)
+	}
+	authURL.RawQuery = query.Encode()
+
+	return authURL.String()
+}
+
+// codeFromQuery extracts the authorization code from the query of the redirect
+// URL.
+func codeFromQuery(serverInfo *oauth2ServerInfo, query url.Values) string {
+	for _, supported := range serverInfo.ResponseTypesSupported {
+		if code := query.Get(supported); code != "" {
+			return code
+		}
+	}
+	return ""
+}
+
+// defaultRedirectURL returns the redirect URI pointing to the callback server
+// address, dropping the port when it is chosen at random.
+func defaultRedirectURL(callbackServerAddr string) string {
+	host, port, err := net.SplitHostPort(callbackServerAddr)
+	if err != nil || port == "0" {
+		return fmt.Sprintf("http://%s/oauth2/callback", host)
+	}
+	return fmt.Sprintf("http://%s/oauth2/callback", callbackServerAddr)
+}
+
+// retrieveToken exchanges the authorization code for a token.
+func (o *OAuth2) retrieveToken(
+	ctx context.Context,
+	serverInfo *oauth2ServerInfo,
+	redirectURL, codeVerifier, code string,
+) (*OAuth2Token, error) {
 	form := url.Values{}
 	form.Add("grant_type", "authorization_code")
 	form.Add("code", code)
-	form.Add("client_id", o.clientID)
-	form.Add("client_secret", o.clientSecret)
 	form.Add("redirect_uri", redirectURL)
-	form.Add("code_verifier", codeChallenge)
+	form.Add("code_verifier", codeVerifier)
+
+	return o.requestToken(ctx, serverInfo, form, nil)
+}
+
+// refreshToken obtains a new token with the refresh token of previous.
+func (o *OAuth2) refreshToken(
+	ctx context.Context,
+	serverInfo *oauth2ServerInfo,
+	previous *OAuth2Token,
+) (*OAuth2Token, error) {
+	form := url.Values{}
+	form.Add("grant_type", "refresh_token")
+	form.Add("refresh_token", previous.RefreshToken)
+
+	return o.requestToken(ctx, serverInfo, form, previous)
+}
+
+// requestToken sends the form to the token endpoint, authenticating the
+// client, and returns the resulting token. When refreshing, previous is the
+// token being replaced. It does not modify the session.
+func (o *OAuth2) requestToken(
+	ctx context.Context,
+	serverInfo *oauth2ServerInfo,
+	form url.Values,
+	previous *OAuth2Token,
+) (*OAuth2Token, error) {
+	if !slices.Contains(serverInfo.TokenEndpointAuthMethodsSupported, "client_secret_post") {
+		return nil, fmt.Errorf("unsupported token endpoint authentication methods: %v",
+			serverInfo.TokenEndpointAuthMethodsSupported)
+	}
+
+	form.Set("client_id", o.clientID)
+	form.Set("client_secret", o.clientSecret)
 
 	body := bytes.NewBufferString(form.Encode())
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, serverInfo.TokenEndpoint, body)
 	if err != nil {
-		return fmt.Errorf("failed to build request to %q: %w", serverInfo.TokenEndpoint, err)
+		return nil, fmt.Errorf("failed to build request to %q: %w", serverInfo.TokenEndpoint, err)
 	}
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	resp, err := o.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send request to %q: %w", serverInfo.TokenEndpoint, err)
+		return nil, fmt.Errorf("failed to send request to %q: %w", serverInfo.TokenEndpoint, err)
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -322,28 +655,48 @@ func (o *OAuth2) retrieveToken(
 		if len(body) == 0 {
 			body = []byte("no response body")
 		}
-		return fmt.Errorf("unexpected status code %d from %q: %s", resp.StatusCode, serverInfo.TokenEndpoint, string(body))
+		return nil, fmt.Errorf("unexpected status code %d from %q: %s",
+			resp.StatusCode, serverInfo.TokenEndpoint, string(body))
 	}
 
 	var tokenResponse oauth2TokenResponse
 	decoder := json.NewDecoder(resp.Body)
 	if err := decoder.Decode(&tokenResponse); err != nil {
-		return fmt.Errorf("failed to decode response from %q: %w", serverInfo.TokenEndpoint, err)
+		return nil, fmt.Errorf("failed to decode response from %q: %w", serverInfo.TokenEndpoint, err)
 	}
 
 	if tokenResponse.AccessToken == "" {
-		return fmt.Errorf("missing access token in response from %q", serverInfo.TokenEndpoint)
+		return nil, fmt.Errorf("missing access token in response from %q", serverInfo.TokenEndpoint)
 	}
 	if tokenResponse.TokenType != "Bearer" {
-		return fmt.Errorf("unexpected token type %q from %q", tokenResponse.TokenType, serverInfo.TokenEndpoint)
+		return nil, fmt.Errorf("unexpected token type %q from %q", tokenResponse.TokenType, serverInfo.TokenEndpoint)
 	}
 
-	o.bearerToken = tokenResponse.AccessToken
-	o.server = tokenResponse.Installation.APIEndpoint
-	return nil
+	return tokenResponse.token(previous), nil
+}
+
+// setToken replaces the session token and persists it. Failing to persist the
+// token does not prevent the session from using it. The caller must hold the
+// oauth mutex.
+func (o *OAuth2) setToken(ctx context.Context, token *OAuth2Token) {
+	o.token = token
+	if o.store == nil {
+		return
+	}
+	if err := o.store.Save(ctx, token); err != nil {
+		o.logger.Error("failed to save oauth2 token to store",
+			slog.String("error", err.Error()),
+		)
+	}
 }
 
+// serverInfo discovers the authorization server metadata. The result is cached
+// for the lifetime of the session. The caller must hold the oauth mutex.
 func (o *OAuth2) serverInfo(ctx context.Context) (*oauth2ServerInfo, error) {
+	if o.info != nil {
+		return o.info, nil
+	}
+
 	url := o.oauthServer + "/.well-known/oauth-authorization-server"
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
@@ -379,16 +732,8 @@ func (o *OAuth2) serverInfo(ctx context.Context) (*oauth2ServerInfo, error) {
 	if info.AuthorizationEndpoint == "" || info.TokenEndpoint == "" {
 		return nil, fmt.Errorf("incomplete server info from %q: %+v", url, info)
 	}
-	return &info, nil
-}
-
-func (o *OAuth2) generateCodeChallenge() (string, error) {
-	verifier := make([]byte, 32)
-	if _, err := rand.Read(verifier); err != nil {
-		return "", fmt.Errorf("failed to generate random bytes: %w", err)
-	}
-	challenge := sha256.Sum256(verifier)
-	return base64.RawURLEncoding.EncodeToString(challenge[:]), nil
+	o.info = &info
+	return o.info, nil
 }
 
 type oauth2ServerInfo struct {
@@ -401,7 +746,32 @@ type oauth2ServerInfo struct {
 type oauth2TokenResponse struct {
 	AccessToken  string `json:"access_token"`
 	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
 	Installation struct {
 		APIEndpoint string `json:"apiEndpoint"`
 	} `json:"installation"`
 }
+
+// token converts the response into an OAuth2Token. A refresh response may omit
+// the refresh token and the installation, in which case they are kept from the
+// previous token.
+func (r oauth2TokenResponse) token(previous *OAuth2Token) *OAuth2Token {
+	token := &OAuth2Token{
+		AccessToken:  r.AccessToken,
+		RefreshToken: r.RefreshToken,
+		Server:       r.Installation.APIEndpoint,
+	}
+	if r.ExpiresIn > 0 {
+		token.Expiry = time.Now().Add(time.Duration(r.ExpiresIn) * time.Second)
+	}
+	if previous != nil {
+		if token.RefreshToken == "" {
+			token.RefreshToken = previous.RefreshToken
+		}
+		if token.Server == "" {
+			token.Server = previous.Server
+		}
+	}
+	return token
+}
@@ -0,0 +1,141 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// OAuth2Config holds the configuration of an OAuth2 application that
+// authorises many users, such as a web application onboarding different
+// Teamwork installations. Unlike OAuth2, it keeps no token: each step of the
+// authorization returns its result, and the application decides where to store
+// it. It is safe for concurrent use.
+//
+// The authorization works as follows:
+//
+//  1. Generate a verifier with NewOAuth2Verifier and an unguessable state, and
+//     keep both, for example in the user's session.
+//  2. Redirect the user to AuthCodeURL.
+//  3. Handle the redirect back to the application with CallbackHandler, or call
+//     Exchange with the received code.
+//  4. Use the token to authenticate requests, renewing it with Refresh once
+//     it expires.
+type OAuth2Config struct {
+	oauth        *OAuth2
+	redirectURL  string
+	serverInfo   *oauth2ServerInfo
+	authEndpoint url.URL
+}
+
+// NewOAuth2Config creates a new OAuth2Config with the provided client ID,
+// client secret and redirect URL, which must match the one registered for the
+// application. The authorization server is discovered straight away, so a
+// misconfiguration is reported on start up. Only the client, server and logger
+// options apply.
+func NewOAuth2Config(
+	ctx context.Context,
+	clientID, clientSecret, redirectURL string,
+	opts ...OAuth2Option,
+) (*OAuth2Config, error) {
+	oauth := NewOAuth2(clientID, clientSecret, opts...)
+
+	serverInfo, err := oauth.serverInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get oauth2 server info: %w", err)
+	}
+	authEndpoint, err := url.Parse(serverInfo.AuthorizationEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse authorization endpoint %q: %w", serverInfo.AuthorizationEndpoint, err)
+	}
+
+	return &OAuth2Config{
+		oauth:        oauth,
+		redirectURL:  redirectURL,
+		serverInfo:   serverInfo,
+		authEndpoint: *authEndpoint,
+	}, nil
+}
+
+// AuthCodeURL returns the URL of the authorization page the user must be
+// redirected to. The state is sent back unchanged to the redirect URL and must
+// be checked by the application to prevent cross-site request forgery. The
+// verifier, created with NewOAuth2Verifier, is required again to exchange the
+// authorization code.
+func (c *OAuth2Config) AuthCodeURL(state, verifier string) string {
+	return buildAuthorizationURL(c.authEndpoint, c.oauth.clientID, c.redirectURL, oauth2CodeChallenge(verifier), state)
+}
+
+// Exchange converts the authorization code received in the redirect URL into a
+// token, using the verifier that was used to build the authorization URL. The
+// token identifies the Teamwork installation the user authorised in its Server
+// field.
+func (c *OAuth2Config) Exchange(ctx context.Context, code, verifier string) (*OAuth2Token, error) {
+	if code == "" {
+		return nil, errors.New("missing authorization code")
+	}
+	token, err := c.oauth.retrieveToken(ctx, c.serverInfo, c.redirectURL, verifier, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	return token, nil
+}
+
+// Refresh obtains a new token using the refresh token of the provided one. The
+// refresh token and the server are kept from the previous token when the
+// authorization server does not return them again.
+func (c *OAuth2Config) Refresh(ctx context.Context, token *OAuth2Token) (*OAuth2Token, error) {
+	if token == nil || token.RefreshToken == "" {
+		return nil, errors.New("missing refresh token")
+	}
+	refreshed, err := c.oauth.refreshToken(ctx, c.serverInfo, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refresh access token: %w", err)
+	}
+	return refreshed, nil
+}
+
+// OAuth2VerifierFunc returns the verifier kept for the authorization with the
+// given state. It must return an error when the state is unknown or does not
+// belong to the user making the request.
+type OAuth2VerifierFunc func(r *http.Request, state string) (verifier string, err error)
+
+// OAuth2CallbackFunc receives the outcome of the authorization, either the
+// token or the reason it failed, and is responsible for writing the response
+// to the user.
+type OAuth2CallbackFunc func(w http.ResponseWriter, r *http.Request, token *OAuth2Token, err error)
+
+// CallbackHandler returns an http.Handler for the redirect URL. It checks the
+// authorization response, looks up the verifier of the state with verifier,
+// exchanges the code and hands the result over to callback.
+func (c *OAuth2Config) CallbackHandler(verifier OAuth2VerifierFunc, callback OAuth2CallbackFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := r.URL.Query()
+		if oauthErr := query.Get("error"); oauthErr != "" {
+			callback(w, r, nil, fmt.Errorf("authorization denied: %s %s", oauthErr, query.Get("error_description")))
+			return
+		}
+
+		code := codeFromQuery(c.serverInfo, query)
+		if code == "" {
+			callback(w, r, nil, errors.New("missing code parameter"))
+			return
+		}
+
+		codeVerifier, err := verifier(r, query.Get("state"))
+		if err != nil {
+			callback(w, r, nil, fmt.Errorf("failed to verify state: %w", err))
+			return
+		}
+
+		token, err := c.Exchange(r.Context(), code, codeVerifier)
+		callback(w, r, token, err)
+	})
+}
@@ -0,0 +1,175 @@
+package session_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/teamwork/twapi-go-sdk/session"
+)
+
+func TestOAuth2ConfigAuthCodeURL(t *testing.T) {
+	authServer, _ := newTestAuthorizationServer(t, func() string { return "https://example.teamwork.com" })
+
+	config, err := session.NewOAuth2Config(t.Context(), "client", "secret", "https://app.example.com/callback",
+		session.WithOAuth2Server(authServer.URL),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error creating config: %s", err)
+	}
+
+	authURL, err := url.Parse(config.AuthCodeURL("state", "verifier"))
+	if err != nil {
+		t.Fatalf("unexpected error parsing authorization URL: %s", err)
+	}
+	query := authURL.Query()
+
+	expected := map[string]string{
+		"client_id":             "client",
+		"response_type":         "code",
+		"redirect_uri":          "https://app.example.com/callback",
+		"state":                 "state",
+		"code_challenge":        "iMnq5o6zALKXGivsnlom_0F5_WYda32GHkxlV7mq7hQ",
+		"code_challenge_method": "S256",
+	}
+	for key, value := range expected {
+		if got := query.Get(key); got != value {
+			t.Errorf("expected %s %q but got %q", key, value, got)
+		}
+	}
+}
+
+func TestOAuth2ConfigCallbackHandler(t *testing.T) {
+	const apiEndpoint = "https://example.teamwork.com"
+
+	authServer, _ := newTestAuthorizationServer(t, func() string { return apiEndpoint })
+
+	mux := http.NewServeMux()
+	app := httptest.NewServer(mux)
+	t.Cleanup(app.Close)
+
+	config, err := session.NewOAuth2Config(t.Context(), "client", "secret", app.URL+"/callback",
+		session.WithOAuth2Server(authServer.URL),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error creating config: %s", err)
+	}
+
+	verifier, err := session.NewOAuth2Verifier()
+	if err != nil {
+		t.Fatalf("unexpected error generating verifier: %s", err)
+	}
+
+	tokens := make(chan *session.OAuth2Token, 1)
+	mux.Handle("/callback", config.CallbackHandler(
+		func(_ *http.Request, state string) (string, error) {
+			if state != "state" {
+				return "", errors.New("unknown state")
+			}
+			return verifier, nil
+		},
+		func(w http.ResponseWriter, _ *http.Request, token *session.OAuth2Token, err error) {
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			tokens <- token
+		},
+	))
+
+	tests := []struct {
+		name           string
+		state          string
+		expectedStatus int
+	}{{
+		name:           "it should exchange the code of a known state",
+		state:          "state",
+		expectedStatus: http.StatusOK,
+	}, {
+		name:           "it should reject an unknown state",
+		state:          "forged",
+		expectedStatus: http.StatusBadRequest,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location, err := authorize(t.Context(), config.AuthCodeURL(tt.state, verifier))
+			if err != nil {
+				t.Fatalf("unexpected error authorising: %s", err)
+			}
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, location, nil)
+			if err != nil {
+				t.Fatalf("unexpected error building request: %s", err)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error calling back: %s", err)
+			}
+			_ = resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Fatalf("expected status %d but got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			token := <-tokens
+			if token.AccessToken != "access-1" {
+				t.Errorf("expected the issued token but got %q", token.AccessToken)
+			}
+			if token.Server != apiEndpoint {
+				t.Errorf("expected server %q but got %q", apiEndpoint, token.Server)
+			}
+			if got := token.BearerToken().Server(); got != apiEndpoint {
+				t.Errorf("expected bearer token server %q but got %q", apiEndpoint, got)
+			}
+		})
+	}
+}
+
+func TestOAuth2ConfigRefresh(t *testing.T) {
+	authServer, issued := newTestAuthorizationServer(t, func() string { return "" })
+
+	config, err := session.NewOAuth2Config(t.Context(), "client", "secret", "https://app.example.com/callback",
+		session.WithOAuth2Server(authServer.URL),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error creating config: %s", err)
+	}
+
+	expired := &session.OAuth2Token{
+		AccessToken:  "access-0",
+		RefreshToken: "refresh",
+		Expiry:       time.Now().Add(-time.Hour),
+		Server:       "https://example.teamwork.com",
+	}
+	token, err := config.Refresh(t.Context(), expired)
+	if err != nil {
+		t.Fatalf("unexpected error refreshing: %s", err)
+	}
+
+	if token.AccessToken != "access-1" {
+		t.Errorf("expected the refreshed token but got %q", token.AccessToken)
+	}
+	if token.Server != expired.Server {
+		t.Errorf("expected the previous server %q but got %q", expired.Server, token.Server)
+	}
+	if token.Expired() {
+		t.Error("expected the refreshed token to be valid")
+	}
+	if expired.AccessToken != "access-0" {
+		t.Error("expected the previous token to be left untouched")
+	}
+	if n := issued.Load(); n != 1 {
+		t.Errorf("expected a single token request but got %d", n)
+	}
+
+	if _, err := config.Refresh(t.Context(), &session.OAuth2Token{AccessToken: "access"}); err == nil {
+		t.Error("expected an error refreshing a token without refresh token")
+	}
+}
@@ -0,0 +1,405 @@
+package session_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/session"
+)
+
+// newTestAuthorizationServer starts a fake OAuth2 authorization server that
+// exposes the discovery document and a token endpoint. Every token request is
+// answered with a new access token, numbered from 1. The code exchange checks
+// the PKCE verifier against the challenge sent to the authorization endpoint.
+func newTestAuthorizationServer(t *testing.T, apiEndpoint func() string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var issued atomic.Int32
+	var codeChallenge atomic.Value
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	mux.HandleFunc("GET /.well-known/oauth-authorization-server", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"authorization_endpoint":                server.URL + "/authorize",
+			"token_endpoint":                        server.URL + "/token",
+			"response_types_supported":              []string{"code"},
+			"token_endpoint_auth_methods_supported": []string{"client_secret_post"},
+		})
+	})
+	mux.HandleFunc("GET /authorize", func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("client_id") != "client" || query.Get("response_type") != "code" {
+			http.Error(w, "invalid client", http.StatusBadRequest)
+			return
+		}
+		if query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256" {
+			http.Error(w, "missing code challenge", http.StatusBadRequest)
+			return
+		}
+		redirect, err := url.Parse(query.Get("redirect_uri"))
+		if err != nil || redirect.Host == "" {
+			http.Error(w, "invalid redirect URI", http.StatusBadRequest)
+			return
+		}
+		codeChallenge.Store(query.Get("code_challenge"))
+		redirectQuery := url.Values{"code": {"code"}}
+		if state := query.Get("state"); state != "" {
+			redirectQuery.Set("state", state)
+		}
+		redirect.RawQuery = redirectQuery.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.PostForm.Get("client_id") != "client" || r.PostForm.Get("client_secret") != "secret" {
+			http.Error(w, "invalid client", http.StatusUnauthorized)
+			return
+		}
+		switch r.PostForm.Get("grant_type") {
+		case "refresh_token":
+			if r.PostForm.Get("refresh_token") != "refresh" {
+				http.Error(w, "invalid refresh token", http.StatusBadRequest)
+				return
+			}
+		case "authorization_code":
+			if r.PostForm.Get("code") != "code" {
+				http.Error(w, "invalid code", http.StatusBadRequest)
+				return
+			}
+			challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+			if base64.RawURLEncoding.EncodeToString(challenge[:]) != codeChallenge.Load() {
+				http.Error(w, "invalid code verifier", http.StatusBadRequest)
+				return
+			}
+		default:
+			http.Error(w, "unsupported grant type", http.StatusBadRequest)
+			return
+		}
+		n := issued.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  fmt.Sprintf("access-%d", n),
+			"token_type":    "Bearer",
+			"refresh_token": "refresh",
+			"expires_in":    3600,
+			"installation":  map[string]any{"apiEndpoint": apiEndpoint()},
+		})
+	})
+
+	return server, &issued
+}
+
+func TestOAuth2RefreshExpiredToken(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer access-1" {
+			t.Errorf("expected the refreshed token but got %q", got)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(api.Close)
+
+	authServer, issued := newTestAuthorizationServer(t, func() string { return api.URL })
+
+	store := session.NewMemoryTokenStore()
+	err := store.Save(t.Context(), &session.OAuth2Token{
+		AccessToken:  "expired",
+		RefreshToken: "refresh",
+		Expiry:       time.Now().Add(-time.Hour),
+		Server:       api.URL,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error saving token: %s", err)
+	}
+
+	oauth2 := session.NewOAuth2("client", "secret",
+		session.WithOAuth2Server(authServer.URL),
+		session.WithOAuth2TokenStore(store),
+	)
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "/projects/api/v3/me.json", nil)
+	if err != nil {
+		t.Fatalf("unexpected error building request: %s", err)
+	}
+	if err := oauth2.Authenticate(t.Context(), req); err != nil {
+		t.Fatalf("unexpected error authenticating: %s", err)
+	}
+
+	if got := req.Header.Get("Authorization"); got != "Bearer access-1" {
+		t.Errorf("expected the refreshed token but got %q", got)
+	}
+	if req.URL.Host != api.Listener.Addr().String() {
+		t.Errorf("expected the request to target the installation but got %q", req.URL.Host)
+	}
+	if n := issued.Load(); n != 1 {
+		t.Errorf("expected a single token request but got %d", n)
+	}
+
+	stored, err := store.Load(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error loading token: %s", err)
+	}
+	if stored.AccessToken != "access-1" || stored.Expiry.Before(time.Now()) {
+		t.Errorf("expected the refreshed token to be persisted but got %+v", stored)
+	}
+}
+
+func TestOAuth2RefreshOnUnauthorized(t *testing.T) {
+	var requests atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("Authorization") != "Bearer access-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(api.Close)
+
+	authServer, issued := newTestAuthorizationServer(t, func() string { return api.URL })
+
+	store := session.NewMemoryTokenStore()
+	err := store.Save(t.Context(), &session.OAuth2Token{
+		AccessToken:  "revoked",
+		RefreshToken: "refresh",
+		Server:       api.URL,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error saving token: %s", err)
+	}
+
+	engine := twapi.NewEngine(session.NewOAuth2("client", "secret",
+		session.WithOAuth2Server(authServer.URL),
+		session.WithOAuth2TokenStore(store),
+	))
+
+	resp, err := twapi.ExecuteRaw(t.Context(), engine, requester(http.MethodGet, "/projects/api/v3/me.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected status %d but got %d", http.StatusNoContent, resp.StatusCode)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("expected the request to be sent twice but got %d", n)
+	}
+	if n := issued.Load(); n != 1 {
+		t.Errorf("expected a single token request but got %d", n)
+	}
+}
+
+// authorize follows the authorization URL like a browser would, returning the
+// URL the authorization server redirected to.
+func authorize(ctx context.Context, authURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, authURL, nil)
+	if err != nil {
+		return "", err
+	}
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusFound {
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return resp.Header.Get("Location"), nil
+}
+
+func TestOAuth2AuthorizationHandler(t *testing.T) {
+	const redirectURL = "https://app.example.com/oauth2/callback"
+
+	authServer, issued := newTestAuthorizationServer(t, func() string { return "https://example.teamwork.com" })
+
+	oauth2 := session.NewOAuth2("client", "secret",
+		session.WithOAuth2Server(authServer.URL),
+		session.WithOAuth2RedirectURL(redirectURL),
+		session.WithOAuth2AuthorizationHandler(func(ctx context.Context, authURL string) (string, error) {
+			location, err := authorize(ctx, authURL)
+			if err != nil {
+				return "", err
+			}
+			if !strings.HasPrefix(location, redirectURL+"?") {
+				return "", fmt.Errorf("unexpected redirect to %q", location)
+			}
+			redirect, err := url.Parse(location)
+			if err != nil {
+				return "", err
+			}
+			return redirect.Query().Get("code"), nil
+		}),
+	)
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "/projects/api/v3/me.json", nil)
+	if err != nil {
+		t.Fatalf("unexpected error building request: %s", err)
+	}
+	if err := oauth2.Authenticate(t.Context(), req); err != nil {
+		t.Fatalf("unexpected error authenticating: %s", err)
+	}
+
+	if got := req.Header.Get("Authorization"); got != "Bearer access-1" {
+		t.Errorf("expected the issued token but got %q", got)
+	}
+	if got := oauth2.Server(); got != "https://example.teamwork.com" {
+		t.Errorf("expected the installation server but got %q", got)
+	}
+	if n := issued.Load(); n != 1 {
+		t.Errorf("expected a single token request but got %d", n)
+	}
+}
+
+func TestOAuth2ManualAuthorization(t *testing.T) {
+	authServer, _ := newTestAuthorizationServer(t, func() string { return "https://example.teamwork.com" })
+
+	tests := []struct {
+		name  string
+		input func(redirect string) string
+	}{{
+		name:  "pasted redirect URL",
+		input: func(redirect string) string { return redirect + "\n" },
+	}, {
+		name:  "pasted code",
+		input: func(string) string { return "  code  \n" },
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the authorization URL is only known once the session writes it, so
+			// the input is provided through a pipe after reading the output
+			inReader, inWriter := io.Pipe()
+			t.Cleanup(func() { _ = inWriter.Close() })
+
+			out := &promptWriter{prompted: make(chan string, 1)}
+			oauth2 := session.NewOAuth2("client", "secret",
+				session.WithOAuth2Server(authServer.URL),
+				session.WithOAuth2ManualAuthorization(inReader, out),
+			)
+
+			go func() {
+				authURL := <-out.prompted
+				redirect, err := authorize(t.Context(), authURL)
+				if err != nil {
+					t.Errorf("failed to authorize: %s", err)
+				}
+				_, _ = io.WriteString(inWriter, tt.input(redirect))
+			}()
+
+			if got := oauth2.BearerToken(); got != "" {
+				t.Errorf("expected no token before authenticating but got %q", got)
+			}
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "/projects/api/v3/me.json", nil)
+			if err != nil {
+				t.Fatalf("unexpected error building request: %s", err)
+			}
+			if err := oauth2.Authenticate(t.Context(), req); err != nil {
+				t.Fatalf("unexpected error authenticating: %s", err)
+			}
+			if got := oauth2.BearerToken(); got == "" {
+				t.Error("expected a token after authenticating")
+			}
+		})
+	}
+}
+
+func TestOAuth2ManualAuthorizationDenied(t *testing.T) {
+	authServer, _ := newTestAuthorizationServer(t, func() string { return "https://example.teamwork.com" })
+
+	oauth2 := session.NewOAuth2("client", "secret",
+		session.WithOAuth2Server(authServer.URL),
+		session.WithOAuth2ManualAuthorization(
+			strings.NewReader("http://localhost/oauth2/callback?error=access_denied\n"),
+			io.Discard,
+		),
+	)
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "/projects/api/v3/me.json", nil)
+	if err != nil {
+		t.Fatalf("unexpected error building request: %s", err)
+	}
+	if err := oauth2.Authenticate(t.Context(), req); err == nil || !strings.Contains(err.Error(), "access_denied") {
+		t.Errorf("expected the authorization to be denied but got %v", err)
+	}
+}
+
+func TestOAuth2ManualAuthorizationCancelled(t *testing.T) {
+	authServer, _ := newTestAuthorizationServer(t, func() string { return "https://example.teamwork.com" })
+
+	in, typed := net.Pipe()
+	t.Cleanup(func() {
+		_ = in.Close()
+		_ = typed.Close()
+	})
+	out := &promptWriter{prompted: make(chan string, 1)}
+	oauth2 := session.NewOAuth2("client", "secret",
+		session.WithOAuth2Server(authServer.URL),
+		session.WithOAuth2ManualAuthorization(in, out),
+	)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	go func() {
+		<-out.prompted
+		cancel()
+	}()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/projects/api/v3/me.json", nil)
+	if err != nil {
+		t.Fatalf("unexpected error building request: %s", err)
+	}
+	if err := oauth2.Authenticate(ctx, req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the authorization to be cancelled but got %v", err)
+	}
+
+	// the prompt stopped reading, so the next line is left to whoever reads
+	// the input next
+	_ = typed.SetWriteDeadline(time.Now().Add(50 * time.Millisecond))
+	if _, err := io.WriteString(typed, "code\n"); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("expected nothing to read the input after the cancellation but got %v", err)
+	}
+}
+
+// promptWriter captures the authorization URL written by the manual
+// authorization flow.
+type promptWriter struct {
+	buffer   bytes.Buffer
+	prompted chan string
+}
+
+func (p *promptWriter) Write(data []byte) (int, error) {
+	n, err := p.buffer.Write(data)
+	for line := range strings.Lines(p.buffer.String()) {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "http") {
+			select {
+			case p.prompted <- line:
+			default:
+			}
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,256 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+)
+
+var (
+	_ twapi.ContextSession     = (*Router)(nil)
+	_ twapi.RefreshableSession = (*Router)(nil)
+)
+
+type tenantContextKey struct{}
+
+// WithTenant returns a new context identifying the tenant the requests are sent
+// on behalf of. The tenant is an application defined key, such as a customer
+// or installation ID, that the Router hands over to its CredentialProvider.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant stored in the context by WithTenant.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	tenant, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenant, ok && tenant != ""
+}
+
+// Credentials are the credentials of a tenant.
+type Credentials struct {
+	// Session authenticates the requests of the tenant. Its Server method must
+	// identify the tenant's Teamwork installation, such as a BearerToken created
+	// from an OAuth2Token.
+	Session twapi.Session
+
+	// Expiry is when the credentials expire, after which the Router asks the
+	// CredentialProvider for new ones. The zero value means they do not expire.
+	Expiry time.Time
+}
+
+// expired reports whether the credentials expired or are about to expire.
+func (c *Credentials) expired() bool {
+	return !c.Expiry.IsZero() && time.Now().Add(expiryDelta).After(c.Expiry)
+}
+
+// CredentialProvider resolves the credentials of a tenant.
+type CredentialProvider interface {
+	// Credentials returns the credentials of the tenant. It is called the first
+	// time the tenant is seen, once its credentials expire and after the API
+	// rejects them. In the last two cases stale holds the previous credentials,
+	// so the provider can renew them, for example with OAuth2Config.Refresh.
+	Credentials(ctx context.Context, tenant string, stale *Credentials) (*Credentials, error)
+}
+
+// CredentialProviderFunc is a function that implements the CredentialProvider
+// interface.
+type CredentialProviderFunc func(ctx context.Context, tenant string, stale *Credentials) (*Credentials, error)
+
+// Credentials implements the CredentialProvider interface.
+func (f CredentialProviderFunc) Credentials(
+	ctx context.Context,
+	tenant string,
+	stale *Credentials,
+) (*Credentials, error) {
+	return f(ctx, tenant, stale)
+}
+
+// Router is a session that authenticates each request with the credentials of
+// the tenant stored in the request context with WithTenant. It allows a single
+// Engine to serve many Teamwork installations, building every request against
+// the installation of its tenant.
+//
+// The credentials are cached per tenant and resolved again through the
+// CredentialProvider when they expire or are rejected by the API. Concurrent
+// requests of the same tenant share a single call to the provider.
+type Router struct {
+	provider CredentialProvider
+	ttl      time.Duration
+
+	mutex   sync.Mutex
+	tenants map[string]*routerTenant
+}
+
+// routerTenant holds the cached credentials of a tenant. Its mutex is held
+// while calling the provider, so the tenant credentials are resolved once.
+type routerTenant struct {
+	mutex       sync.Mutex
+	credentials *Credentials
+	resolvedAt  time.Time
+}
+
+// RouterOption defines a function type that can modify the Router
+// configuration.
+type RouterOption func(*Router)
+
+// WithRouterCacheTTL limits how long the credentials of a tenant are cached,
+// even when they do not expire. It is useful when the credentials can be
+// revoked or replaced outside of the application. By default the credentials
+// are cached until they expire or are rejected by the API.
+func WithRouterCacheTTL(ttl time.Duration) RouterOption {
+	return func(r *Router) {
+		r.ttl = ttl
+	}
+}
+
+// NewRouter creates a new Router resolving the tenant credentials with the
+// provided CredentialProvider.
+func NewRouter(provider CredentialProvider, opts ...RouterOption) *Router {
+	router := &Router{
+		provider: provider,
+		tenants:  make(map[string]*routerTenant),
+	}
+	for _, opt := range opts {
+		opt(router)
+	}
+	return router
+}
+
+// Authenticate implements the Session interface for Router.
+func (r *Router) Authenticate(ctx context.Context, req *http.Request) error {
+	credentials, err := r.credentials(ctx)
+	if err != nil {
+		return err
+	}
+	return credentials.Session.Authenticate(ctx, req)
+}
+
+// Server implements the Session interface for Router. It always returns an
+// empty string, as the server depends on the tenant of each request. The
+// Engine uses ServerContext instead.
+func (r *Router) Server() string {
+	return ""
+}
+
+// ServerContext implements the ContextSession interface for Router, returning
+// the server of the tenant stored in the context.
+func (r *Router) ServerContext(ctx context.Context) (string, error) {
+	credentials, err := r.credentials(ctx)
+	if err != nil {
+		return "", err
+	}
+	return credentials.Session.Server(), nil
+}
+
+// Refresh implements the RefreshableSession interface for Router. The tenant
+// credentials are resolved again, unless they were already replaced since the
+// rejected request was sent.
+func (r *Router) Refresh(ctx context.Context, rejected *http.Request) error {
+	tenantName, ok := TenantFromContext(ctx)
+	if !ok {
+		return errors.New("missing tenant")
+	}
+	tenant := r.tenant(tenantName)
+
+	tenant.mutex.Lock()
+	defer tenant.mutex.Unlock()
+
+	if tenant.credentials != nil {
+		current, err := authenticatedHeaders(ctx, tenant.credentials.Session, rejected)
+		if err != nil {
+			return err
+		}
+		if !sameAuthentication(current, rejected.Header) {
+			return nil
+		}
+	}
+	return r.resolve(ctx, tenantName, tenant)
+}
+
+// Forget drops the cached credentials of the tenant, for example after it
+// uninstalled the application.
+func (r *Router) Forget(tenant string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.tenants, tenant)
+}
+
+// credentials returns the valid credentials of the tenant stored in the
+// context, resolving them when needed.
+func (r *Router) credentials(ctx context.Context) (*Credentials, error) {
+	tenantName, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil, errors.New("missing tenant")
+	}
+	tenant := r.tenant(tenantName)
+
+	tenant.mutex.Lock()
+	defer tenant.mutex.Unlock()
+
+	if tenant.credentials == nil || tenant.credentials.expired() ||
+		(r.ttl > 0 && time.Since(tenant.resolvedAt) > r.ttl) {
+		if err := r.resolve(ctx, tenantName, tenant); err != nil {
+			if tenant.credentials == nil {
+				// don't keep unknown tenants around
+				r.Forget(tenantName)
+			}
+			return nil, err
+		}
+	}
+	return tenant.credentials, nil
+}
+
+// resolve asks the provider for the tenant credentials. The caller must hold
+// the tenant mutex.
+func (r *Router) resolve(ctx context.Context, tenantName string, tenant *routerTenant) error {
+	credentials, err := r.provider.Credentials(ctx, tenantName, tenant.credentials)
+	if err != nil {
+		return fmt.Errorf("failed to resolve credentials of tenant %q: %w", tenantName, err)
+	}
+	if credentials == nil || credentials.Session == nil {
+		return fmt.Errorf("missing credentials of tenant %q", tenantName)
+	}
+	tenant.credentials = credentials
+	tenant.resolvedAt = time.Now()
+	return nil
+}
+
+// tenant returns the cache entry of the tenant, creating it when missing.
+func (r *Router) tenant(tenantName string) *routerTenant {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	tenant, ok := r.tenants[tenantName]
+	if !ok {
+		tenant = &routerTenant{}
+		r.tenants[tenantName] = tenant
+	}
+	return tenant
+}
+
+// authenticatedHeaders returns the headers the session adds to a copy of the
+// request.
+func authenticatedHeaders(ctx context.Context, session twapi.Session, req *http.Request) (http.Header, error) {
+	probe, err := http.NewRequestWithContext(ctx, req.Method, req.URL.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	if err := session.Authenticate(ctx, probe); err != nil {
+		return nil, fmt.Errorf("failed to authenticate request: %w", err)
+	}
+	return probe.Header, nil
+}
+
+// sameAuthentication reports whether both headers carry the same credentials.
+func sameAuthentication(a, b http.Header) bool {
+	return a.Get("Authorization") == b.Get("Authorization") && a.Get("Cookie") == b.Get("Cookie")
+}
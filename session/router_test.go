@@ -0,0 +1,136 @@
+package session_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/session"
+)
+
+// newTestInstallation starts a fake Teamwork installation that only accepts the
+// given bearer token.
+func newTestInstallation(t *testing.T, token *atomic.Value) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRouter(t *testing.T) {
+	var tokenA, tokenB atomic.Value
+	tokenA.Store("token-a")
+	tokenB.Store("token-b")
+	installations := map[string]*httptest.Server{
+		"a": newTestInstallation(t, &tokenA),
+		"b": newTestInstallation(t, &tokenB),
+	}
+	tokens := map[string]*atomic.Value{"a": &tokenA, "b": &tokenB}
+
+	var calls atomic.Int32
+	router := session.NewRouter(session.CredentialProviderFunc(
+		func(_ context.Context, tenant string, _ *session.Credentials) (*session.Credentials, error) {
+			calls.Add(1)
+			installation, ok := installations[tenant]
+			if !ok {
+				return nil, fmt.Errorf("unknown tenant %q", tenant)
+			}
+			return &session.Credentials{
+				Session: session.NewBearerToken(tokens[tenant].Load().(string), installation.URL),
+			}, nil
+		},
+	))
+	engine := twapi.NewEngine(router)
+
+	send := func(tenant string) error {
+		ctx := session.WithTenant(t.Context(), tenant)
+		resp, err := twapi.ExecuteRaw(ctx, engine, requester(http.MethodGet, "/projects/api/v3/me.json"))
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent {
+			return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := send([]string{"a", "b"}[i%2]); err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := calls.Load(); n != 2 {
+		t.Errorf("expected the credentials of each tenant to be resolved once but got %d calls", n)
+	}
+
+	// the installation rotates the token, so the cached one is rejected
+	tokenA.Store("token-a2")
+	if err := send("a"); err != nil {
+		t.Errorf("unexpected error after token rotation: %s", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("expected the rejected credentials to be resolved again but got %d calls", n)
+	}
+
+	if err := send("c"); err == nil {
+		t.Error("expected an error for an unknown tenant")
+	}
+	if _, err := twapi.ExecuteRaw(t.Context(), engine, requester(http.MethodGet, "/")); err == nil {
+		t.Error("expected an error without tenant")
+	}
+}
+
+func TestRouterExpiredCredentials(t *testing.T) {
+	var stales []*session.Credentials
+	router := session.NewRouter(session.CredentialProviderFunc(
+		func(_ context.Context, _ string, stale *session.Credentials) (*session.Credentials, error) {
+			stales = append(stales, stale)
+			return &session.Credentials{
+				Session: session.NewBearerToken(fmt.Sprintf("token-%d", len(stales)), "https://example.teamwork.com"),
+				Expiry:  time.Now().Add(30 * time.Second),
+			}, nil
+		},
+	))
+
+	ctx := session.WithTenant(t.Context(), "tenant")
+	for range 2 {
+		server, err := router.ServerContext(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if server != "https://example.teamwork.com" {
+			t.Errorf("expected the tenant server but got %q", server)
+		}
+	}
+
+	if len(stales) != 2 {
+		t.Fatalf("expected credentials about to expire to be renewed but got %d calls", len(stales))
+	}
+	if stales[0] != nil {
+		t.Error("expected no stale credentials on the first call")
+	}
+	if stales[1] == nil {
+		t.Error("expected the expired credentials on renewal")
+	}
+}
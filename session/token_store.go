@@ -0,0 +1,180 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// expiryDelta is how long before its expiry a token is considered expired, so
+// it is not sent just as it stops being valid.
+const expiryDelta = time.Minute
+
+// OAuth2Token contains the credentials obtained through an OAuth2
+// authorization.
+type OAuth2Token struct {
+	// AccessToken is the bearer token used to authenticate requests.
+	AccessToken string `json:"accessToken"`
+
+	// RefreshToken is used to obtain a new access token once it expires. It is
+	// empty when the authorization server did not issue one.
+	RefreshToken string `json:"refreshToken,omitempty"`
+
+	// Expiry is when the access token expires. The zero value means the access
+	// token does not expire.
+	Expiry time.Time `json:"expiry,omitzero"`
+
+	// Server is the API endpoint of the Teamwork installation the user
+	// authorised, such as "https://yourcompany.teamwork.com".
+	Server string `json:"server"`
+}
+
+// Expired reports whether the access token expired or is about to expire.
+func (t *OAuth2Token) Expired() bool {
+	return !t.Expiry.IsZero() && time.Now().Add(expiryDelta).After(t.Expiry)
+}
+
+// BearerToken returns a session authenticating requests with the access token
+// against the installation the token belongs to. In a service handling many
+// users it can be attached to each request context with WithBearerTokenContext,
+// and used with an Engine created with a BearerTokenContext session.
+func (t *OAuth2Token) BearerToken() *BearerToken {
+	return NewBearerToken(t.AccessToken, t.Server)
+}
+
+// TokenStore persists the OAuth2 token of a session, so a user that already
+// authorised the application is not asked again.
+type TokenStore interface {
+	// Load returns the stored token. It returns a nil token and no error when
+	// no token was stored yet.
+	Load(ctx context.Context) (*OAuth2Token, error)
+
+	// Save stores the token, replacing any previous one.
+	Save(ctx context.Context, token *OAuth2Token) error
+}
+
+var (
+	_ TokenStore = (*FileTokenStore)(nil)
+	_ TokenStore = (*MemoryTokenStore)(nil)
+)
+
+// FileTokenStore stores the OAuth2 token as JSON in a file only readable by the
+// current user.
+type FileTokenStore struct {
+	path  string
+	mutex sync.Mutex
+}
+
+// NewFileTokenStore creates a new FileTokenStore that stores the token in the
+// provided path. Missing parent directories are created when saving.
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+// Load implements the TokenStore interface for FileTokenStore.
+func (f *FileTokenStore) Load(_ context.Context) (*OAuth2Token, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token file %q: %w", f.path, err)
+	}
+
+	var token OAuth2Token
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("failed to decode token file %q: %w", f.path, err)
+	}
+	return &token, nil
+}
+
+// Save implements the TokenStore interface for FileTokenStore. The token is
+// written to a temporary file that replaces the previous one, so a failure
+// never leaves a partially written token behind.
+func (f *FileTokenStore) Save(_ context.Context, token *OAuth2Token) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return fmt.Errorf("failed to encode token: %w", err)
+	}
+
+	dir := filepath.Dir(f.path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create token directory %q: %w", dir, err)
+	}
+
+	file, err := os.CreateTemp(dir, filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary token file: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+
+	// CreateTemp already creates the file with 0600, but being explicit guards
+	// against platforms that behave differently
+	if err := file.Chmod(0o600); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to restrict token file permissions: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close token file: %w", err)
+	}
+	if err := os.Rename(file.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to replace token file %q: %w", f.path, err)
+	}
+	return nil
+}
+
+// MemoryTokenStore keeps the OAuth2 token in memory. It is useful to share a
+// token between sessions of the same process, and in tests.
+type MemoryTokenStore struct {
+	token *OAuth2Token
+	mutex sync.Mutex
+}
+
+// NewMemoryTokenStore creates a new empty MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{}
+}
+
+// Load implements the TokenStore interface for MemoryTokenStore.
+func (m *MemoryTokenStore) Load(_ context.Context) (*OAuth2Token, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.token == nil {
+		return nil, nil
+	}
+	token := *m.token
+	return &token, nil
+}
+
+// Save implements the TokenStore interface for MemoryTokenStore.
+func (m *MemoryTokenStore) Save(_ context.Context, token *OAuth2Token) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if token == nil {
+		m.token = nil
+		return nil
+	}
+	stored := *token
+	m.token = &stored
+	return nil
+}
@@ -0,0 +1,70 @@
+package session_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/teamwork/twapi-go-sdk/session"
+)
+
+func TestFileTokenStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "token.json")
+	store := session.NewFileTokenStore(path)
+
+	token, err := store.Load(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error loading a missing token: %s", err)
+	}
+	if token != nil {
+		t.Fatalf("expected no token but got %+v", token)
+	}
+
+	want := &session.OAuth2Token{
+		AccessToken:  "access",
+		RefreshToken: "refresh",
+		Expiry:       time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		Server:       "https://example.teamwork.com",
+	}
+	if err := store.Save(t.Context(), want); err != nil {
+		t.Fatalf("unexpected error saving token: %s", err)
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("unexpected error reading token file: %s", err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("expected token file permissions 0600 but got %o", perm)
+		}
+	}
+
+	got, err := session.NewFileTokenStore(path).Load(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error loading token: %s", err)
+	}
+	if got == nil || *got != *want {
+		t.Errorf("expected token %+v but got %+v", want, got)
+	}
+}
+
+func TestMemoryTokenStore(t *testing.T) {
+	store := session.NewMemoryTokenStore()
+
+	token := &session.OAuth2Token{AccessToken: "access"}
+	if err := store.Save(t.Context(), token); err != nil {
+		t.Fatalf("unexpected error saving token: %s", err)
+	}
+	token.AccessToken = "modified"
+
+	got, err := store.Load(t.Context())
+	if err != nil {
+		t.Fatalf("unexpected error loading token: %s", err)
+	}
+	if got == nil || got.AccessToken != "access" {
+		t.Errorf("expected the stored token to be isolated from the caller but got %+v", got)
+	}
+}
@@ -0,0 +1,184 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Cursor is the position of a ChangeFeed. It is encoded as JSON to be stored
+// between polls.
+type Cursor struct {
+	// Entities contains the high-water mark of each entity polled.
+	Entities map[Entity]Watermark `json:"entities"`
+}
+
+// Watermark is the position of a ChangeFeed for an entity.
+type Watermark struct {
+	// Start is the moment the changes of the entity are followed from. The
+	// changes before it are never reported, even within the skew window.
+	Start time.Time `json:"start,omitzero"`
+
+	// At is the time of the latest change reported.
+	At time.Time `json:"at"`
+
+	// PolledAt is when the latest complete poll started. Every change before it
+	// was reported, so the next poll loads the changes from there even when the
+	// entity had no change.
+	PolledAt time.Time `json:"polledAt,omitzero"`
+
+	// Recent contains the changes reported within the skew window before At,
+	// which the next poll loads again and must not report twice. Several changes
+	// can happen at the same time, so the time alone cannot tell them apart.
+	Recent []Change `json:"recent,omitempty"`
+}
+
+// reported reports whether the change was already reported, being older than
+// the skew window or one of the recent changes.
+func (c *Cursor) reported(change Change, skew time.Duration) bool {
+	watermark, ok := c.Entities[change.Entity]
+	if !ok {
+		return false
+	}
+	if change.At.Before(watermark.Start) || !change.At.After(watermark.At.Add(-skew)) {
+		return true
+	}
+	return slices.ContainsFunc(watermark.Recent, func(recent Change) bool {
+		return sameChange(recent, change)
+	})
+}
+
+// advance moves the high-water mark of the entity past the change, forgetting
+// the recent changes that fell out of the skew window. The start is recorded
+// with the first change of the entity.
+func (c *Cursor) advance(change Change, skew time.Duration, start time.Time) {
+	if c.Entities == nil {
+		c.Entities = make(map[Entity]Watermark)
+	}
+	watermark, ok := c.Entities[change.Entity]
+	if !ok {
+		watermark.Start = start
+	}
+	if change.At.After(watermark.At) {
+		watermark.At = change.At
+	}
+	watermark.Recent = append(watermark.Recent, change)
+	watermark.Recent = slices.DeleteFunc(watermark.Recent, func(recent Change) bool {
+		return !recent.At.After(watermark.At.Add(-skew))
+	})
+	c.Entities[change.Entity] = watermark
+}
+
+// polled records that every change of the entities before the moment was
+// reported. The start is recorded for the entities polled for the first time.
+func (c *Cursor) polled(entities []Entity, at, start time.Time) {
+	if c.Entities == nil {
+		c.Entities = make(map[Entity]Watermark)
+	}
+	for _, entity := range entities {
+		watermark, ok := c.Entities[entity]
+		if !ok {
+			watermark.Start = start
+		}
+		if at.After(watermark.PolledAt) {
+			watermark.PolledAt = at
+		}
+		c.Entities[entity] = watermark
+	}
+}
+
+// clone returns a deep copy of the cursor.
+func (c *Cursor) clone() Cursor {
+	if c.Entities == nil {
+		return Cursor{}
+	}
+	entities := maps.Clone(c.Entities)
+	for entity, watermark := range entities {
+		watermark.Recent = slices.Clone(watermark.Recent)
+		entities[entity] = watermark
+	}
+	return Cursor{Entities: entities}
+}
+
+// CursorStore persists the cursor of a ChangeFeed, so a process resuming the
+// feed continues where the previous one stopped.
+type CursorStore interface {
+	// Load returns the stored cursor. It returns a nil cursor and no error when
+	// no cursor was stored yet.
+	Load(ctx context.Context) (*Cursor, error)
+
+	// Save stores the cursor, replacing any previous one.
+	Save(ctx context.Context, cursor *Cursor) error
+}
+
+var _ CursorStore = (*FileCursorStore)(nil)
+
+// FileCursorStore stores the cursor as JSON in a file.
+type FileCursorStore struct {
+	path string
+}
+
+// NewFileCursorStore creates a new FileCursorStore that stores the cursor in
+// the provided path. Missing parent directories are created when saving.
+func NewFileCursorStore(path string) *FileCursorStore {
+	return &FileCursorStore{path: path}
+}
+
+// Load implements the CursorStore interface for FileCursorStore.
+func (f *FileCursorStore) Load(_ context.Context) (*Cursor, error) {
+	data, err := os.ReadFile(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cursor file %q: %w", f.path, err)
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, fmt.Errorf("failed to decode cursor file %q: %w", f.path, err)
+	}
+	return &cursor, nil
+}
+
+// Save implements the CursorStore interface for FileCursorStore. The cursor is
+// written to a temporary file that replaces the previous one, so a failure
+// never leaves a partially written cursor behind.
+func (f *FileCursorStore) Save(_ context.Context, cursor *Cursor) error {
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return fmt.Errorf("failed to encode cursor: %w", err)
+	}
+
+	dir := filepath.Dir(f.path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create cursor directory %q: %w", dir, err)
+	}
+
+	file, err := os.CreateTemp(dir, filepath.Base(f.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary cursor file: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(file.Name())
+	}()
+
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write cursor file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close cursor file: %w", err)
+	}
+	if err := os.Rename(file.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to replace cursor file %q: %w", f.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,561 @@
+// Package sync follows the changes made in an installation, to keep a local
+// copy of its entities up to date without loading everything again.
+//
+// A ChangeFeed polls the activity log and the lists supporting the
+// updatedAfter filter, and reports the changes found since the previous poll
+// in chronological order:
+//
+//	feed := sync.NewChangeFeed(engine, sync.WithCursorStore(sync.NewFileCursorStore("cursor.json")))
+//	for change, err := range feed.Changes(ctx) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(change.Entity, change.ID, change.Action, change.At)
+//	}
+//
+// The position of the feed is a Cursor, keeping a high-water mark per entity.
+// It is stored after every poll, so a process resuming the feed does not
+// report the same changes again.
+package sync
+
+import (
+	"cmp"
+	"context"
+	"iter"
+	"slices"
+	"time"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/projects"
+)
+
+const defaultSkew = time.Minute
+
+// Entity is a kind of item whose changes are followed.
+type Entity string
+
+// List of entities.
+const (
+	EntityProject   Entity = "project"
+	EntityTasklist  Entity = "tasklist"
+	EntityTask      Entity = "task"
+	EntityMilestone Entity = "milestone"
+	EntityComment   Entity = "comment"
+	EntityTimelog   Entity = "timelog"
+	EntityMessage   Entity = "message"
+	EntityFile      Entity = "file"
+	EntityNotebook  Entity = "notebook"
+	EntityLink      Entity = "link"
+)
+
+// Entities lists every entity, which a ChangeFeed follows by default.
+var Entities = []Entity{
+	EntityProject,
+	EntityTasklist,
+	EntityTask,
+	EntityMilestone,
+	EntityComment,
+	EntityTimelog,
+	EntityMessage,
+	EntityFile,
+	EntityNotebook,
+	EntityLink,
+}
+
+// Action is what happened to an item.
+type Action string
+
+// List of actions.
+const (
+	ActionCreated   Action = "created"
+	ActionUpdated   Action = "updated"
+	ActionDeleted   Action = "deleted"
+	ActionCompleted Action = "completed"
+	ActionReopened  Action = "reopened"
+	ActionRestored  Action = "restored"
+)
+
+// Change is a change made to an item.
+type Change struct {
+	// Entity is the kind of the item.
+	Entity Entity `json:"entity"`
+
+	// ID is the unique identifier of the item.
+	ID int64 `json:"id"`
+
+	// Action is what happened to the item.
+	Action Action `json:"action"`
+
+	// At is when the change happened.
+	At time.Time `json:"at"`
+}
+
+// activityEntities maps the item types of the activity log to the entities.
+// Comments have an item type for each kind of object they are posted on.
+var activityEntities = map[projects.LogItemType]Entity{
+	projects.LogItemTypeProject:          EntityProject,
+	projects.LogItemTypeTasklist:         EntityTasklist,
+	projects.LogItemTypeTask:             EntityTask,
+	projects.LogItemTypeMilestone:        EntityMilestone,
+	projects.LogItemTypeComment:          EntityComment,
+	projects.LogItemTypeTaskComment:      EntityComment,
+	projects.LogItemTypeMilestoneComment: EntityComment,
+	projects.LogItemTypeNotebookComment:  EntityComment,
+	projects.LogItemTypeFileComment:      EntityComment,
+	projects.LogItemTypeLinkComment:      EntityComment,
+	projects.LogItemTypeTimelog:          EntityTimelog,
+	projects.LogItemTypeMessage:          EntityMessage,
+	projects.LogItemTypeFile:             EntityFile,
+	projects.LogItemTypeNotebook:         EntityNotebook,
+	projects.LogItemTypeLink:             EntityLink,
+}
+
+// activityActions maps the actions of the activity log to the actions. Likes,
+// reactions and views do not change the item, so they are not reported.
+var activityActions = map[projects.Action]Action{
+	projects.LogTypeNew:       ActionCreated,
+	projects.LogTypeEdited:    ActionUpdated,
+	projects.LogTypeDeleted:   ActionDeleted,
+	projects.LogTypeCompleted: ActionCompleted,
+	projects.LogTypeReopened:  ActionReopened,
+	projects.LogTypeUndeleted: ActionRestored,
+}
+
+// updatedLoader loads the items of an entity updated after a moment, with the
+// list supporting the updatedAfter filter.
+type updatedLoader func(ctx context.Context, f *ChangeFeed, since time.Time) ([]Change, error)
+
+// updatedLoaders contains the entities whose list supports the updatedAfter
+// filter. It catches the changes not recorded in the activity log, such as
+// most of the updates made through the API.
+var updatedLoaders = map[Entity]updatedLoader{
+	EntityProject: loadUpdatedProjects,
+	EntityTask:    loadUpdatedTasks,
+	EntityComment: loadUpdatedComments,
+}
+
+// Option defines a function type that can modify the ChangeFeed configuration.
+type Option func(*ChangeFeed)
+
+// WithEntities restricts the entities followed by the feed. By default, it
+// follows every entity of Entities.
+func WithEntities(entities ...Entity) Option {
+	return func(f *ChangeFeed) {
+		f.entities = entities
+	}
+}
+
+// WithProject restricts the feed to the changes made in a project. By default,
+// it follows the whole installation.
+func WithProject(projectID int64) Option {
+	return func(f *ChangeFeed) {
+		f.projectID = projectID
+	}
+}
+
+// WithSkew sets how far back each poll looks before the high-water mark of an
+// entity. A change can be recorded with a time earlier than a change already
+// reported, because the clocks of the API servers drift apart or because it
+// was committed late, and it would be missed without looking back. The changes
+// already reported in that window are remembered by the cursor, so they are not
+// reported twice. The window also covers the drift between the clock of the
+// API and the one of the feed, which records when each poll started. Defaults
+// to 1 minute.
+func WithSkew(skew time.Duration) Option {
+	return func(f *ChangeFeed) {
+		f.skew = skew
+	}
+}
+
+// WithStart sets the moment the changes are followed from, for the entities
+// without a high-water mark in the cursor. By default, the first poll reports
+// every item, as far back as the API keeps the activity log.
+func WithStart(start time.Time) Option {
+	return func(f *ChangeFeed) {
+		f.start = start
+	}
+}
+
+// WithClock sets the function returning the current time, recorded in the
+// cursor when a poll starts. Defaults to time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(f *ChangeFeed) {
+		f.now = now
+	}
+}
+
+// WithCursorStore sets the store the cursor is loaded from before the first
+// poll, and saved to after every poll. By default, the cursor is only kept in
+// memory, and can be retrieved with ChangeFeed.Cursor.
+func WithCursorStore(store CursorStore) Option {
+	return func(f *ChangeFeed) {
+		f.store = store
+	}
+}
+
+// WithPageSize sets the number of items loaded per page. Defaults to 100.
+func WithPageSize(pageSize int64) Option {
+	return func(f *ChangeFeed) {
+		f.pageSize = pageSize
+	}
+}
+
+// ChangeFeed reports the changes made in an installation since the previous
+// poll. It is not safe for concurrent use.
+type ChangeFeed struct {
+	engine    *twapi.Engine
+	entities  []Entity
+	projectID int64
+	skew      time.Duration
+	start     time.Time
+	store     CursorStore
+	pageSize  int64
+	now       func() time.Time
+
+	cursor Cursor
+	loaded bool
+}
+
+// NewChangeFeed creates a feed of the changes made in the installation the
+// engine sends requests to.
+func NewChangeFeed(engine *twapi.Engine, opts ...Option) *ChangeFeed {
+	f := &ChangeFeed{
+		engine:   engine,
+		entities: Entities,
+		skew:     defaultSkew,
+		pageSize: 100,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Cursor returns a copy of the current position of the feed.
+func (f *ChangeFeed) Cursor() Cursor {
+	return f.cursor.clone()
+}
+
+// SetCursor moves the feed to the position, such as one previously returned by
+// Cursor. It replaces the cursor loaded from the store.
+func (f *ChangeFeed) SetCursor(cursor Cursor) {
+	f.cursor = cursor.clone()
+	f.loaded = true
+}
+
+// Changes polls the API once, returning the changes made since the cursor in
+// chronological order. Changes at the same time are ordered by entity and
+// identifier, so the order is stable.
+//
+// The cursor advances as each change is returned, and past the start of the
+// poll once every change was returned, so the next poll only loads the changes
+// made since, even for the entities without any. It is saved to the cursor
+// store once the iteration ends, even when it is interrupted. Every change is
+// loaded before the first one is returned, so a feed polled rarely should
+// restrict its entities or project.
+func (f *ChangeFeed) Changes(ctx context.Context) iter.Seq2[Change, error] {
+	return func(yield func(Change, error) bool) {
+		if err := f.loadCursor(ctx); err != nil {
+			yield(Change{}, err)
+			return
+		}
+		polledAt := f.now()
+		changes, err := f.poll(ctx)
+		if err != nil {
+			yield(Change{}, err)
+			return
+		}
+
+		stopped := false
+		for _, change := range changes {
+			f.cursor.advance(change, f.skew, f.start)
+			if !yield(change, nil) {
+				stopped = true
+				break
+			}
+		}
+		if !stopped {
+			f.cursor.polled(f.entities, polledAt, f.start)
+		}
+		if f.store == nil {
+			return
+		}
+		if err := f.store.Save(ctx, &f.cursor); err != nil && !stopped {
+			yield(Change{}, err)
+		}
+	}
+}
+
+// loadCursor loads the cursor from the store, before the first poll.
+func (f *ChangeFeed) loadCursor(ctx context.Context) error {
+	if f.loaded || f.store == nil {
+		return nil
+	}
+	cursor, err := f.store.Load(ctx)
+	if err != nil {
+		return err
+	}
+	if cursor != nil {
+		f.cursor = cursor.clone()
+	}
+	f.loaded = true
+	return nil
+}
+
+// poll loads the changes not reported yet, in order.
+func (f *ChangeFeed) poll(ctx context.Context) ([]Change, error) {
+	changes, err := f.loadActivities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, entity := range f.entities {
+		loader, ok := updatedLoaders[entity]
+		if !ok {
+			continue
+		}
+		updated, err := loader(ctx, f, f.since(entity))
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, updated...)
+	}
+
+	changes = slices.DeleteFunc(changes, func(change Change) bool {
+		if !slices.Contains(f.entities, change.Entity) {
+			return true
+		}
+		if _, ok := f.cursor.Entities[change.Entity]; !ok {
+			// the activity log is loaded from the earliest entity, which can be
+			// before the start of the others
+			return change.At.Before(f.start)
+		}
+		return f.cursor.reported(change, f.skew)
+	})
+	slices.SortFunc(changes, compareChanges)
+	return dedupe(changes), nil
+}
+
+// since returns the moment the changes of the entity are loaded from, or the
+// zero time to load all of them.
+func (f *ChangeFeed) since(entity Entity) time.Time {
+	watermark := f.cursor.Entities[entity]
+	mark := watermark.At
+	if watermark.PolledAt.After(mark) {
+		mark = watermark.PolledAt
+	}
+	if mark.IsZero() {
+		return f.start
+	}
+	return mark.Add(-f.skew)
+}
+
+// loadActivities loads the changes recorded in the activity log, from the
+// earliest moment any followed entity is loaded from.
+func (f *ChangeFeed) loadActivities(ctx context.Context) ([]Change, error) {
+	var itemTypes []projects.LogItemType
+	var since time.Time
+	for i, entity := range f.entities {
+		for itemType, itemEntity := range activityEntities {
+			if itemEntity == entity {
+				itemTypes = append(itemTypes, itemType)
+			}
+		}
+		if entitySince := f.since(entity); i == 0 || entitySince.Before(since) {
+			since = entitySince
+		}
+	}
+	if len(itemTypes) == 0 {
+		return nil, nil
+	}
+	slices.Sort(itemTypes)
+
+	req := projects.NewActivityListRequest()
+	req.Path.ProjectID = f.projectID
+	req.Filters.StartDate = since
+	req.Filters.LogItemTypes = itemTypes
+	req.Filters.OrderBy = projects.ActivityOrderByDate
+	req.Filters.OrderMode = twapi.OrderModeAscending
+	req.Filters.PageSize = f.pageSize
+
+	var changes []Change
+	items := twapi.AllItems(ctx, f.engine, req, func(r *projects.ActivityListResponse) []projects.Activity {
+		return r.Activities
+	})
+	for activity, err := range items {
+		if err != nil {
+			return nil, err
+		}
+		var itemType projects.LogItemType
+		if err := itemType.UnmarshalText([]byte(activity.Item.Type)); err != nil {
+			continue
+		}
+		entity, ok := activityEntities[itemType]
+		if !ok {
+			continue
+		}
+		action, ok := activityActions[activity.Action]
+		if !ok {
+			continue
+		}
+		changes = append(changes, Change{
+			Entity: entity,
+			ID:     activity.Item.ID,
+			Action: action,
+			At:     activity.At,
+		})
+	}
+	return changes, nil
+}
+
+// loadUpdatedProjects loads the projects updated after a moment.
+func loadUpdatedProjects(ctx context.Context, f *ChangeFeed, since time.Time) ([]Change, error) {
+	req := projects.NewProjectListRequest()
+	if !since.IsZero() {
+		req.Filters.UpdatedAfter = &since
+	}
+	if f.projectID > 0 {
+		req.Filters.ProjectIDs = []int64{f.projectID}
+	}
+	req.Filters.PageSize = f.pageSize
+
+	var changes []Change
+	items := twapi.AllItems(ctx, f.engine, req, func(r *projects.ProjectListResponse) []projects.Project {
+		return r.Projects
+	})
+	for project, err := range items {
+		if err != nil {
+			return nil, err
+		}
+		if project.UpdatedAt == nil {
+			continue
+		}
+		changes = append(changes, updateChange(EntityProject, project.ID, project.CreatedAt, *project.UpdatedAt))
+	}
+	return changes, nil
+}
+
+// loadUpdatedTasks loads the tasks updated after a moment, including the
+// completed ones.
+func loadUpdatedTasks(ctx context.Context, f *ChangeFeed, since time.Time) ([]Change, error) {
+	req := projects.NewTaskListRequest()
+	req.Path.ProjectID = f.projectID
+	if !since.IsZero() {
+		req.Filters.UpdatedAfter = &since
+	}
+	req.Filters.IncludeCompletedTasks = new(true)
+	req.Filters.IncludeTasksFromCompletedTasklists = new(true)
+	req.Filters.OrderBy = projects.TaskOrderByUpdatedAt
+	req.Filters.OrderMode = twapi.OrderModeAscending
+	req.Filters.PageSize = f.pageSize
+
+	var changes []Change
+	items := twapi.AllItems(ctx, f.engine, req, func(r *projects.TaskListResponse) []projects.Task {
+		return r.Tasks
+	})
+	for task, err := range items {
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, updateChange(EntityTask, task.ID, task.CreatedAt, task.UpdatedAt))
+	}
+	return changes, nil
+}
+
+// loadUpdatedComments loads the comments posted, edited or deleted after a
+// moment. The comments list cannot be restricted to a project, so the
+// comments of other projects are skipped.
+func loadUpdatedComments(ctx context.Context, f *ChangeFeed, since time.Time) ([]Change, error) {
+	req := projects.NewCommentListRequest()
+	req.Filters.UpdatedAfter = since
+	req.Filters.PageSize = f.pageSize
+
+	var changes []Change
+	items := twapi.AllItems(ctx, f.engine, req, func(r *projects.CommentListResponse) []projects.Comment {
+		return r.Comments
+	})
+	for comment, err := range items {
+		if err != nil {
+			return nil, err
+		}
+		if f.projectID > 0 && comment.Project.ID != f.projectID {
+			continue
+		}
+		change := Change{Entity: EntityComment, ID: comment.ID}
+		switch {
+		case comment.Deleted && comment.DeletedAt != nil:
+			change.Action, change.At = ActionDeleted, *comment.DeletedAt
+		case comment.EditedAt != nil:
+			change.Action, change.At = ActionUpdated, *comment.EditedAt
+		case comment.PostedAt != nil:
+			change.Action, change.At = ActionCreated, *comment.PostedAt
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// updateChange returns the change of an item loaded for its update time, which
+// is its creation when it was not updated since.
+func updateChange(entity Entity, id int64, createdAt *time.Time, updatedAt time.Time) Change {
+	action := ActionUpdated
+	if createdAt != nil && !updatedAt.After(*createdAt) {
+		action = ActionCreated
+	}
+	return Change{Entity: entity, ID: id, Action: action, At: updatedAt}
+}
+
+// compareChanges orders the changes by time, entity, identifier and action.
+func compareChanges(a, b Change) int {
+	return cmp.Or(
+		a.At.Compare(b.At),
+		cmp.Compare(a.Entity, b.Entity),
+		cmp.Compare(a.ID, b.ID),
+		cmp.Compare(a.Action, b.Action),
+	)
+}
+
+// sameChange reports whether two changes are the same change, reported by
+// both the activity log and an updatedAfter list. The two record the time of a
+// change with a different precision, and the lists only know an item was
+// updated, so changes to the same item within the same second are one change
+// unless their actions differ otherwise.
+func sameChange(a, b Change) bool {
+	return a.Entity == b.Entity && a.ID == b.ID &&
+		a.At.Truncate(time.Second).Equal(b.At.Truncate(time.Second)) &&
+		(a.Action == b.Action || a.Action == ActionUpdated || b.Action == ActionUpdated)
+}
+
+// dedupe drops the sorted changes reported twice, keeping the most specific
+// action. The same changes are within the same second, so each change is only
+// compared to the changes of its item kept in that second.
+func dedupe(changes []Change) []Change {
+	type item struct {
+		entity Entity
+		id     int64
+	}
+	var result []Change
+	var second time.Time
+	kept := make(map[item][]int)
+	for _, change := range changes {
+		if at := change.At.Truncate(time.Second); !at.Equal(second) {
+			second = at
+			clear(kept)
+		}
+		key := item{entity: change.Entity, id: change.ID}
+		i := slices.IndexFunc(kept[key], func(i int) bool {
+			return sameChange(result[i], change)
+		})
+		if i < 0 {
+			kept[key] = append(kept[key], len(result))
+			result = append(result, change)
+			continue
+		}
+		if j := kept[key][i]; result[j].Action == ActionUpdated {
+			result[j].Action = change.Action
+		}
+	}
+	return result
+}
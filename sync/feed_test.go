@@ -0,0 +1,182 @@
+package sync_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"slices"
+	gosync "sync"
+	"testing"
+	"time"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/projects"
+	"github.com/teamwork/twapi-go-sdk/sync"
+	"github.com/teamwork/twapi-go-sdk/twapitest"
+)
+
+// base is the time the tests happen at.
+var base = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+// clock returns the time of the tests, a little after the latest change.
+func clock() time.Time {
+	return base.Add(10 * time.Second)
+}
+
+func addActivity(server *twapitest.Server, itemType projects.LogItemType, id int64, action projects.Action, at time.Time) {
+	server.AddActivity(projects.Activity{
+		Action: action,
+		At:     at,
+		Item:   twapi.Relationship{ID: id, Type: string(itemType)},
+	})
+}
+
+func addTask(server *twapitest.Server, id int64, createdAt, updatedAt time.Time) {
+	server.AddTask(projects.Task{ID: id, Name: "Task", CreatedAt: &createdAt, UpdatedAt: updatedAt})
+}
+
+// collect polls the feed once.
+func collect(t *testing.T, feed *sync.ChangeFeed) []sync.Change {
+	t.Helper()
+
+	var changes []sync.Change
+	for change, err := range feed.Changes(t.Context()) {
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+func TestChangeFeed(t *testing.T) {
+	server := twapitest.NewServer(t)
+	engine := server.Engine()
+
+	addActivity(server, projects.LogItemTypeMilestone, 7, projects.LogTypeNew, base)
+	addActivity(server, projects.LogItemTypeTaskComment, 3, projects.LogTypeNew, base.Add(time.Second))
+	addActivity(server, projects.LogItemTypeTask, 1, projects.LogTypeCompleted, base.Add(2*time.Second))
+	addActivity(server, projects.LogItemTypeTask, 1, projects.LogTypeViewed, base.Add(3*time.Second))
+	// the task list reports the completion too, with a different precision
+	addTask(server, 1, base.Add(-time.Hour), base.Add(2*time.Second+300*time.Millisecond))
+	addTask(server, 2, base.Add(4*time.Second), base.Add(4*time.Second))
+
+	store := sync.NewFileCursorStore(filepath.Join(t.TempDir(), "cursor.json"))
+	feed := sync.NewChangeFeed(engine, sync.WithCursorStore(store), sync.WithClock(clock))
+
+	want := []sync.Change{
+		{Entity: sync.EntityMilestone, ID: 7, Action: sync.ActionCreated, At: base},
+		{Entity: sync.EntityComment, ID: 3, Action: sync.ActionCreated, At: base.Add(time.Second)},
+		{Entity: sync.EntityTask, ID: 1, Action: sync.ActionCompleted, At: base.Add(2 * time.Second)},
+		{Entity: sync.EntityTask, ID: 2, Action: sync.ActionCreated, At: base.Add(4 * time.Second)},
+	}
+	if changes := collect(t, feed); !slices.EqualFunc(changes, want, equalChange) {
+		t.Fatalf("expected changes %v but got %v", want, changes)
+	}
+	if changes := collect(t, feed); len(changes) != 0 {
+		t.Fatalf("expected no change on the second poll but got %v", changes)
+	}
+
+	// a change at the same time as the latest one, and a change recorded late
+	// with an earlier time, are both within the skew window
+	addTask(server, 3, base.Add(-time.Hour), base.Add(4*time.Second))
+	addActivity(server, projects.LogItemTypeMilestone, 7, projects.LogTypeEdited, base.Add(-30*time.Second))
+	addActivity(server, projects.LogItemTypeMilestone, 8, projects.LogTypeNew, base.Add(-2*time.Minute))
+
+	// a new feed resumes from the stored cursor
+	feed = sync.NewChangeFeed(engine, sync.WithCursorStore(store), sync.WithClock(clock))
+	want = []sync.Change{
+		{Entity: sync.EntityMilestone, ID: 7, Action: sync.ActionUpdated, At: base.Add(-30 * time.Second)},
+		{Entity: sync.EntityTask, ID: 3, Action: sync.ActionUpdated, At: base.Add(4 * time.Second)},
+	}
+	if changes := collect(t, feed); !slices.EqualFunc(changes, want, equalChange) {
+		t.Fatalf("expected changes %v but got %v", want, changes)
+	}
+}
+
+func TestChangeFeedWithoutChanges(t *testing.T) {
+	server := twapitest.NewServer(t)
+
+	var mutex gosync.Mutex
+	var queries []string
+	engine := server.Engine(twapi.WithMiddleware(func(next twapi.HTTPClient) twapi.HTTPClient {
+		return twapi.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			mutex.Lock()
+			queries = append(queries, req.URL.Path+" "+req.URL.Query().Get("startDate")+req.URL.Query().Get("updatedAfter"))
+			mutex.Unlock()
+			return next.Do(req)
+		})
+	}))
+
+	feed := sync.NewChangeFeed(engine, sync.WithEntities(sync.EntityNotebook, sync.EntityTask), sync.WithClock(clock))
+	if changes := collect(t, feed); len(changes) != 0 {
+		t.Fatalf("expected no change but got %v", changes)
+	}
+	queries = nil
+
+	// the second poll only loads what happened since the first one, although
+	// there was nothing to report
+	if changes := collect(t, feed); len(changes) != 0 {
+		t.Fatalf("expected no change but got %v", changes)
+	}
+	since := clock().Add(-time.Minute).Format(time.RFC3339)
+	want := []string{
+		"/projects/api/v3/latestactivity.json " + since,
+		"/projects/api/v3/tasks.json " + since,
+	}
+	if !slices.Equal(queries, want) {
+		t.Errorf("expected requests %q but got %q", want, queries)
+	}
+}
+
+func TestChangeFeedInterrupted(t *testing.T) {
+	server := twapitest.NewServer(t)
+	engine := server.Engine()
+	for id := range int64(3) {
+		addActivity(server, projects.LogItemTypeTasklist, id+1, projects.LogTypeNew, base.Add(time.Duration(id)*time.Second))
+	}
+
+	feed := sync.NewChangeFeed(engine,
+		sync.WithEntities(sync.EntityTasklist),
+		sync.WithStart(base.Add(time.Second)),
+		sync.WithClock(clock),
+	)
+	for change, err := range feed.Changes(t.Context()) {
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if change.ID != 2 {
+			t.Fatalf("expected the changes before the start to be skipped but got %v", change)
+		}
+		break
+	}
+
+	cursor := feed.Cursor()
+	if at := cursor.Entities[sync.EntityTasklist].At; !at.Equal(base.Add(time.Second)) {
+		t.Errorf("expected the cursor to stop at the change returned but got %s", at)
+	}
+	if polledAt := cursor.Entities[sync.EntityTasklist].PolledAt; !polledAt.IsZero() {
+		t.Errorf("expected the interrupted poll not to be recorded but got %s", polledAt)
+	}
+
+	// the cursor survives encoding, as it would be stored
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		t.Fatalf("failed to encode cursor: %s", err)
+	}
+	var decoded sync.Cursor
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to decode cursor: %s", err)
+	}
+	feed = sync.NewChangeFeed(engine, sync.WithEntities(sync.EntityTasklist), sync.WithClock(clock))
+	feed.SetCursor(decoded)
+
+	want := []sync.Change{{Entity: sync.EntityTasklist, ID: 3, Action: sync.ActionCreated, At: base.Add(2 * time.Second)}}
+	if changes := collect(t, feed); !slices.EqualFunc(changes, want, equalChange) {
+		t.Errorf("expected changes %v but got %v", want, changes)
+	}
+}
+
+func equalChange(a, b sync.Change) bool {
+	return a.Entity == b.Entity && a.ID == b.ID && a.Action == b.Action && a.At.Equal(b.At)
+}
@@ -0,0 +1,205 @@
+package twapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// operationKey is the context key of the API operation name.
+type operationKey struct{}
+
+// WithOperationContext attaches the name of the API operation being executed
+// to the context. Execute and ExecuteRaw do it automatically, naming the
+// operation after the requester type, such as "projects.TaskList" for
+// projects.TaskListRequest, unless the context already carries a name.
+func WithOperationContext(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// OperationFromContext returns the name of the API operation attached to the
+// context, so middlewares can tell which call a request belongs to.
+func OperationFromContext(ctx context.Context) (string, bool) {
+	operation, ok := ctx.Value(operationKey{}).(string)
+	return operation, ok && operation != ""
+}
+
+// operationName derives the operation name from the requester type.
+func operationName(requester any) string {
+	rt := reflect.TypeOf(requester)
+	if rt == nil {
+		return ""
+	}
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt.Name() == "" {
+		return ""
+	}
+	return strings.TrimSuffix(rt.String(), "Request")
+}
+
+// Tracer starts the spans recorded by TracingMiddleware. It is a small subset
+// of the OpenTelemetry tracing API, so the SDK does not depend on any tracing
+// library, and an adapter for one is only a few lines long.
+type Tracer interface {
+	// Start creates a span with the given name, returning a context that
+	// carries it.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is an operation being traced.
+type Span interface {
+	// SetAttributes adds the attributes to the span.
+	SetAttributes(attrs ...slog.Attr)
+
+	// SetError marks the span as failed with the given error.
+	SetError(err error)
+
+	// End completes the span.
+	End()
+}
+
+// Histogram records the distribution of a value, such as a latency.
+type Histogram interface {
+	Record(ctx context.Context, value float64, attrs ...slog.Attr)
+}
+
+// Counter records an increasing count, such as the number of errors.
+type Counter interface {
+	Add(ctx context.Context, value int64, attrs ...slog.Attr)
+}
+
+// Attribute keys set by TracingMiddleware and MetricsMiddleware. They follow
+// the OpenTelemetry semantic conventions where one exists.
+const (
+	AttributeOperation  = "twapi.operation"
+	AttributeMethod     = "http.request.method"
+	AttributeServer     = "server.address"
+	AttributePath       = "url.path"
+	AttributeStatusCode = "http.response.status_code"
+	AttributeErrorType  = "error.type"
+	AttributePage       = "twapi.page"
+	AttributePageSize   = "twapi.page_size"
+
+	// AttributeIDPrefix prefixes the entity identifiers found in the request
+	// path, keyed by the collection they belong to, such as
+	// "twapi.id.tasklists" for /projects/api/v3/tasklists/123/tasks.json.
+	AttributeIDPrefix = "twapi.id."
+)
+
+// TracingMiddleware returns a middleware that records a span for every request
+// sent by the Engine. Spans are named after the operation attached to the
+// request context, falling back to the HTTP method, and carry the status code,
+// the page and page size of list requests, and the entity identifiers in the
+// request path. Responses with a status code of 400 or above, and transport
+// errors, mark the span as failed.
+//
+// The middleware is called for every attempt, so a request retried by the
+// Engine produces a span per attempt. It is added with WithMiddleware:
+//
+//	engine := twapi.NewEngine(session, twapi.WithMiddleware(twapi.TracingMiddleware(tracer)))
+func TracingMiddleware(tracer Tracer) func(HTTPClient) HTTPClient {
+	return func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			name, ok := OperationFromContext(req.Context())
+			if !ok {
+				name = "HTTP " + req.Method
+			}
+
+			ctx, span := tracer.Start(req.Context(), name)
+			defer span.End()
+			span.SetAttributes(requestAttributes(req)...)
+
+			resp, err := next.Do(req.WithContext(ctx))
+			switch {
+			case err != nil:
+				span.SetAttributes(slog.String(AttributeErrorType, fmt.Sprintf("%T", err)))
+				span.SetError(err)
+			default:
+				span.SetAttributes(slog.Int(AttributeStatusCode, resp.StatusCode))
+				if resp.StatusCode >= http.StatusBadRequest {
+					span.SetAttributes(slog.String(AttributeErrorType, strconv.Itoa(resp.StatusCode)))
+					span.SetError(fmt.Errorf("unexpected status %s", resp.Status))
+				}
+			}
+			return resp, err
+		})
+	}
+}
+
+// MetricsMiddleware returns a middleware that records the latency of every
+// request sent by the Engine in seconds, and counts the requests that failed
+// with a transport error or a status code of 400 or above. Both instruments are
+// optional. Measurements carry the operation, method and status code, or the
+// error type for transport errors.
+//
+// The middleware is called for every attempt, so a request retried by the
+// Engine is measured once per attempt. It is added with WithMiddleware:
+//
+//	engine := twapi.NewEngine(session, twapi.WithMiddleware(twapi.MetricsMiddleware(latency, failures)))
+func MetricsMiddleware(latency Histogram, failures Counter) func(HTTPClient) HTTPClient {
+	return func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.Do(req)
+			elapsed := time.Since(start)
+
+			attrs := []slog.Attr{slog.String(AttributeMethod, req.Method)}
+			if operation, ok := OperationFromContext(req.Context()); ok {
+				attrs = append(attrs, slog.String(AttributeOperation, operation))
+			}
+			failed := err != nil
+			if err != nil {
+				attrs = append(attrs, slog.String(AttributeErrorType, fmt.Sprintf("%T", err)))
+			} else {
+				attrs = append(attrs, slog.Int(AttributeStatusCode, resp.StatusCode))
+				failed = resp.StatusCode >= http.StatusBadRequest
+			}
+
+			if latency != nil {
+				latency.Record(req.Context(), elapsed.Seconds(), attrs...)
+			}
+			if failures != nil && failed {
+				failures.Add(req.Context(), 1, attrs...)
+			}
+			return resp, err
+		})
+	}
+}
+
+// requestAttributes describes the request being traced.
+func requestAttributes(req *http.Request) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String(AttributeMethod, req.Method),
+		slog.String(AttributeServer, req.URL.Host),
+		slog.String(AttributePath, req.URL.Path),
+	}
+	if operation, ok := OperationFromContext(req.Context()); ok {
+		attrs = append(attrs, slog.String(AttributeOperation, operation))
+	}
+
+	query := req.URL.Query()
+	if page, err := strconv.ParseInt(query.Get("page"), 10, 64); err == nil {
+		attrs = append(attrs, slog.Int64(AttributePage, page))
+	}
+	if pageSize, err := strconv.ParseInt(query.Get("pageSize"), 10, 64); err == nil {
+		attrs = append(attrs, slog.Int64(AttributePageSize, pageSize))
+	}
+
+	// numeric segments identify an entity of the collection named before them
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	for i := 1; i < len(segments); i++ {
+		id, err := strconv.ParseInt(strings.TrimSuffix(segments[i], ".json"), 10, 64)
+		if err != nil {
+			continue
+		}
+		attrs = append(attrs, slog.Int64(AttributeIDPrefix+segments[i-1], id))
+	}
+	return attrs
+}
@@ -0,0 +1,196 @@
+package twapi_test
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"testing"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/projects"
+	"github.com/teamwork/twapi-go-sdk/twapitest"
+)
+
+// testSpan is a span recorded by testTracer.
+type testSpan struct {
+	name  string
+	attrs map[string]slog.Value
+	err   error
+	ended bool
+}
+
+func (s *testSpan) SetAttributes(attrs ...slog.Attr) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+
+func (s *testSpan) SetError(err error) {
+	s.err = err
+}
+
+func (s *testSpan) End() {
+	s.ended = true
+}
+
+// testTracer records the spans it starts.
+type testTracer struct {
+	mutex sync.Mutex
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, twapi.Span) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	span := &testSpan{name: name, attrs: make(map[string]slog.Value)}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+// testInstrument records the measurements of a histogram or counter.
+type testInstrument struct {
+	mutex  sync.Mutex
+	values []float64
+	attrs  []map[string]slog.Value
+}
+
+func (i *testInstrument) Record(_ context.Context, value float64, attrs ...slog.Attr) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	recorded := make(map[string]slog.Value)
+	for _, attr := range attrs {
+		recorded[attr.Key] = attr.Value
+	}
+	i.values = append(i.values, value)
+	i.attrs = append(i.attrs, recorded)
+}
+
+func (i *testInstrument) Add(ctx context.Context, value int64, attrs ...slog.Attr) {
+	i.Record(ctx, float64(value), attrs...)
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	server := twapitest.NewServer(t)
+	project := server.AddProject(projects.Project{Name: "Apollo"})
+	tasklist := server.AddTasklist(projects.Tasklist{Name: "Launch", Project: twapi.Relationship{ID: project.ID}})
+	server.AddTask(projects.Task{Name: "Countdown", Tasklist: twapi.Relationship{ID: tasklist.ID}})
+
+	tracer := &testTracer{}
+	engine := server.Engine(twapi.WithMiddleware(twapi.TracingMiddleware(tracer)))
+
+	listRequest := projects.NewTaskListRequest()
+	listRequest.Path.TasklistID = tasklist.ID
+	listRequest.Filters.PageSize = 10
+	if _, err := projects.TaskList(t.Context(), engine, listRequest); err != nil {
+		t.Fatalf("failed to list tasks: %v", err)
+	}
+	if _, err := projects.TaskGet(t.Context(), engine, projects.NewTaskGetRequest(999)); err == nil {
+		t.Fatal("expected error retrieving missing task")
+	}
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(tracer.spans))
+	}
+
+	tests := []struct {
+		name      string
+		span      *testSpan
+		wantName  string
+		wantAttrs map[string]any
+		wantError bool
+	}{{
+		name:     "list",
+		span:     tracer.spans[0],
+		wantName: "projects.TaskList",
+		wantAttrs: map[string]any{
+			twapi.AttributeOperation:              "projects.TaskList",
+			twapi.AttributeMethod:                 http.MethodGet,
+			twapi.AttributeStatusCode:             int64(http.StatusOK),
+			twapi.AttributePage:                   int64(1),
+			twapi.AttributePageSize:               int64(10),
+			twapi.AttributeIDPrefix + "tasklists": tasklist.ID,
+		},
+	}, {
+		name:     "missing entity",
+		span:     tracer.spans[1],
+		wantName: "projects.TaskGet",
+		wantAttrs: map[string]any{
+			twapi.AttributeStatusCode:         int64(http.StatusNotFound),
+			twapi.AttributeIDPrefix + "tasks": int64(999),
+		},
+		wantError: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.span.name != tt.wantName {
+				t.Errorf("expected span name %q, got %q", tt.wantName, tt.span.name)
+			}
+			for key, want := range tt.wantAttrs {
+				if got, ok := tt.span.attrs[key]; !ok || got.Any() != want {
+					t.Errorf("expected attribute %s to be %v, got %v", key, want, got)
+				}
+			}
+			if (tt.span.err != nil) != tt.wantError {
+				t.Errorf("expected span error %t, got %v", tt.wantError, tt.span.err)
+			}
+			if !tt.span.ended {
+				t.Error("expected span to be ended")
+			}
+		})
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	server := twapitest.NewServer(t)
+	project := server.AddProject(projects.Project{Name: "Apollo"})
+
+	latency, failures := &testInstrument{}, &testInstrument{}
+	engine := server.Engine(twapi.WithMiddleware(twapi.MetricsMiddleware(latency, failures)))
+
+	if _, err := projects.ProjectGet(t.Context(), engine, projects.NewProjectGetRequest(project.ID)); err != nil {
+		t.Fatalf("failed to retrieve project: %v", err)
+	}
+	if _, err := projects.ProjectGet(t.Context(), engine, projects.NewProjectGetRequest(999)); err == nil {
+		t.Fatal("expected error retrieving missing project")
+	}
+
+	if len(latency.values) != 2 {
+		t.Fatalf("expected 2 latency measurements, got %d", len(latency.values))
+	}
+	if len(failures.values) != 1 {
+		t.Fatalf("expected 1 failure, got %d", len(failures.values))
+	}
+	if got := failures.attrs[0][twapi.AttributeStatusCode].Int64(); got != http.StatusNotFound {
+		t.Errorf("expected failure with status %d, got %d", http.StatusNotFound, got)
+	}
+	if got := failures.attrs[0][twapi.AttributeOperation].String(); got != "projects.ProjectGet" {
+		t.Errorf("expected failure of projects.ProjectGet, got %q", got)
+	}
+}
+
+func TestOperationContext(t *testing.T) {
+	var operation string
+	engine := newTestEngine(t, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), twapi.WithMiddleware(func(next twapi.HTTPClient) twapi.HTTPClient {
+		return twapi.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			operation, _ = twapi.OperationFromContext(req.Context())
+			return next.Do(req)
+		})
+	}))
+
+	ctx := twapi.WithOperationContext(t.Context(), "custom.Operation")
+	resp, err := twapi.ExecuteRaw(ctx, engine, testRequest{path: "/"})
+	if err != nil {
+		t.Fatalf("failed to execute request: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if operation != "custom.Operation" {
+		t.Errorf("expected operation from context to be kept, got %q", operation)
+	}
+}
@@ -0,0 +1,103 @@
+package twapitest
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/teamwork/twapi-go-sdk/projects"
+)
+
+// AddActivity adds the activity to the log, assigning an identifier when it has
+// none, and returns the stored activity. The changes made through the API are
+// not recorded in the log, so tests add the activities they expect.
+func (s *Server) AddActivity(activity projects.Activity) projects.Activity {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if activity.ID == 0 {
+		activity.ID = s.newID()
+	}
+	if activity.At.IsZero() {
+		activity.At = s.timestamp()
+	}
+	if activity.User.ID == 0 {
+		activity.User = relationship(s.me, "users")
+	}
+	if activity.Project.ID != 0 && activity.Project.Type == "" {
+		activity.Project.Type = "projects"
+	}
+	s.activities[activity.ID] = activity
+	return activity
+}
+
+func (s *Server) registerActivities(mux *http.ServeMux) {
+	mux.HandleFunc("GET /projects/api/v3/latestactivity.json", s.listActivities)
+	mux.HandleFunc("GET /projects/api/v3/projects/{projectId}/latestactivity.json", s.listActivities)
+}
+
+// listActivities handles the listing of the activity log, filtered by date and
+// item type. Like the API, the latest activities come first unless the
+// ascending order is requested.
+func (s *Server) listActivities(w http.ResponseWriter, r *http.Request) {
+	list, ok := listQueryOf(w, r)
+	if !ok {
+		return
+	}
+	if r.PathValue("projectId") != "" {
+		project, ok := findByPathValue(w, r, "projectId", s.projects)
+		if !ok {
+			return
+		}
+		list.projectIDs = []int64{project.ID}
+	}
+
+	query := r.URL.Query()
+	var startDate, endDate time.Time
+	var err error
+	if value := query.Get("startDate"); value != "" {
+		if startDate, err = time.Parse(time.RFC3339, value); err != nil {
+			writeError(w, http.StatusBadRequest, errInvalidParameter("startDate").Error())
+			return
+		}
+	}
+	if value := query.Get("endDate"); value != "" {
+		if endDate, err = time.Parse(time.RFC3339, value); err != nil {
+			writeError(w, http.StatusBadRequest, errInvalidParameter("endDate").Error())
+			return
+		}
+	}
+	var itemTypes []projects.LogItemType
+	if value := query.Get("activityTypes"); value != "" {
+		for part := range strings.SplitSeq(value, ",") {
+			var itemType projects.LogItemType
+			if err := itemType.UnmarshalText([]byte(part)); err != nil {
+				writeError(w, http.StatusBadRequest, errInvalidParameter("activityTypes").Error())
+				return
+			}
+			itemTypes = append(itemTypes, itemType)
+		}
+	}
+
+	var items []projects.Activity
+	for _, activity := range sortedValues(s.activities) {
+		var itemType projects.LogItemType
+		_ = itemType.UnmarshalText([]byte(activity.Item.Type))
+		switch {
+		case !startDate.IsZero() && activity.At.Before(startDate),
+			!endDate.IsZero() && activity.At.After(endDate),
+			len(itemTypes) > 0 && !slices.Contains(itemTypes, itemType),
+			!list.matchProject(activity.Project.ID):
+			continue
+		}
+		items = append(items, activity)
+	}
+	slices.SortStableFunc(items, func(a, b projects.Activity) int {
+		if query.Get("orderMode") == "asc" {
+			return a.At.Compare(b.At)
+		}
+		return b.At.Compare(a.At)
+	})
+	writeList(s, w, r, list, "activities", "activities", items)
+}
@@ -0,0 +1,364 @@
+package twapitest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/internal/redact"
+)
+
+// ErrInteractionNotFound is returned by the Replayer when the cassette has no
+// unused interaction matching the request.
+var ErrInteractionNotFound = errors.New("no recorded interaction matches the request")
+
+var (
+	_ twapi.HTTPClient = (*Recorder)(nil)
+	_ twapi.HTTPClient = (*Replayer)(nil)
+)
+
+// Cassette contains the HTTP interactions recorded by a Recorder, in the order
+// they happened. It is stored as indented JSON, so changes to a cassette can be
+// reviewed like any other test fixture.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a request sent to the API together with the response it got.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a request stored in a cassette. Its credentials are
+// redacted like in the request logs: the Authorization header keeps only its
+// scheme, the tw-auth cookie is removed, and so are the password and the
+// signature of pre-signed URLs.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   Body        `json:"body,omitzero"`
+}
+
+// RecordedResponse is a response stored in a cassette. Its credentials, such as
+// the tw-auth cookie, are redacted.
+type RecordedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       Body        `json:"body,omitzero"`
+}
+
+// Body is the body of a recorded request or response. It is stored as a string
+// when it is valid UTF-8, and base64 encoded otherwise.
+type Body []byte
+
+// MarshalJSON encodes the body as a JSON string, or as a base64 object for
+// binary contents.
+func (b Body) MarshalJSON() ([]byte, error) {
+	if utf8.Valid(b) {
+		return json.Marshal(string(b))
+	}
+	return json.Marshal(map[string]string{"base64": base64.StdEncoding.EncodeToString(b)})
+}
+
+// UnmarshalJSON decodes a body encoded by MarshalJSON.
+func (b *Body) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*b = Body(text)
+		return nil
+	}
+	var binary struct {
+		Base64 string `json:"base64"`
+	}
+	if err := json.Unmarshal(data, &binary); err != nil {
+		return err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(binary.Base64)
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// LoadCassette reads the cassette stored in path.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette %q: %w", path, err)
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to decode cassette %q: %w", path, err)
+	}
+	return &cassette, nil
+}
+
+// Save stores the cassette in path. Missing parent directories are created.
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette %q: %w", path, err)
+	}
+	return nil
+}
+
+// Recorder is an HTTP client that records the requests sent through it and the
+// responses received to a cassette, to be replayed later with a Replayer. It
+// can be set as the Engine client:
+//
+//	recorder := twapitest.NewRecorder("testdata/tasks.json", nil)
+//	engine := twapi.NewEngine(session, twapi.WithHTTPClient(recorder))
+//
+// or wrap the Engine client as a middleware, keeping any configured client:
+//
+//	engine := twapi.NewEngine(session, twapi.WithMiddleware(recorder.Middleware))
+//
+// The interactions are kept in memory until Save is called. Credentials are
+// redacted before being stored. It is safe for concurrent use.
+type Recorder struct {
+	path     string
+	client   twapi.HTTPClient
+	mutex    sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder creates a new Recorder that stores the cassette in path, sending
+// the requests through client. When client is nil, http.DefaultClient is used.
+func NewRecorder(path string, client twapi.HTTPClient) *Recorder {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Recorder{path: path, client: client}
+}
+
+// Do sends the request through the Recorder client and records the exchange.
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	return r.record(r.client, req)
+}
+
+// Middleware records the exchanges of the next client. It is meant to be used
+// with twapi.WithMiddleware.
+func (r *Recorder) Middleware(next twapi.HTTPClient) twapi.HTTPClient {
+	return twapi.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		return r.record(next, req)
+	})
+}
+
+// Save stores the recorded interactions in the cassette file, replacing any
+// previous contents.
+func (r *Recorder) Save() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.cassette.Save(r.path)
+}
+
+// record sends the request through the client, and stores the exchange when a
+// response is received. Both bodies are buffered so they can be stored while
+// remaining readable by the client and the caller.
+func (r *Recorder) record(client twapi.HTTPClient, req *http.Request) (*http.Response, error) {
+	requestBody, err := bufferRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return resp, err
+	}
+
+	responseBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(responseBody))
+
+	interaction := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    redact.URL(req.URL),
+			Header: redact.Header(req.Header),
+			Body:   requestBody,
+		},
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     redact.Header(resp.Header),
+			Body:       responseBody,
+		},
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	return resp, nil
+}
+
+// Replayer is an HTTP client that answers requests with the responses stored
+// in a cassette, without any network access. A request matches a recorded one
+// when they have the same method, path, query and body. The order of the query
+// parameters, of the comma-separated values of include and fields[...], and of
+// the keys of JSON bodies is not relevant.
+//
+// Each interaction is replayed once, in the order it was recorded, so repeated
+// requests, such as retries, get the successive responses. It is safe for
+// concurrent use.
+type Replayer struct {
+	mutex        sync.Mutex
+	interactions []Interaction
+	keys         []string
+	used         []bool
+}
+
+// NewReplayer creates a new Replayer serving the cassette stored in path.
+func NewReplayer(path string) (*Replayer, error) {
+	cassette, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewCassetteReplayer(cassette)
+}
+
+// NewCassetteReplayer creates a new Replayer serving the cassette.
+func NewCassetteReplayer(cassette *Cassette) (*Replayer, error) {
+	r := &Replayer{
+		interactions: cassette.Interactions,
+		keys:         make([]string, len(cassette.Interactions)),
+		used:         make([]bool, len(cassette.Interactions)),
+	}
+	for i, interaction := range cassette.Interactions {
+		recorded, err := url.Parse(interaction.Request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse recorded url %q: %w", interaction.Request.URL, err)
+		}
+		r.keys[i] = matchKey(interaction.Request.Method, recorded, interaction.Request.Body)
+	}
+	return r, nil
+}
+
+// Do returns the recorded response of the first unused interaction matching the
+// request, or ErrInteractionNotFound when there is none.
+func (r *Replayer) Do(req *http.Request) (*http.Response, error) {
+	body, err := bufferRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	key := matchKey(req.Method, req.URL, body)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for i, interaction := range r.interactions {
+		if r.used[i] || r.keys[i] != key {
+			continue
+		}
+		r.used[i] = true
+
+		recorded := interaction.Response
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+			StatusCode:    recorded.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        recorded.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(recorded.Body)),
+			ContentLength: int64(len(recorded.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, req.Method, req.URL.RequestURI())
+}
+
+// Remaining returns the number of interactions that were not replayed yet.
+func (r *Replayer) Remaining() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var remaining int
+	for _, used := range r.used {
+		if !used {
+			remaining++
+		}
+	}
+	return remaining
+}
+
+// bufferRequestBody reads the request body, replacing it with a copy that can
+// be read again.
+func bufferRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
+
+// matchKey identifies the request for matching purposes. The scheme and host
+// are ignored, so a cassette can be replayed against any server, and so are the
+// credentials redacted from the query, which change on every request.
+func matchKey(method string, u *url.URL, body []byte) string {
+	return method + " " + u.Path + "?" + normalizeQuery(redact.Query(u.Query())) + "\n" + normalizeBody(body)
+}
+
+// normalizeQuery encodes the query sorted by key, also sorting the
+// comma-separated values of the include and fields[...] parameters.
+func normalizeQuery(query url.Values) string {
+	for key, values := range query {
+		if key != "include" && !strings.HasPrefix(key, "fields[") {
+			continue
+		}
+		for i, value := range values {
+			parts := strings.Split(value, ",")
+			slices.Sort(parts)
+			values[i] = strings.Join(parts, ",")
+		}
+	}
+	return query.Encode()
+}
+
+// normalizeBody re-encodes JSON bodies, so the order of the object keys and the
+// whitespace are not relevant. Other bodies are used as they are.
+func normalizeBody(body []byte) string {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return string(body)
+	}
+	normalized, err := json.Marshal(decoded)
+	if err != nil {
+		return string(body)
+	}
+	return string(normalized)
+}
@@ -0,0 +1,284 @@
+package twapitest_test
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/projects"
+	"github.com/teamwork/twapi-go-sdk/session"
+	"github.com/teamwork/twapi-go-sdk/twapitest"
+)
+
+func TestRecorderReplayer(t *testing.T) {
+	cassettePath := filepath.Join(t.TempDir(), "cassette.json")
+
+	server := twapitest.NewServer(t)
+	project := server.AddProject(projects.Project{Name: "Apollo"})
+
+	listRequest := projects.NewProjectListRequest()
+	listRequest.Filters.Include = []projects.ProjectRequestSideload{
+		projects.ProjectRequestSideloadUsers,
+		projects.ProjectRequestSideloadTags,
+	}
+	listRequest.Filters.Fields.Projects = []projects.ProjectField{projects.ProjectFieldName, projects.ProjectFieldID}
+
+	recorder := twapitest.NewRecorder(cassettePath, nil)
+	recording := server.Engine(twapi.WithMiddleware(recorder.Middleware))
+	if _, err := projects.ProjectList(t.Context(), recording, listRequest); err != nil {
+		t.Fatalf("failed to list projects: %v", err)
+	}
+	updateRequest := projects.NewProjectUpdateRequest(project.ID)
+	updateRequest.Name = new("Artemis")
+	if _, err := projects.ProjectUpdate(t.Context(), recording, updateRequest); err != nil {
+		t.Fatalf("failed to update project: %v", err)
+	}
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("failed to save cassette: %v", err)
+	}
+
+	data, err := os.ReadFile(cassettePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), twapitest.Token) {
+		t.Errorf("expected token to be redacted from cassette:\n%s", data)
+	}
+
+	replayer, err := twapitest.NewReplayer(cassettePath)
+	if err != nil {
+		t.Fatalf("failed to load cassette: %v", err)
+	}
+	// the replay runs against a different server, with other credentials and the
+	// sideloads and fields in another order
+	replaying := twapi.NewEngine(
+		session.NewBearerToken("other", "https://replay.example.com"),
+		twapi.WithHTTPClient(replayer),
+	)
+	listRequest.Filters.Include = []projects.ProjectRequestSideload{
+		projects.ProjectRequestSideloadTags,
+		projects.ProjectRequestSideloadUsers,
+	}
+	listRequest.Filters.Fields.Projects = []projects.ProjectField{projects.ProjectFieldID, projects.ProjectFieldName}
+
+	list, err := projects.ProjectList(t.Context(), replaying, listRequest)
+	if err != nil {
+		t.Fatalf("failed to replay project list: %v", err)
+	}
+	if len(list.Projects) != 1 || list.Projects[0].Name != "Apollo" {
+		t.Errorf("expected recorded project, got %+v", list.Projects)
+	}
+	if _, err := projects.ProjectUpdate(t.Context(), replaying, updateRequest); err != nil {
+		t.Fatalf("failed to replay project update: %v", err)
+	}
+	if remaining := replayer.Remaining(); remaining != 0 {
+		t.Errorf("expected every interaction to be replayed, %d remaining", remaining)
+	}
+
+	_, err = projects.ProjectUpdate(t.Context(), replaying, updateRequest)
+	if !errors.Is(err, twapitest.ErrInteractionNotFound) {
+		t.Errorf("expected replayed interaction not to be served again, got %v", err)
+	}
+}
+
+func TestRecorderRedaction(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   http.Header
+	}{{
+		name:   "bearer token",
+		header: http.Header{"Authorization": {"Bearer secret"}},
+		want:   http.Header{"Authorization": {"Bearer REDACTED"}},
+	}, {
+		name:   "basic credentials",
+		header: http.Header{"Authorization": {"Basic c2VjcmV0"}},
+		want:   http.Header{"Authorization": {"Basic REDACTED"}},
+	}, {
+		name:   "cookie",
+		header: http.Header{"Cookie": {"theme=dark; tw-auth=secret; lang=en"}},
+		want:   http.Header{"Cookie": {"theme=dark; tw-auth=REDACTED; lang=en"}},
+	}, {
+		name:   "unrelated headers",
+		header: http.Header{"Accept": {"application/json"}},
+		want:   http.Header{"Accept": {"application/json"}},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cassettePath := filepath.Join(t.TempDir(), "cassette.json")
+			recorder := twapitest.NewRecorder(cassettePath, twapi.HTTPClientFunc(
+				func(req *http.Request) (*http.Response, error) {
+					return &http.Response{
+						StatusCode: http.StatusOK,
+						Header:     http.Header{"Set-Cookie": {"tw-auth=secret; Path=/; HttpOnly"}},
+						Body:       http.NoBody,
+						Request:    req,
+					}, nil
+				},
+			))
+
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, "https://example.com/me.json", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header = tt.header
+			resp, err := recorder.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+			if err := recorder.Save(); err != nil {
+				t.Fatal(err)
+			}
+
+			cassette, err := twapitest.LoadCassette(cassettePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			interaction := cassette.Interactions[0]
+			for name := range tt.want {
+				if got, want := interaction.Request.Header.Get(name), tt.want.Get(name); got != want {
+					t.Errorf("expected %s header %q, got %q", name, want, got)
+				}
+			}
+			if got := interaction.Response.Header.Get("Set-Cookie"); got != "tw-auth=REDACTED; Path=/; HttpOnly" {
+				t.Errorf("expected redacted Set-Cookie header, got %q", got)
+			}
+			if got := req.Header.Get("Authorization"); got != tt.header.Get("Authorization") {
+				t.Errorf("expected sent request to keep its credentials, got %q", got)
+			}
+		})
+	}
+}
+
+func TestRecorderRedactsURL(t *testing.T) {
+	cassettePath := filepath.Join(t.TempDir(), "cassette.json")
+	recorder := twapitest.NewRecorder(cassettePath, twapi.HTTPClientFunc(
+		func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Authentication-Info": {"nextnonce=secret"}},
+				Body:       http.NoBody,
+				Request:    req,
+			}, nil
+		},
+	))
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodPut,
+		"https://bucket.s3.amazonaws.com/f.txt?X-Amz-Expires=300&X-Amz-Signature=secret", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Proxy-Authorization", "Basic secret")
+	req.Header.Set("X-Amz-Security-Token", "secret")
+	resp, err := recorder.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(cassettePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("expected no credential in the cassette, got %s", data)
+	}
+
+	// the request is replayed with another signature
+	replayer, err := twapitest.NewReplayer(cassettePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err = http.NewRequestWithContext(t.Context(), http.MethodPut,
+		"https://bucket.s3.amazonaws.com/f.txt?X-Amz-Expires=300&X-Amz-Signature=other", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = replayer.Do(req)
+	if err != nil {
+		t.Fatalf("expected request to match, got %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+func TestReplayerMatching(t *testing.T) {
+	cassette := &twapitest.Cassette{
+		Interactions: []twapitest.Interaction{{
+			Request: twapitest.RecordedRequest{
+				Method: http.MethodPost,
+				URL:    "https://example.com/projects.json?b=2&a=1",
+				Body:   twapitest.Body(`{"project":{"name":"Apollo","description":"moon"}}`),
+			},
+			Response: twapitest.RecordedResponse{
+				StatusCode: http.StatusCreated,
+				Body:       twapitest.Body(`{"id":"1"}`),
+			},
+		}},
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		body   string
+		want   bool
+	}{{
+		name:   "equivalent request",
+		method: http.MethodPost,
+		url:    "https://other.example.com/projects.json?a=1&b=2",
+		body:   `{ "project": { "description": "moon", "name": "Apollo" } }`,
+		want:   true,
+	}, {
+		name:   "different method",
+		method: http.MethodPut,
+		url:    "https://example.com/projects.json?a=1&b=2",
+		body:   `{"project":{"name":"Apollo","description":"moon"}}`,
+	}, {
+		name:   "different query",
+		method: http.MethodPost,
+		url:    "https://example.com/projects.json?a=1&b=3",
+		body:   `{"project":{"name":"Apollo","description":"moon"}}`,
+	}, {
+		name:   "different body",
+		method: http.MethodPost,
+		url:    "https://example.com/projects.json?a=1&b=2",
+		body:   `{"project":{"name":"Artemis","description":"moon"}}`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replayer, err := twapitest.NewCassetteReplayer(cassette)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req, err := http.NewRequestWithContext(t.Context(), tt.method, tt.url, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := replayer.Do(req)
+			if tt.want {
+				if err != nil {
+					t.Fatalf("expected request to match, got %v", err)
+				}
+				defer func() { _ = resp.Body.Close() }()
+				if resp.StatusCode != http.StatusCreated {
+					t.Errorf("expected status %d, got %d", http.StatusCreated, resp.StatusCode)
+				}
+				return
+			}
+			if !errors.Is(err, twapitest.ErrInteractionNotFound) {
+				t.Errorf("expected ErrInteractionNotFound, got %v", err)
+			}
+		})
+	}
+}
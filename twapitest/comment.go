@@ -0,0 +1,197 @@
+package twapitest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/teamwork/twapi-go-sdk/projects"
+)
+
+// AddComment adds the comment to the model, assigning an identifier when it has
+// none, and returns the stored comment.
+func (s *Server) AddComment(comment projects.Comment) projects.Comment {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if comment.ID == 0 {
+		comment.ID = s.newID()
+	}
+	if comment.PostedAt == nil {
+		comment.PostedAt = new(s.timestamp())
+	}
+	if comment.PostedBy == nil {
+		comment.PostedBy = new(s.me)
+	}
+	if comment.ContentType == "" {
+		comment.ContentType = "TEXT"
+	}
+	if comment.Project.Type == "" {
+		comment.Project.Type = "projects"
+	}
+	s.comments[comment.ID] = comment
+	return comment
+}
+
+// Comment returns the comment with the given identifier.
+func (s *Server) Comment(id int64) (projects.Comment, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	comment, ok := s.comments[id]
+	return comment, ok
+}
+
+func (s *Server) registerComments(mux *http.ServeMux) {
+	mux.HandleFunc("POST /tasks/{objectId}/comments.json", s.createComment("tasks"))
+	mux.HandleFunc("POST /milestones/{objectId}/comments.json", s.createComment("milestones"))
+	mux.HandleFunc("PUT /comments/{id}", s.updateComment)
+	mux.HandleFunc("DELETE /comments/{id}", s.deleteComment)
+	mux.HandleFunc("GET /projects/api/v3/comments/{id}", s.getComment)
+	mux.HandleFunc("GET /projects/api/v3/comments.json", s.listComments(""))
+	mux.HandleFunc("GET /projects/api/v3/tasks/{objectId}/comments.json", s.listComments("tasks"))
+	mux.HandleFunc("GET /projects/api/v3/milestones/{objectId}/comments.json", s.listComments("milestones"))
+}
+
+// createComment handles the creation of comments on the entities of the given
+// type. Only tasks and milestones are modelled.
+func (s *Server) createComment(objectType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		projectID, ok := s.commentProject(w, r, objectType)
+		if !ok {
+			return
+		}
+		// projects.CommentCreateRequest can't be decoded, as notify is an
+		// interface
+		var payload struct {
+			Comment struct {
+				Body        string  `json:"body"`
+				ContentType *string `json:"contentType"`
+			} `json:"comment"`
+		}
+		if !decodeBody(w, r, &payload) {
+			return
+		}
+		if payload.Comment.Body == "" {
+			writeError(w, http.StatusUnprocessableEntity, "body is required")
+			return
+		}
+
+		objectID, _ := pathID(r, "objectId")
+		now, me := s.touch()
+		comment := projects.Comment{
+			ID:          s.newID(),
+			Body:        payload.Comment.Body,
+			HTMLBody:    payload.Comment.Body,
+			ContentType: "TEXT",
+			Object:      new(relationship(objectID, objectType)),
+			Project:     relationship(projectID, "projects"),
+			PostedBy:    me,
+			PostedAt:    now,
+		}
+		setIfPresent(&comment.ContentType, payload.Comment.ContentType)
+		s.comments[comment.ID] = comment
+
+		writeJSON(w, http.StatusCreated, map[string]any{"id": legacyID(comment.ID)})
+	}
+}
+
+func (s *Server) updateComment(w http.ResponseWriter, r *http.Request) {
+	comment, ok := findByPath(w, r, s.comments)
+	if !ok {
+		return
+	}
+	var payload struct {
+		Comment struct {
+			Body        string  `json:"body"`
+			ContentType *string `json:"content-type"`
+		} `json:"comment"`
+	}
+	if !decodeBody(w, r, &payload) {
+		return
+	}
+
+	comment.Body = payload.Comment.Body
+	comment.HTMLBody = payload.Comment.Body
+	setIfPresent(&comment.ContentType, payload.Comment.ContentType)
+	comment.EditedAt, comment.LastEditedBy = s.touch()
+	s.comments[comment.ID] = comment
+
+	writeLegacyOK(w)
+}
+
+func (s *Server) deleteComment(w http.ResponseWriter, r *http.Request) {
+	comment, ok := findByPath(w, r, s.comments)
+	if !ok {
+		return
+	}
+	delete(s.comments, comment.ID)
+	writeLegacyOK(w)
+}
+
+func (s *Server) getComment(w http.ResponseWriter, r *http.Request) {
+	comment, ok := findByPath(w, r, s.comments)
+	if !ok {
+		return
+	}
+	// the API returns a single comment under the plural key
+	s.writeItem(w, r, http.StatusOK, "comments", "comments", comment)
+}
+
+// listComments handles the listing of comments, restricted to the entity of
+// the given type when it is not empty.
+func (s *Server) listComments(objectType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, ok := listQueryOf(w, r)
+		if !ok {
+			return
+		}
+		var objectID int64
+		if objectType != "" {
+			if _, ok := s.commentProject(w, r, objectType); !ok {
+				return
+			}
+			objectID, _ = pathID(r, "objectId")
+		}
+
+		var items []projects.Comment
+		for _, comment := range sortedValues(s.comments) {
+			switch {
+			case objectID != 0 && (comment.Object == nil || comment.Object.Type != objectType ||
+				comment.Object.ID != objectID),
+				!list.matchProject(comment.Project.ID),
+				!list.matchSearch(comment.Body),
+				!list.matchUpdated(commentUpdatedAt(comment)):
+				continue
+			}
+			items = append(items, comment)
+		}
+		writeList(s, w, r, list, "comments", "comments", items)
+	}
+}
+
+// commentProject returns the project of the entity identified by the objectId
+// path segment, writing a 404 Not Found response when it does not exist. The
+// caller must hold the mutex.
+func (s *Server) commentProject(w http.ResponseWriter, r *http.Request, objectType string) (int64, bool) {
+	switch objectType {
+	case "tasks":
+		task, ok := findByPathValue(w, r, "objectId", s.tasks)
+		return s.tasklists[task.Tasklist.ID].Project.ID, ok
+	case "milestones":
+		milestone, ok := findByPathValue(w, r, "objectId", s.milestones)
+		return milestone.Project.ID, ok
+	}
+	writeError(w, http.StatusNotFound, "not found")
+	return 0, false
+}
+
+// commentUpdatedAt returns when the comment was last posted, edited or deleted.
+func commentUpdatedAt(comment projects.Comment) *time.Time {
+	var updatedAt *time.Time
+	for _, at := range []*time.Time{comment.PostedAt, comment.EditedAt, comment.DeletedAt} {
+		if at != nil && (updatedAt == nil || at.After(*updatedAt)) {
+			updatedAt = at
+		}
+	}
+	return updatedAt
+}
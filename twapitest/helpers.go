@@ -0,0 +1,91 @@
+package twapitest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+)
+
+// decodeBody decodes the JSON request body into payload, writing a 400 Bad
+// Request response when it is malformed.
+func decodeBody(w http.ResponseWriter, r *http.Request, payload any) bool {
+	if err := json.NewDecoder(r.Body).Decode(payload); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// findByPath returns the entity identified by the id path segment, writing a
+// 404 Not Found response when it does not exist. The caller must hold the
+// mutex.
+func findByPath[T any](w http.ResponseWriter, r *http.Request, items map[int64]T) (T, bool) {
+	return findByPathValue(w, r, "id", items)
+}
+
+// findByPathValue returns the entity identified by the named path segment,
+// writing a 404 Not Found response when it does not exist. The caller must hold
+// the mutex.
+func findByPathValue[T any](w http.ResponseWriter, r *http.Request, name string, items map[int64]T) (T, bool) {
+	id, ok := pathID(r, name)
+	if !ok {
+		var zero T
+		writeError(w, http.StatusBadRequest, "invalid identifier")
+		return zero, false
+	}
+	item, ok := items[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return item, false
+	}
+	return item, true
+}
+
+// listQueryOf parses the list parameters of the request, writing a 400 Bad
+// Request response when they are malformed.
+func listQueryOf(w http.ResponseWriter, r *http.Request) (listQuery, bool) {
+	list, err := parseListQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return list, false
+	}
+	return list, true
+}
+
+// setIfPresent replaces the target with the value of an optional update field.
+func setIfPresent[T any](target *T, value *T) {
+	if value != nil {
+		*target = *value
+	}
+}
+
+// touch returns the update timestamp and author of a change. The caller must
+// hold the mutex.
+func (s *Server) touch() (*time.Time, *int64) {
+	return new(s.timestamp()), new(s.me)
+}
+
+// legacyID formats an identifier the way the v1 API returns it.
+func legacyID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+// relationship creates a relationship to the entity of the given type.
+func relationship(id int64, relType string) twapi.Relationship {
+	return twapi.Relationship{ID: id, Type: relType}
+}
+
+// relationships creates relationships to the entities of the given type.
+func relationships(ids []int64, relType string) []twapi.Relationship {
+	if len(ids) == 0 {
+		return nil
+	}
+	result := make([]twapi.Relationship, len(ids))
+	for i, id := range ids {
+		result[i] = relationship(id, relType)
+	}
+	return result
+}
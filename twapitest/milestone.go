@@ -0,0 +1,172 @@
+package twapitest
+
+import (
+	"net/http"
+	"time"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/projects"
+)
+
+// AddMilestone adds the milestone to the model, assigning an identifier when it
+// has none, and returns the stored milestone.
+func (s *Server) AddMilestone(milestone projects.Milestone) projects.Milestone {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if milestone.ID == 0 {
+		milestone.ID = s.newID()
+	}
+	now := s.timestamp()
+	if milestone.CreatedAt == nil {
+		milestone.CreatedAt = &now
+	}
+	if milestone.UpdatedAt == nil {
+		milestone.UpdatedAt = &now
+	}
+	if milestone.Project.Type == "" {
+		milestone.Project.Type = "projects"
+	}
+	if milestone.Status == "" {
+		milestone.Status = "new"
+	}
+	s.milestones[milestone.ID] = milestone
+	return milestone
+}
+
+// Milestone returns the milestone with the given identifier.
+func (s *Server) Milestone(id int64) (projects.Milestone, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	milestone, ok := s.milestones[id]
+	return milestone, ok
+}
+
+func (s *Server) registerMilestones(mux *http.ServeMux) {
+	mux.HandleFunc("POST /projects/{projectId}/milestones.json", s.createMilestone)
+	mux.HandleFunc("PUT /milestones/{id}", s.updateMilestone)
+	mux.HandleFunc("DELETE /milestones/{id}", s.deleteMilestone)
+	mux.HandleFunc("GET /projects/api/v3/milestones/{id}", s.getMilestone)
+	mux.HandleFunc("GET /projects/api/v3/milestones.json", s.listMilestones)
+	mux.HandleFunc("GET /projects/api/v3/projects/{projectId}/milestones.json", s.listMilestones)
+}
+
+func (s *Server) createMilestone(w http.ResponseWriter, r *http.Request) {
+	project, ok := findByPathValue(w, r, "projectId", s.projects)
+	if !ok {
+		return
+	}
+	var payload struct {
+		Milestone projects.MilestoneCreateRequest `json:"milestone"`
+	}
+	if !decodeBody(w, r, &payload) {
+		return
+	}
+	create := payload.Milestone
+	if create.Name == "" {
+		writeError(w, http.StatusUnprocessableEntity, "title is required")
+		return
+	}
+
+	now, _ := s.touch()
+	milestone := projects.Milestone{
+		ID:                 s.newID(),
+		Name:               create.Name,
+		DueAt:              time.Time(create.DueAt),
+		Project:            relationship(project.ID, "projects"),
+		Tasklists:          relationships(create.TasklistIDs, "tasklists"),
+		Tags:               relationships(create.TagIDs, "tags"),
+		ResponsibleParties: responsibleParties(create.Assignees),
+		CreatedAt:          now,
+		UpdatedAt:          now,
+		Status:             "new",
+	}
+	setIfPresent(&milestone.Description, create.Description)
+	s.milestones[milestone.ID] = milestone
+
+	writeJSON(w, http.StatusCreated, map[string]any{"milestoneId": legacyID(milestone.ID)})
+}
+
+func (s *Server) updateMilestone(w http.ResponseWriter, r *http.Request) {
+	milestone, ok := findByPath(w, r, s.milestones)
+	if !ok {
+		return
+	}
+	var payload struct {
+		Milestone projects.MilestoneUpdateRequest `json:"milestone"`
+	}
+	if !decodeBody(w, r, &payload) {
+		return
+	}
+
+	update := payload.Milestone
+	setIfPresent(&milestone.Name, update.Name)
+	setIfPresent(&milestone.Description, update.Description)
+	if update.DueAt != nil {
+		milestone.DueAt = time.Time(*update.DueAt)
+	}
+	if update.TasklistIDs != nil {
+		milestone.Tasklists = relationships(update.TasklistIDs, "tasklists")
+	}
+	if update.TagIDs != nil {
+		milestone.Tags = relationships(update.TagIDs, "tags")
+	}
+	if update.Assignees != nil {
+		milestone.ResponsibleParties = responsibleParties(*update.Assignees)
+	}
+	milestone.UpdatedAt, _ = s.touch()
+	s.milestones[milestone.ID] = milestone
+
+	writeLegacyOK(w)
+}
+
+func (s *Server) deleteMilestone(w http.ResponseWriter, r *http.Request) {
+	milestone, ok := findByPath(w, r, s.milestones)
+	if !ok {
+		return
+	}
+	delete(s.milestones, milestone.ID)
+	writeLegacyOK(w)
+}
+
+func (s *Server) getMilestone(w http.ResponseWriter, r *http.Request) {
+	milestone, ok := findByPath(w, r, s.milestones)
+	if !ok {
+		return
+	}
+	s.writeItem(w, r, http.StatusOK, "milestone", "milestones", milestone)
+}
+
+func (s *Server) listMilestones(w http.ResponseWriter, r *http.Request) {
+	list, ok := listQueryOf(w, r)
+	if !ok {
+		return
+	}
+	if r.PathValue("projectId") != "" {
+		project, ok := findByPathValue(w, r, "projectId", s.projects)
+		if !ok {
+			return
+		}
+		list.projectIDs = []int64{project.ID}
+	}
+
+	var items []projects.Milestone
+	for _, milestone := range sortedValues(s.milestones) {
+		if list.matchSearch(milestone.Name) && list.matchProject(milestone.Project.ID) &&
+			list.matchUpdated(milestone.UpdatedAt) {
+			items = append(items, milestone)
+		}
+	}
+	writeList(s, w, r, list, "milestones", "milestones", items)
+}
+
+// responsibleParties converts the assignees of a legacy request into the
+// relationships of a milestone.
+func responsibleParties(groups projects.LegacyUserGroups) []twapi.Relationship {
+	var result []twapi.Relationship
+	result = append(result, relationships(groups.UserIDs, "users")...)
+	result = append(result, relationships(groups.CompanyIDs, "companies")...)
+	result = append(result, relationships(groups.TeamIDs, "teams")...)
+	return result
+}
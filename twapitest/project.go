@@ -0,0 +1,172 @@
+package twapitest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/teamwork/twapi-go-sdk/projects"
+)
+
+// AddProject adds the project to the model, assigning an identifier when it has
+// none, and returns the stored project.
+func (s *Server) AddProject(project projects.Project) projects.Project {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if project.ID == 0 {
+		project.ID = s.newID()
+	}
+	now := s.timestamp()
+	if project.CreatedAt == nil {
+		project.CreatedAt = &now
+	}
+	if project.UpdatedAt == nil {
+		project.UpdatedAt = &now
+	}
+	if project.Status == "" {
+		project.Status = projects.ProjectStatusActive
+	}
+	if project.Type == "" {
+		project.Type = "normal"
+	}
+	s.projects[project.ID] = project
+	return project
+}
+
+// Project returns the project with the given identifier.
+func (s *Server) Project(id int64) (projects.Project, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	project, ok := s.projects[id]
+	return project, ok
+}
+
+func (s *Server) registerProjects(mux *http.ServeMux) {
+	mux.HandleFunc("POST /projects.json", s.createProject)
+	mux.HandleFunc("PUT /projects/{id}", s.updateProject)
+	mux.HandleFunc("DELETE /projects/{id}", s.deleteProject)
+	mux.HandleFunc("GET /projects/api/v3/projects/{id}", s.getProject)
+	mux.HandleFunc("GET /projects/api/v3/projects.json", s.listProjects)
+}
+
+func (s *Server) createProject(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Project projects.ProjectCreateRequest `json:"project"`
+	}
+	if !decodeBody(w, r, &payload) {
+		return
+	}
+	if payload.Project.Name == "" {
+		writeError(w, http.StatusUnprocessableEntity, "name is required")
+		return
+	}
+
+	now, me := s.touch()
+	project := projects.Project{
+		ID:          s.newID(),
+		Name:        payload.Project.Name,
+		Description: payload.Project.Description,
+		Company:     relationship(payload.Project.CompanyID, "companies"),
+		Tags:        relationships(payload.Project.TagIDs, "tags"),
+		CreatedAt:   now,
+		CreatedBy:   me,
+		UpdatedAt:   now,
+		UpdatedBy:   me,
+		Status:      projects.ProjectStatusActive,
+		Type:        "normal",
+	}
+	project.StartAt = legacyDate(payload.Project.StartAt)
+	project.EndAt = legacyDate(payload.Project.EndAt)
+	if payload.Project.CategoryID != nil {
+		project.Category = new(relationship(*payload.Project.CategoryID, "projectCategories"))
+	}
+	if payload.Project.OwnerID != nil {
+		project.Owner = new(relationship(*payload.Project.OwnerID, "users"))
+	}
+	s.projects[project.ID] = project
+
+	writeJSON(w, http.StatusCreated, map[string]any{"id": legacyID(project.ID)})
+}
+
+func (s *Server) updateProject(w http.ResponseWriter, r *http.Request) {
+	project, ok := findByPath(w, r, s.projects)
+	if !ok {
+		return
+	}
+	var payload struct {
+		Project projects.ProjectUpdateRequest `json:"project"`
+	}
+	if !decodeBody(w, r, &payload) {
+		return
+	}
+
+	update := payload.Project
+	setIfPresent(&project.Name, update.Name)
+	if update.Description != nil {
+		project.Description = update.Description
+	}
+	if update.StartAt != nil {
+		project.StartAt = legacyDate(update.StartAt)
+	}
+	if update.EndAt != nil {
+		project.EndAt = legacyDate(update.EndAt)
+	}
+	if update.CategoryID != nil {
+		project.Category = new(relationship(*update.CategoryID, "projectCategories"))
+	}
+	if update.CompanyID != nil {
+		project.Company = relationship(*update.CompanyID, "companies")
+	}
+	if update.OwnerID != nil {
+		project.Owner = new(relationship(*update.OwnerID, "users"))
+	}
+	if update.TagIDs != nil {
+		project.Tags = relationships(update.TagIDs, "tags")
+	}
+	setIfPresent(&project.Status, update.Status)
+	project.UpdatedAt, project.UpdatedBy = s.touch()
+	s.projects[project.ID] = project
+
+	writeLegacyOK(w)
+}
+
+func (s *Server) deleteProject(w http.ResponseWriter, r *http.Request) {
+	project, ok := findByPath(w, r, s.projects)
+	if !ok {
+		return
+	}
+	delete(s.projects, project.ID)
+	writeLegacyOK(w)
+}
+
+func (s *Server) getProject(w http.ResponseWriter, r *http.Request) {
+	project, ok := findByPath(w, r, s.projects)
+	if !ok {
+		return
+	}
+	s.writeItem(w, r, http.StatusOK, "project", "projects", project)
+}
+
+func (s *Server) listProjects(w http.ResponseWriter, r *http.Request) {
+	list, ok := listQueryOf(w, r)
+	if !ok {
+		return
+	}
+
+	var items []projects.Project
+	for _, project := range sortedValues(s.projects) {
+		if list.matchSearch(project.Name) && list.matchProject(project.ID) && list.matchUpdated(project.UpdatedAt) {
+			items = append(items, project)
+		}
+	}
+	writeList(s, w, r, list, "projects", "projects", items)
+}
+
+// legacyDate converts a v1 date into the time of a v3 entity.
+func legacyDate(date *projects.LegacyDate) *time.Time {
+	if date == nil {
+		return nil
+	}
+	return new(time.Time(*date))
+}
@@ -0,0 +1,331 @@
+package twapitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// listQuery contains the list parameters shared by every entity.
+type listQuery struct {
+	page         int64
+	pageSize     int64
+	skipCounts   bool
+	searchTerm   string
+	projectIDs   []int64
+	updatedAfter time.Time
+}
+
+// parseListQuery extracts the list parameters from the request.
+func parseListQuery(r *http.Request) (listQuery, error) {
+	query := r.URL.Query()
+	list := listQuery{
+		page:       1,
+		pageSize:   defaultPageSize,
+		skipCounts: query.Get("skipCounts") == "true",
+		searchTerm: strings.ToLower(query.Get("searchTerm")),
+	}
+
+	var err error
+	if value := query.Get("page"); value != "" {
+		if list.page, err = strconv.ParseInt(value, 10, 64); err != nil || list.page < 1 {
+			return list, errInvalidParameter("page")
+		}
+	}
+	if value := query.Get("pageSize"); value != "" {
+		if list.pageSize, err = strconv.ParseInt(value, 10, 64); err != nil || list.pageSize < 1 {
+			return list, errInvalidParameter("pageSize")
+		}
+		list.pageSize = min(list.pageSize, maxPageSize)
+	}
+	if value := query.Get("updatedAfter"); value != "" {
+		if list.updatedAfter, err = time.Parse(time.RFC3339, value); err != nil {
+			return list, errInvalidParameter("updatedAfter")
+		}
+	}
+	if list.projectIDs, err = parseIDs(query.Get("projectIds")); err != nil {
+		return list, errInvalidParameter("projectIds")
+	}
+	return list, nil
+}
+
+// matchSearch reports whether any of the values contains the search term.
+func (l listQuery) matchSearch(values ...string) bool {
+	if l.searchTerm == "" {
+		return true
+	}
+	for _, value := range values {
+		if strings.Contains(strings.ToLower(value), l.searchTerm) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchProject reports whether the project is one of the requested ones.
+func (l listQuery) matchProject(projectID int64) bool {
+	return len(l.projectIDs) == 0 || slices.Contains(l.projectIDs, projectID)
+}
+
+// matchUpdated reports whether the entity was updated after the requested
+// time.
+func (l listQuery) matchUpdated(updatedAt *time.Time) bool {
+	return l.updatedAfter.IsZero() || (updatedAt != nil && updatedAt.After(l.updatedAfter))
+}
+
+// errInvalidParameter reports a malformed query parameter.
+func errInvalidParameter(name string) error {
+	return fmt.Errorf("invalid %s parameter", name)
+}
+
+// parseIDs parses a comma-separated list of identifiers.
+func parseIDs(value string) ([]int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	var ids []int64
+	for part := range strings.SplitSeq(value, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// pathID parses the identifier in the named path segment, which may carry the
+// .json extension.
+func pathID(r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(strings.TrimSuffix(r.PathValue(name), ".json"), 10, 64)
+	return id, err == nil && id > 0
+}
+
+// sortedValues returns the values of the map ordered by key.
+func sortedValues[T any](m map[int64]T) []T {
+	values := make([]T, 0, len(m))
+	for _, id := range slices.Sorted(maps.Keys(m)) {
+		values = append(values, m[id])
+	}
+	return values
+}
+
+// writeItem writes a single entity under key, applying the sparse fieldset of
+// fieldsKey and the requested sideloads.
+func (s *Server) writeItem(w http.ResponseWriter, r *http.Request, status int, key, fieldsKey string, item any) {
+	encoded, err := encode(item)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, status, map[string]any{
+		key:        sparse(encoded, fieldsFor(r, fieldsKey)),
+		"included": s.included(r, []map[string]any{encoded}),
+	})
+}
+
+// writeList writes a page of entities under key, together with the pagination
+// metadata, applying the sparse fieldset of fieldsKey and the requested
+// sideloads.
+func writeList[T any](
+	s *Server,
+	w http.ResponseWriter,
+	r *http.Request,
+	list listQuery,
+	key, fieldsKey string,
+	items []T,
+) {
+	total := int64(len(items))
+	start := min((list.page-1)*list.pageSize, total)
+	end := min(start+list.pageSize, total)
+	hasMore := end < total
+
+	// like the API, a skipped count is a lower bound derived from the page
+	count := total
+	if list.skipCounts {
+		count = end
+		if hasMore {
+			count++
+		}
+	}
+
+	fields := fieldsFor(r, fieldsKey)
+	encodedItems := make([]map[string]any, 0, end-start)
+	sparseItems := make([]map[string]any, 0, end-start)
+	for _, item := range items[start:end] {
+		encoded, err := encode(item)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		encodedItems = append(encodedItems, encoded)
+		sparseItems = append(sparseItems, sparse(encoded, fields))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		key: sparseItems,
+		"meta": map[string]any{
+			"page": map[string]any{
+				"pageOffset": list.page - 1,
+				"pageSize":   list.pageSize,
+				"count":      count,
+				"hasMore":    hasMore,
+			},
+		},
+		"included": s.included(r, encodedItems),
+	})
+}
+
+// encode converts the entity into its JSON object representation.
+func encode(item any) (map[string]any, error) {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	var encoded map[string]any
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, err
+	}
+	return encoded, nil
+}
+
+// fieldsFor returns the attributes requested with fields[key], or nil when the
+// request does not restrict them.
+func fieldsFor(r *http.Request, key string) []string {
+	value := r.URL.Query().Get("fields[" + key + "]")
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// sparse keeps the identifier and the requested attributes of the encoded
+// entity. Every attribute is kept when fields is empty.
+func sparse(encoded map[string]any, fields []string) map[string]any {
+	if len(fields) == 0 {
+		return encoded
+	}
+	result := map[string]any{"id": encoded["id"]}
+	for _, field := range fields {
+		if value, ok := encoded[field]; ok {
+			result[field] = value
+		}
+	}
+	return result
+}
+
+// included resolves the relationships of the encoded entities whose type was
+// requested with the include parameter, keyed by type and identifier. The
+// caller must hold the mutex.
+func (s *Server) included(r *http.Request, encodedItems []map[string]any) map[string]any {
+	include := r.URL.Query().Get("include")
+	if include == "" {
+		return map[string]any{}
+	}
+	requested := strings.Split(include, ",")
+
+	included := make(map[string]any)
+	for _, encoded := range encodedItems {
+		for _, ref := range references(encoded) {
+			if !slices.Contains(requested, ref.Type) {
+				continue
+			}
+			item, ok := s.lookup(ref.Type, ref.ID)
+			if !ok {
+				continue
+			}
+			encodedItem, err := encode(item)
+			if err != nil {
+				continue
+			}
+			byID, ok := included[ref.Type].(map[string]any)
+			if !ok {
+				byID = make(map[string]any)
+				included[ref.Type] = byID
+			}
+			byID[strconv.FormatInt(ref.ID, 10)] = sparse(encodedItem, fieldsFor(r, ref.Type))
+		}
+	}
+	return included
+}
+
+// reference is a relationship to another entity found in an encoded entity.
+type reference struct {
+	ID   int64
+	Type string
+}
+
+// references returns the relationships to other entities in the top level
+// attributes of the encoded entity.
+func references(encoded map[string]any) []reference {
+	var result []reference
+	for _, value := range encoded {
+		switch value := value.(type) {
+		case map[string]any:
+			if rel, ok := asReference(value); ok {
+				result = append(result, rel)
+			}
+		case []any:
+			for _, element := range value {
+				if object, ok := element.(map[string]any); ok {
+					if rel, ok := asReference(object); ok {
+						result = append(result, rel)
+					}
+				}
+			}
+		}
+	}
+	return result
+}
+
+// asReference converts an encoded twapi.Relationship.
+func asReference(object map[string]any) (reference, bool) {
+	id, ok := object["id"].(float64)
+	if !ok {
+		return reference{}, false
+	}
+	refType, ok := object["type"].(string)
+	if !ok || refType == "" {
+		return reference{}, false
+	}
+	return reference{ID: int64(id), Type: refType}, true
+}
+
+// lookup returns the entity of the given relationship type. The caller must
+// hold the mutex.
+func (s *Server) lookup(relType string, id int64) (any, bool) {
+	var (
+		item any
+		ok   bool
+	)
+	switch relType {
+	case "projects":
+		item, ok = s.projects[id]
+	case "tasklists":
+		item, ok = s.tasklists[id]
+	case "tasks":
+		item, ok = s.tasks[id]
+	case "tags":
+		item, ok = s.tags[id]
+	case "timelogs":
+		item, ok = s.timelogs[id]
+	case "comments":
+		item, ok = s.comments[id]
+	case "milestones":
+		item, ok = s.milestones[id]
+	case "users":
+		item, ok = s.users[id]
+	}
+	return item, ok
+}
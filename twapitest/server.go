@@ -0,0 +1,174 @@
+package twapitest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/projects"
+	"github.com/teamwork/twapi-go-sdk/session"
+)
+
+// Token is the bearer token the server accepts. Requests without it are
+// rejected with 401 Unauthorized.
+const Token = "twapitest"
+
+// Server is an in-process fake of the Teamwork API, backed by an in-memory
+// model of projects, tasklists, tasks, tags, timelogs, comments, milestones,
+// users and the activity log. It serves the same endpoints the projects package calls, so code built
+// on the SDK can be tested without a Teamwork installation.
+//
+// List endpoints paginate with the page and pageSize parameters, reporting
+// meta.page.hasMore and meta.page.count, and every v3 endpoint honours
+// fields[...] sparse fieldsets and include sideloads of the modelled entities.
+//
+// The model is deliberately simple. Only the most common filters are applied,
+// and lists are ordered by ID, except the activity log, ordered by date. It is safe for concurrent use.
+type Server struct {
+	server *httptest.Server
+
+	mutex      sync.Mutex
+	nextID     int64
+	now        func() time.Time
+	me         int64
+	projects   map[int64]projects.Project
+	tasklists  map[int64]projects.Tasklist
+	tasks      map[int64]projects.Task
+	tags       map[int64]projects.Tag
+	timelogs   map[int64]projects.Timelog
+	comments   map[int64]projects.Comment
+	milestones map[int64]projects.Milestone
+	users      map[int64]projects.User
+	activities map[int64]projects.Activity
+}
+
+// Option defines a function type that can modify the Server initial
+// configuration.
+type Option func(*Server)
+
+// WithClock sets the function used to timestamp the changes made to the model.
+// By default, it uses time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(s *Server) {
+		s.now = now
+	}
+}
+
+// NewServer starts a new fake server, which is closed when the test finishes.
+// The model starts with a single user, returned by Me, that authenticated
+// requests act on behalf of.
+func NewServer(tb testing.TB, opts ...Option) *Server {
+	tb.Helper()
+
+	s := &Server{
+		now:        time.Now,
+		projects:   make(map[int64]projects.Project),
+		tasklists:  make(map[int64]projects.Tasklist),
+		tasks:      make(map[int64]projects.Task),
+		tags:       make(map[int64]projects.Tag),
+		timelogs:   make(map[int64]projects.Timelog),
+		comments:   make(map[int64]projects.Comment),
+		milestones: make(map[int64]projects.Milestone),
+		users:      make(map[int64]projects.User),
+		activities: make(map[int64]projects.Activity),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.me = s.AddUser(projects.User{
+		FirstName: "Test",
+		LastName:  "User",
+		Email:     "test.user@example.com",
+		Admin:     true,
+		Type:      "account",
+	}).ID
+
+	mux := http.NewServeMux()
+	s.registerProjects(mux)
+	s.registerTasklists(mux)
+	s.registerTasks(mux)
+	s.registerTags(mux)
+	s.registerTimelogs(mux)
+	s.registerComments(mux)
+	s.registerMilestones(mux)
+	s.registerUsers(mux)
+	s.registerActivities(mux)
+
+	s.server = httptest.NewServer(s.authenticate(mux))
+	tb.Cleanup(s.server.Close)
+	return s
+}
+
+// URL returns the base URL of the server, to be used as the session server.
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// Engine returns a new Engine authenticated against the server.
+func (s *Server) Engine(opts ...twapi.EngineOption) *twapi.Engine {
+	return twapi.NewEngine(session.NewBearerToken(Token, s.server.URL), opts...)
+}
+
+// Me returns the user authenticated requests act on behalf of.
+func (s *Server) Me() projects.User {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.users[s.me]
+}
+
+// authenticate rejects requests without the server token, and serialises the
+// access to the model.
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+Token {
+			writeError(w, http.StatusUnauthorized, "invalid or missing bearer token")
+			return
+		}
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// newID returns the identifier of a new entity. The caller must hold the
+// mutex.
+func (s *Server) newID() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+// timestamp returns the current time of the server clock, truncated to the
+// precision of the API. The caller must hold the mutex.
+func (s *Server) timestamp() time.Time {
+	return s.now().UTC().Truncate(time.Second)
+}
+
+// writeJSON encodes the body as the JSON response.
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// writeError writes an error response in the format of the v3 API.
+func writeError(w http.ResponseWriter, status int, detail string) {
+	writeJSON(w, status, map[string]any{
+		"errors": []map[string]any{{
+			"title":  http.StatusText(status),
+			"detail": detail,
+		}},
+	})
+}
+
+// writeLegacyOK writes the response of a successful v1 update or delete.
+func writeLegacyOK(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]any{"STATUS": "OK"})
+}
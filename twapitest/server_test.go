@@ -0,0 +1,231 @@
+package twapitest_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/projects"
+	"github.com/teamwork/twapi-go-sdk/twapitest"
+)
+
+func TestServer(t *testing.T) {
+	ctx := t.Context()
+	server := twapitest.NewServer(t)
+	engine := server.Engine()
+
+	project, err := projects.ProjectCreate(ctx, engine, projects.NewProjectCreateRequest("Apollo"))
+	if err != nil {
+		t.Fatalf("failed to create project: %v", err)
+	}
+	tasklist, err := projects.TasklistCreate(ctx, engine, projects.NewTasklistCreateRequest(int64(project.ID), "Launch"))
+	if err != nil {
+		t.Fatalf("failed to create tasklist: %v", err)
+	}
+
+	createRequest := projects.NewTaskCreateRequest(int64(tasklist.ID), "Fuel the rocket")
+	createRequest.EstimatedMinutes = new(int64(90))
+	created, err := projects.TaskCreate(ctx, engine, createRequest)
+	if err != nil {
+		t.Fatalf("failed to create task: %v", err)
+	}
+
+	updateRequest := projects.NewTaskUpdateRequest(created.Task.ID)
+	updateRequest.Name = new("Fuel the rocket twice")
+	if _, err := projects.TaskUpdate(ctx, engine, updateRequest); err != nil {
+		t.Fatalf("failed to update task: %v", err)
+	}
+
+	timelogRequest := projects.NewTimelogCreateRequestInTask(created.Task.ID, time.Now(), 90*time.Minute)
+	if _, err := projects.TimelogCreate(ctx, engine, timelogRequest); err != nil {
+		t.Fatalf("failed to create timelog: %v", err)
+	}
+
+	if _, err := projects.TaskComplete(ctx, engine, projects.NewTaskCompleteRequest(created.Task.ID)); err != nil {
+		t.Fatalf("failed to complete task: %v", err)
+	}
+
+	retrieved, err := projects.TaskGet(ctx, engine, projects.NewTaskGetRequest(created.Task.ID))
+	if err != nil {
+		t.Fatalf("failed to retrieve task: %v", err)
+	}
+	if retrieved.Task.Name != "Fuel the rocket twice" {
+		t.Errorf("expected updated name, got %q", retrieved.Task.Name)
+	}
+	if retrieved.Task.EstimatedMinutes != 90 {
+		t.Errorf("expected 90 estimated minutes, got %d", retrieved.Task.EstimatedMinutes)
+	}
+	if retrieved.Task.Status != "completed" || retrieved.Task.CompletedBy == nil {
+		t.Errorf("expected completed task, got status %q", retrieved.Task.Status)
+	}
+
+	timelogs, err := projects.TimelogList(ctx, engine, projects.NewTimelogListRequest())
+	if err != nil {
+		t.Fatalf("failed to list timelogs: %v", err)
+	}
+	if len(timelogs.Timelogs) != 1 || timelogs.Timelogs[0].Minutes != 90 {
+		t.Errorf("expected a single timelog of 90 minutes, got %+v", timelogs.Timelogs)
+	}
+	if timelogs.Timelogs[0].Project.ID != int64(project.ID) {
+		t.Errorf("expected timelog in project %d, got %d", project.ID, timelogs.Timelogs[0].Project.ID)
+	}
+
+	me, err := projects.UserGetMe(ctx, engine, projects.NewUserGetMeRequest())
+	if err != nil {
+		t.Fatalf("failed to retrieve logged user: %v", err)
+	}
+	if me.User.ID != server.Me().ID {
+		t.Errorf("expected logged user %d, got %d", server.Me().ID, me.User.ID)
+	}
+}
+
+func TestServerPagination(t *testing.T) {
+	server := twapitest.NewServer(t)
+	project := server.AddProject(projects.Project{Name: "Apollo"})
+	tasklist := server.AddTasklist(projects.Tasklist{
+		Name:    "Launch",
+		Project: twapi.Relationship{ID: project.ID},
+	})
+	for range 7 {
+		server.AddTask(projects.Task{
+			Name:     "Countdown",
+			Tasklist: twapi.Relationship{ID: tasklist.ID},
+		})
+	}
+	server.AddTask(projects.Task{
+		Name:     "Done already",
+		Tasklist: twapi.Relationship{ID: tasklist.ID},
+		Status:   "completed",
+	})
+
+	tests := []struct {
+		name     string
+		pageSize int64
+		want     int
+	}{{
+		name:     "single page",
+		pageSize: 50,
+		want:     7,
+	}, {
+		name:     "exact pages",
+		pageSize: 7,
+		want:     7,
+	}, {
+		name:     "partial last page",
+		pageSize: 3,
+		want:     7,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := projects.NewTaskListRequest()
+			req.Path.TasklistID = tasklist.ID
+			req.Filters.PageSize = tt.pageSize
+
+			var got int
+			items := twapi.AllItems(t.Context(), server.Engine(), req, func(r *projects.TaskListResponse) []projects.Task {
+				return r.Tasks
+			})
+			for task, err := range items {
+				if err != nil {
+					t.Fatalf("failed to list tasks: %v", err)
+				}
+				if task.Status == "completed" {
+					t.Errorf("unexpected completed task %d", task.ID)
+				}
+				got++
+			}
+			if got != tt.want {
+				t.Errorf("expected %d tasks, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestServerSparseFields(t *testing.T) {
+	server := twapitest.NewServer(t)
+	server.AddProject(projects.Project{Name: "Apollo", Description: new("To the moon")})
+
+	req := projects.NewProjectListRequest()
+	req.Filters.Fields.Projects = []projects.ProjectField{projects.ProjectFieldName}
+	resp, err := projects.ProjectList(t.Context(), server.Engine(), req)
+	if err != nil {
+		t.Fatalf("failed to list projects: %v", err)
+	}
+	if len(resp.Projects) != 1 {
+		t.Fatalf("expected 1 project, got %d", len(resp.Projects))
+	}
+	if got := resp.Projects[0]; got.ID == 0 || got.Name != "Apollo" || got.Description != nil || got.Status != "" {
+		t.Errorf("expected only id and name, got %+v", got)
+	}
+}
+
+func TestServerInclude(t *testing.T) {
+	server := twapitest.NewServer(t)
+	project := server.AddProject(projects.Project{
+		Name:  "Apollo",
+		Owner: &twapi.Relationship{ID: server.Me().ID, Type: "users"},
+	})
+
+	tests := []struct {
+		name    string
+		include string
+		want    bool
+	}{{
+		name:    "requested",
+		include: "users",
+		want:    true,
+	}, {
+		name: "not requested",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequestWithContext(t.Context(), http.MethodGet,
+				server.URL()+"/projects/api/v3/projects.json?include="+tt.include, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+twapitest.Token)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = resp.Body.Close() }()
+
+			var body struct {
+				Included struct {
+					Users map[string]projects.User `json:"users"`
+				} `json:"included"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			_, ok := body.Included.Users[strconv.FormatInt(server.Me().ID, 10)]
+			if ok != tt.want {
+				t.Errorf("expected owner of project %d included to be %t", project.ID, tt.want)
+			}
+		})
+	}
+}
+
+func TestServerUnauthorized(t *testing.T) {
+	server := twapitest.NewServer(t)
+
+	req, err := http.NewRequestWithContext(t.Context(), http.MethodGet, server.URL()+"/projects/api/v3/me.json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+}
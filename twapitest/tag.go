@@ -0,0 +1,120 @@
+package twapitest
+
+import (
+	"net/http"
+
+	"github.com/teamwork/twapi-go-sdk/projects"
+)
+
+// AddTag adds the tag to the model, assigning an identifier when it has none,
+// and returns the stored tag.
+func (s *Server) AddTag(tag projects.Tag) projects.Tag {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if tag.ID == 0 {
+		tag.ID = s.newID()
+	}
+	s.tags[tag.ID] = tag
+	return tag
+}
+
+// Tag returns the tag with the given identifier.
+func (s *Server) Tag(id int64) (projects.Tag, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	tag, ok := s.tags[id]
+	return tag, ok
+}
+
+func (s *Server) registerTags(mux *http.ServeMux) {
+	mux.HandleFunc("POST /projects/api/v3/tags.json", s.createTag)
+	mux.HandleFunc("PATCH /projects/api/v3/tags/{id}", s.updateTag)
+	mux.HandleFunc("DELETE /projects/api/v3/tags/{id}", s.deleteTag)
+	mux.HandleFunc("GET /projects/api/v3/tags/{id}", s.getTag)
+	mux.HandleFunc("GET /projects/api/v3/tags.json", s.listTags)
+}
+
+func (s *Server) createTag(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Tag projects.TagCreateRequest `json:"tag"`
+	}
+	if !decodeBody(w, r, &payload) {
+		return
+	}
+	if payload.Tag.Name == "" {
+		writeError(w, http.StatusUnprocessableEntity, "name is required")
+		return
+	}
+
+	tag := projects.Tag{
+		ID:   s.newID(),
+		Name: payload.Tag.Name,
+	}
+	setIfPresent(&tag.Color, payload.Tag.Color)
+	if payload.Tag.ProjectID != nil {
+		tag.Project = new(relationship(*payload.Tag.ProjectID, "projects"))
+	}
+	s.tags[tag.ID] = tag
+
+	s.writeItem(w, r, http.StatusCreated, "tag", "tags", tag)
+}
+
+func (s *Server) updateTag(w http.ResponseWriter, r *http.Request) {
+	tag, ok := findByPath(w, r, s.tags)
+	if !ok {
+		return
+	}
+	var payload struct {
+		Tag projects.TagUpdateRequest `json:"tag"`
+	}
+	if !decodeBody(w, r, &payload) {
+		return
+	}
+
+	setIfPresent(&tag.Name, payload.Tag.Name)
+	setIfPresent(&tag.Color, payload.Tag.Color)
+	if payload.Tag.ProjectID != nil {
+		tag.Project = new(relationship(*payload.Tag.ProjectID, "projects"))
+	}
+	s.tags[tag.ID] = tag
+
+	s.writeItem(w, r, http.StatusOK, "tag", "tags", tag)
+}
+
+func (s *Server) deleteTag(w http.ResponseWriter, r *http.Request) {
+	tag, ok := findByPath(w, r, s.tags)
+	if !ok {
+		return
+	}
+	delete(s.tags, tag.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getTag(w http.ResponseWriter, r *http.Request) {
+	tag, ok := findByPath(w, r, s.tags)
+	if !ok {
+		return
+	}
+	s.writeItem(w, r, http.StatusOK, "tag", "tags", tag)
+}
+
+func (s *Server) listTags(w http.ResponseWriter, r *http.Request) {
+	list, ok := listQueryOf(w, r)
+	if !ok {
+		return
+	}
+
+	var items []projects.Tag
+	for _, tag := range sortedValues(s.tags) {
+		var projectID int64
+		if tag.Project != nil {
+			projectID = tag.Project.ID
+		}
+		if list.matchSearch(tag.Name) && list.matchProject(projectID) {
+			items = append(items, tag)
+		}
+	}
+	writeList(s, w, r, list, "tags", "tags", items)
+}
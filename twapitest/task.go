@@ -0,0 +1,287 @@
+package twapitest
+
+import (
+	"net/http"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/projects"
+)
+
+const taskStatusCompleted = "completed"
+
+// AddTask adds the task to the model, assigning an identifier when it has none,
+// and returns the stored task.
+func (s *Server) AddTask(task projects.Task) projects.Task {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if task.ID == 0 {
+		task.ID = s.newID()
+	}
+	now := s.timestamp()
+	if task.CreatedAt == nil {
+		task.CreatedAt = &now
+	}
+	if task.UpdatedAt.IsZero() {
+		task.UpdatedAt = now
+	}
+	if task.Tasklist.Type == "" {
+		task.Tasklist.Type = "tasklists"
+	}
+	if task.Status == "" {
+		task.Status = "new"
+	}
+	s.tasks[task.ID] = task
+	return task
+}
+
+// Task returns the task with the given identifier.
+func (s *Server) Task(id int64) (projects.Task, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	task, ok := s.tasks[id]
+	return task, ok
+}
+
+func (s *Server) registerTasks(mux *http.ServeMux) {
+	mux.HandleFunc("POST /projects/api/v3/tasklists/{tasklistId}/tasks.json", s.createTask)
+	mux.HandleFunc("PUT /projects/api/v3/tasks/{id}", s.updateTask)
+	mux.HandleFunc("DELETE /projects/api/v3/tasks/{id}", s.deleteTask)
+	mux.HandleFunc("PUT /tasks/{id}/complete.json", s.completeTask)
+	mux.HandleFunc("GET /projects/api/v3/tasks/{id}", s.getTask)
+	mux.HandleFunc("GET /projects/api/v3/tasks.json", s.listTasks)
+	mux.HandleFunc("GET /projects/api/v3/tasklists/{tasklistId}/tasks.json", s.listTasks)
+	mux.HandleFunc("GET /projects/api/v3/projects/{projectId}/tasks.json", s.listTasks)
+}
+
+// taskPayload is the body of the task create and update requests.
+type taskPayload[T any] struct {
+	Task         T                          `json:"task"`
+	Predecessors []projects.TaskPredecessor `json:"predecessors"`
+}
+
+func (s *Server) createTask(w http.ResponseWriter, r *http.Request) {
+	tasklist, ok := findByPathValue(w, r, "tasklistId", s.tasklists)
+	if !ok {
+		return
+	}
+	var payload taskPayload[projects.TaskCreateRequest]
+	if !decodeBody(w, r, &payload) {
+		return
+	}
+	create := payload.Task
+	if create.Name == "" {
+		writeError(w, http.StatusUnprocessableEntity, "name is required")
+		return
+	}
+
+	now, me := s.touch()
+	task := projects.Task{
+		ID:           s.newID(),
+		Name:         create.Name,
+		Description:  create.Description,
+		Priority:     create.Priority,
+		StartAt:      create.StartAt,
+		DueAt:        create.DueAt,
+		Tasklist:     relationship(tasklist.ID, "tasklists"),
+		Tags:         relationships(create.TagIDs, "tags"),
+		Predecessors: predecessors(payload.Predecessors),
+		CreatedAt:    now,
+		CreatedBy:    me,
+		UpdatedAt:    *now,
+		UpdatedBy:    me,
+		Status:       "new",
+	}
+	setIfPresent(&task.Progress, create.Progress)
+	setIfPresent(&task.EstimatedMinutes, create.EstimatedMinutes)
+	if create.ParentTaskID != nil {
+		task.ParentTask = new(relationship(*create.ParentTaskID, "tasks"))
+	}
+	if create.Assignees != nil {
+		task.Assignees = assignees(*create.Assignees)
+	}
+	s.tasks[task.ID] = task
+
+	s.writeItem(w, r, http.StatusCreated, "task", "tasks", task)
+}
+
+func (s *Server) updateTask(w http.ResponseWriter, r *http.Request) {
+	task, ok := findByPath(w, r, s.tasks)
+	if !ok {
+		return
+	}
+	var payload taskPayload[projects.TaskUpdateRequest]
+	if !decodeBody(w, r, &payload) {
+		return
+	}
+
+	update := payload.Task
+	setIfPresent(&task.Name, update.Name)
+	if update.Description != nil {
+		task.Description = update.Description
+	}
+	if update.Priority != nil {
+		task.Priority = update.Priority
+	}
+	setIfPresent(&task.Progress, update.Progress)
+	if update.StartAt != nil {
+		task.StartAt = update.StartAt
+	}
+	if update.DueAt != nil {
+		task.DueAt = update.DueAt
+	}
+	setIfPresent(&task.EstimatedMinutes, update.EstimatedMinutes)
+	if update.TasklistID != nil {
+		if _, ok := s.tasklists[*update.TasklistID]; !ok {
+			writeError(w, http.StatusUnprocessableEntity, "tasklist not found")
+			return
+		}
+		task.Tasklist = relationship(*update.TasklistID, "tasklists")
+	}
+	if update.ParentTaskID != nil {
+		task.ParentTask = new(relationship(*update.ParentTaskID, "tasks"))
+	}
+	if update.Assignees != nil {
+		task.Assignees = assignees(*update.Assignees)
+	}
+	if update.TagIDs != nil {
+		task.Tags = relationships(update.TagIDs, "tags")
+	}
+	if payload.Predecessors != nil {
+		task.Predecessors = predecessors(payload.Predecessors)
+	}
+	now, me := s.touch()
+	task.UpdatedAt, task.UpdatedBy = *now, me
+	s.tasks[task.ID] = task
+
+	s.writeItem(w, r, http.StatusOK, "task", "tasks", task)
+}
+
+func (s *Server) deleteTask(w http.ResponseWriter, r *http.Request) {
+	task, ok := findByPath(w, r, s.tasks)
+	if !ok {
+		return
+	}
+	delete(s.tasks, task.ID)
+	writeJSON(w, http.StatusOK, map[string]any{})
+}
+
+func (s *Server) completeTask(w http.ResponseWriter, r *http.Request) {
+	task, ok := findByPath(w, r, s.tasks)
+	if !ok {
+		return
+	}
+	now, me := s.touch()
+	task.Status = taskStatusCompleted
+	task.Progress = 100
+	task.CompletedAt, task.CompletedBy = now, me
+	task.UpdatedAt, task.UpdatedBy = *now, me
+	s.tasks[task.ID] = task
+
+	writeLegacyOK(w)
+}
+
+func (s *Server) getTask(w http.ResponseWriter, r *http.Request) {
+	task, ok := findByPath(w, r, s.tasks)
+	if !ok {
+		return
+	}
+	s.writeItem(w, r, http.StatusOK, "task", "tasks", s.relatedTasks(r, task))
+}
+
+func (s *Server) listTasks(w http.ResponseWriter, r *http.Request) {
+	list, ok := listQueryOf(w, r)
+	if !ok {
+		return
+	}
+	var tasklistID int64
+	if r.PathValue("tasklistId") != "" {
+		tasklist, ok := findByPathValue(w, r, "tasklistId", s.tasklists)
+		if !ok {
+			return
+		}
+		tasklistID = tasklist.ID
+	}
+	if r.PathValue("projectId") != "" {
+		project, ok := findByPathValue(w, r, "projectId", s.projects)
+		if !ok {
+			return
+		}
+		list.projectIDs = []int64{project.ID}
+	}
+	includeCompleted := r.URL.Query().Get("includeCompletedTasks") == "true"
+
+	var items []projects.Task
+	for _, task := range sortedValues(s.tasks) {
+		switch {
+		case tasklistID != 0 && task.Tasklist.ID != tasklistID,
+			!includeCompleted && task.Status == taskStatusCompleted,
+			!list.matchProject(s.tasklists[task.Tasklist.ID].Project.ID),
+			!list.matchSearch(task.Name),
+			!list.matchUpdated(&task.UpdatedAt):
+			continue
+		}
+		items = append(items, s.relatedTasks(r, task))
+	}
+	writeList(s, w, r, list, "tasks", "tasks", items)
+}
+
+// relatedTasks fills the predecessors and subtasks of the task when they were
+// requested with includeRelatedTasks, leaving them out otherwise like the API
+// does. The caller must hold the mutex.
+func (s *Server) relatedTasks(r *http.Request, task projects.Task) projects.Task {
+	query := r.URL.Query()
+	if query.Get("includeRelatedTasks") != "true" {
+		task.Predecessors = nil
+		task.SubTaskIDs = nil
+		return task
+	}
+
+	includeCompleted := query.Get("includeCompletedPredecessors") == "true"
+	var related []twapi.Relationship
+	for _, predecessor := range task.Predecessors {
+		if includeCompleted || s.tasks[predecessor.ID].Status != taskStatusCompleted {
+			related = append(related, predecessor)
+		}
+	}
+	task.Predecessors = related
+
+	task.SubTaskIDs = nil
+	for _, subtask := range sortedValues(s.tasks) {
+		if subtask.ParentTask == nil || subtask.ParentTask.ID != task.ID {
+			continue
+		}
+		if includeCompleted || subtask.Status != taskStatusCompleted {
+			task.SubTaskIDs = append(task.SubTaskIDs, subtask.ID)
+		}
+	}
+	return task
+}
+
+// predecessors converts the predecessors of a request into the relationships
+// of a task, keeping the constraint type in the relationship metadata.
+func predecessors(requested []projects.TaskPredecessor) []twapi.Relationship {
+	if len(requested) == 0 {
+		return nil
+	}
+	result := make([]twapi.Relationship, len(requested))
+	for i, predecessor := range requested {
+		result[i] = twapi.Relationship{
+			ID:   predecessor.ID,
+			Type: "tasks",
+			Meta: map[string]any{"type": string(predecessor.Type)},
+		}
+	}
+	return result
+}
+
+// assignees converts the assignees of a request into the relationships of a
+// task.
+func assignees(groups projects.UserGroups) []twapi.Relationship {
+	var result []twapi.Relationship
+	result = append(result, relationships(groups.UserIDs, "users")...)
+	result = append(result, relationships(groups.CompanyIDs, "companies")...)
+	result = append(result, relationships(groups.TeamIDs, "teams")...)
+	return result
+}
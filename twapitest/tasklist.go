@@ -0,0 +1,151 @@
+package twapitest
+
+import (
+	"net/http"
+
+	"github.com/teamwork/twapi-go-sdk/projects"
+)
+
+// AddTasklist adds the tasklist to the model, assigning an identifier when it
+// has none, and returns the stored tasklist.
+func (s *Server) AddTasklist(tasklist projects.Tasklist) projects.Tasklist {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if tasklist.ID == 0 {
+		tasklist.ID = s.newID()
+	}
+	now := s.timestamp()
+	if tasklist.CreatedAt == nil {
+		tasklist.CreatedAt = &now
+	}
+	if tasklist.UpdatedAt == nil {
+		tasklist.UpdatedAt = &now
+	}
+	if tasklist.Project.Type == "" {
+		tasklist.Project.Type = "projects"
+	}
+	if tasklist.Status == "" {
+		tasklist.Status = "new"
+	}
+	s.tasklists[tasklist.ID] = tasklist
+	return tasklist
+}
+
+// Tasklist returns the tasklist with the given identifier.
+func (s *Server) Tasklist(id int64) (projects.Tasklist, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	tasklist, ok := s.tasklists[id]
+	return tasklist, ok
+}
+
+func (s *Server) registerTasklists(mux *http.ServeMux) {
+	mux.HandleFunc("POST /projects/{projectId}/tasklists.json", s.createTasklist)
+	mux.HandleFunc("PUT /tasklists/{id}", s.updateTasklist)
+	mux.HandleFunc("DELETE /tasklists/{id}", s.deleteTasklist)
+	mux.HandleFunc("GET /projects/api/v3/tasklists/{id}", s.getTasklist)
+	mux.HandleFunc("GET /projects/api/v3/tasklists.json", s.listTasklists)
+	mux.HandleFunc("GET /projects/api/v3/projects/{projectId}/tasklists.json", s.listTasklists)
+}
+
+func (s *Server) createTasklist(w http.ResponseWriter, r *http.Request) {
+	project, ok := findByPathValue(w, r, "projectId", s.projects)
+	if !ok {
+		return
+	}
+	var payload struct {
+		Tasklist projects.TasklistCreateRequest `json:"todo-list"`
+	}
+	if !decodeBody(w, r, &payload) {
+		return
+	}
+	if payload.Tasklist.Name == "" {
+		writeError(w, http.StatusUnprocessableEntity, "name is required")
+		return
+	}
+
+	now, _ := s.touch()
+	tasklist := projects.Tasklist{
+		ID:        s.newID(),
+		Name:      payload.Tasklist.Name,
+		Project:   relationship(project.ID, "projects"),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Status:    "new",
+	}
+	if payload.Tasklist.Description != nil {
+		tasklist.Description = *payload.Tasklist.Description
+	}
+	if payload.Tasklist.MilestoneID != nil {
+		tasklist.Milestone = new(relationship(*payload.Tasklist.MilestoneID, "milestones"))
+	}
+	s.tasklists[tasklist.ID] = tasklist
+
+	writeJSON(w, http.StatusCreated, map[string]any{"tasklistId": legacyID(tasklist.ID)})
+}
+
+func (s *Server) updateTasklist(w http.ResponseWriter, r *http.Request) {
+	tasklist, ok := findByPath(w, r, s.tasklists)
+	if !ok {
+		return
+	}
+	var payload struct {
+		Tasklist projects.TasklistUpdateRequest `json:"todo-list"`
+	}
+	if !decodeBody(w, r, &payload) {
+		return
+	}
+
+	update := payload.Tasklist
+	setIfPresent(&tasklist.Name, update.Name)
+	setIfPresent(&tasklist.Description, update.Description)
+	if update.MilestoneID != nil {
+		tasklist.Milestone = new(relationship(*update.MilestoneID, "milestones"))
+	}
+	tasklist.UpdatedAt, _ = s.touch()
+	s.tasklists[tasklist.ID] = tasklist
+
+	writeLegacyOK(w)
+}
+
+func (s *Server) deleteTasklist(w http.ResponseWriter, r *http.Request) {
+	tasklist, ok := findByPath(w, r, s.tasklists)
+	if !ok {
+		return
+	}
+	delete(s.tasklists, tasklist.ID)
+	writeLegacyOK(w)
+}
+
+func (s *Server) getTasklist(w http.ResponseWriter, r *http.Request) {
+	tasklist, ok := findByPath(w, r, s.tasklists)
+	if !ok {
+		return
+	}
+	s.writeItem(w, r, http.StatusOK, "tasklist", "tasklists", tasklist)
+}
+
+func (s *Server) listTasklists(w http.ResponseWriter, r *http.Request) {
+	list, ok := listQueryOf(w, r)
+	if !ok {
+		return
+	}
+	if r.PathValue("projectId") != "" {
+		project, ok := findByPathValue(w, r, "projectId", s.projects)
+		if !ok {
+			return
+		}
+		list.projectIDs = []int64{project.ID}
+	}
+
+	var items []projects.Tasklist
+	for _, tasklist := range sortedValues(s.tasklists) {
+		if list.matchSearch(tasklist.Name) && list.matchProject(tasklist.Project.ID) &&
+			list.matchUpdated(tasklist.UpdatedAt) {
+			items = append(items, tasklist)
+		}
+	}
+	writeList(s, w, r, list, "tasklists", "tasklists", items)
+}
@@ -0,0 +1,204 @@
+package twapitest
+
+import (
+	"net/http"
+	"time"
+
+	twapi "github.com/teamwork/twapi-go-sdk"
+	"github.com/teamwork/twapi-go-sdk/projects"
+)
+
+// AddTimelog adds the timelog to the model, assigning an identifier when it has
+// none, and returns the stored timelog.
+func (s *Server) AddTimelog(timelog projects.Timelog) projects.Timelog {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if timelog.ID == 0 {
+		timelog.ID = s.newID()
+	}
+	now := s.timestamp()
+	if timelog.CreatedAt.IsZero() {
+		timelog.CreatedAt = now
+	}
+	if timelog.UpdatedAt == nil {
+		timelog.UpdatedAt = &now
+	}
+	if timelog.LoggedAt.IsZero() {
+		timelog.LoggedAt = now
+	}
+	if timelog.User.ID == 0 {
+		timelog.User = relationship(s.me, "users")
+	}
+	if timelog.LoggedBy == 0 {
+		timelog.LoggedBy = s.me
+	}
+	if timelog.Project.Type == "" {
+		timelog.Project.Type = "projects"
+	}
+	s.timelogs[timelog.ID] = timelog
+	return timelog
+}
+
+// Timelog returns the timelog with the given identifier.
+func (s *Server) Timelog(id int64) (projects.Timelog, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	timelog, ok := s.timelogs[id]
+	return timelog, ok
+}
+
+func (s *Server) registerTimelogs(mux *http.ServeMux) {
+	mux.HandleFunc("POST /projects/api/v3/tasks/{taskId}/time.json", s.createTimelog)
+	mux.HandleFunc("POST /projects/api/v3/projects/{projectId}/time.json", s.createTimelog)
+	mux.HandleFunc("PATCH /projects/api/v3/time/{id}", s.updateTimelog)
+	mux.HandleFunc("DELETE /projects/api/v3/time/{id}", s.deleteTimelog)
+	mux.HandleFunc("GET /projects/api/v3/time/{id}", s.getTimelog)
+	mux.HandleFunc("GET /projects/api/v3/time.json", s.listTimelogs)
+	mux.HandleFunc("GET /projects/api/v3/tasks/{taskId}/time.json", s.listTimelogs)
+	mux.HandleFunc("GET /projects/api/v3/projects/{projectId}/time.json", s.listTimelogs)
+}
+
+func (s *Server) createTimelog(w http.ResponseWriter, r *http.Request) {
+	var timelog projects.Timelog
+	if r.PathValue("taskId") != "" {
+		task, ok := findByPathValue(w, r, "taskId", s.tasks)
+		if !ok {
+			return
+		}
+		timelog.Task = new(relationship(task.ID, "tasks"))
+		timelog.Project = relationship(s.tasklists[task.Tasklist.ID].Project.ID, "projects")
+	} else {
+		project, ok := findByPathValue(w, r, "projectId", s.projects)
+		if !ok {
+			return
+		}
+		timelog.Project = relationship(project.ID, "projects")
+	}
+	var payload struct {
+		Timelog projects.TimelogCreateRequest `json:"timelog"`
+	}
+	if !decodeBody(w, r, &payload) {
+		return
+	}
+	create := payload.Timelog
+	if create.Hours*60+create.Minutes <= 0 {
+		writeError(w, http.StatusUnprocessableEntity, "time spent is required")
+		return
+	}
+
+	now, me := s.touch()
+	timelog.ID = s.newID()
+	timelog.Minutes = create.Hours*60 + create.Minutes
+	timelog.LoggedAt = loggedAt(create.Date, create.Time)
+	timelog.User = relationship(*me, "users")
+	if create.UserID != nil {
+		timelog.User = relationship(*create.UserID, "users")
+	}
+	timelog.Tags = relationships(create.TagIDs, "tags")
+	setIfPresent(&timelog.Description, create.Description)
+	setIfPresent(&timelog.Billable, create.Billable)
+	timelog.CreatedAt, timelog.LoggedBy = *now, *me
+	timelog.UpdatedAt, timelog.UpdatedBy = now, me
+	s.timelogs[timelog.ID] = timelog
+
+	s.writeItem(w, r, http.StatusCreated, "timelog", "timelogs", timelog)
+}
+
+func (s *Server) updateTimelog(w http.ResponseWriter, r *http.Request) {
+	timelog, ok := findByPath(w, r, s.timelogs)
+	if !ok {
+		return
+	}
+	var payload struct {
+		Timelog projects.TimelogUpdateRequest `json:"timelog"`
+	}
+	if !decodeBody(w, r, &payload) {
+		return
+	}
+
+	update := payload.Timelog
+	setIfPresent(&timelog.Description, update.Description)
+	setIfPresent(&timelog.Billable, update.Billable)
+	if update.Hours != nil || update.Minutes != nil {
+		var hours, minutes int64
+		setIfPresent(&hours, update.Hours)
+		setIfPresent(&minutes, update.Minutes)
+		timelog.Minutes = hours*60 + minutes
+	}
+	if update.Date != nil || update.Time != nil {
+		date, clock := twapi.Date(timelog.LoggedAt), twapi.Time(timelog.LoggedAt)
+		setIfPresent(&date, update.Date)
+		setIfPresent(&clock, update.Time)
+		timelog.LoggedAt = loggedAt(date, clock)
+	}
+	if update.UserID != nil {
+		timelog.User = relationship(*update.UserID, "users")
+	}
+	if update.TagIDs != nil {
+		timelog.Tags = relationships(update.TagIDs, "tags")
+	}
+	timelog.UpdatedAt, timelog.UpdatedBy = s.touch()
+	s.timelogs[timelog.ID] = timelog
+
+	s.writeItem(w, r, http.StatusOK, "timelog", "timelogs", timelog)
+}
+
+func (s *Server) deleteTimelog(w http.ResponseWriter, r *http.Request) {
+	timelog, ok := findByPath(w, r, s.timelogs)
+	if !ok {
+		return
+	}
+	delete(s.timelogs, timelog.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getTimelog(w http.ResponseWriter, r *http.Request) {
+	timelog, ok := findByPath(w, r, s.timelogs)
+	if !ok {
+		return
+	}
+	s.writeItem(w, r, http.StatusOK, "timelog", "timelogs", timelog)
+}
+
+func (s *Server) listTimelogs(w http.ResponseWriter, r *http.Request) {
+	list, ok := listQueryOf(w, r)
+	if !ok {
+		return
+	}
+	var taskID int64
+	if r.PathValue("taskId") != "" {
+		task, ok := findByPathValue(w, r, "taskId", s.tasks)
+		if !ok {
+			return
+		}
+		taskID = task.ID
+	}
+	if r.PathValue("projectId") != "" {
+		project, ok := findByPathValue(w, r, "projectId", s.projects)
+		if !ok {
+			return
+		}
+		list.projectIDs = []int64{project.ID}
+	}
+
+	var items []projects.Timelog
+	for _, timelog := range sortedValues(s.timelogs) {
+		switch {
+		case taskID != 0 && (timelog.Task == nil || timelog.Task.ID != taskID),
+			!list.matchProject(timelog.Project.ID),
+			!list.matchSearch(timelog.Description),
+			!list.matchUpdated(timelog.UpdatedAt):
+			continue
+		}
+		items = append(items, timelog)
+	}
+	writeList(s, w, r, list, "timelogs", "timelogs", items)
+}
+
+// loggedAt combines the date and time of a timelog request.
+func loggedAt(date twapi.Date, clock twapi.Time) time.Time {
+	day, hour := time.Time(date), time.Time(clock)
+	return time.Date(day.Year(), day.Month(), day.Day(), hour.Hour(), hour.Minute(), hour.Second(), 0, time.UTC)
+}
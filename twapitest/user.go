@@ -0,0 +1,195 @@
+package twapitest
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/teamwork/twapi-go-sdk/projects"
+)
+
+// AddUser adds the user to the model, assigning an identifier when it has none,
+// and returns the stored user.
+func (s *Server) AddUser(user projects.User) projects.User {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if user.ID == 0 {
+		user.ID = s.newID()
+	}
+	now := s.timestamp()
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+	if user.UpdatedAt == nil {
+		user.UpdatedAt = &now
+	}
+	if user.Type == "" {
+		user.Type = "account"
+	}
+	s.users[user.ID] = user
+	return user
+}
+
+// User returns the user with the given identifier.
+func (s *Server) User(id int64) (projects.User, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	user, ok := s.users[id]
+	return user, ok
+}
+
+func (s *Server) registerUsers(mux *http.ServeMux) {
+	mux.HandleFunc("POST /people.json", s.createUser)
+	mux.HandleFunc("PUT /people/{id}", s.updateUser)
+	mux.HandleFunc("DELETE /people/{id}", s.deleteUser)
+	mux.HandleFunc("GET /projects/api/v3/people/{id}", s.getUser)
+	mux.HandleFunc("GET /projects/api/v3/me.json", s.getMe)
+	mux.HandleFunc("GET /projects/api/v3/people.json", s.listUsers)
+	mux.HandleFunc("GET /projects/api/v3/projects/{projectId}/people.json", s.listUsers)
+}
+
+func (s *Server) createUser(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		User projects.UserCreateRequest `json:"person"`
+	}
+	if !decodeBody(w, r, &payload) {
+		return
+	}
+	create := payload.User
+	if create.FirstName == "" || create.Email == "" {
+		writeError(w, http.StatusUnprocessableEntity, "first name and email are required")
+		return
+	}
+
+	now, me := s.touch()
+	user := projects.User{
+		ID:        s.newID(),
+		FirstName: create.FirstName,
+		LastName:  create.LastName,
+		Title:     create.Title,
+		Email:     create.Email,
+		Type:      "account",
+		CreatedBy: new(relationship(*me, "users")),
+		CreatedAt: *now,
+		UpdatedBy: new(relationship(*me, "users")),
+		UpdatedAt: now,
+	}
+	setIfPresent(&user.Admin, create.Admin)
+	setIfPresent(&user.Type, create.Type)
+	if create.CompanyID != nil {
+		user.Company = relationship(*create.CompanyID, "companies")
+	}
+	s.users[user.ID] = user
+
+	writeJSON(w, http.StatusCreated, map[string]any{"id": legacyID(user.ID)})
+}
+
+func (s *Server) updateUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := findByPath(w, r, s.users)
+	if !ok {
+		return
+	}
+	var payload struct {
+		User projects.UserUpdateRequest `json:"person"`
+	}
+	if !decodeBody(w, r, &payload) {
+		return
+	}
+
+	update := payload.User
+	setIfPresent(&user.FirstName, update.FirstName)
+	setIfPresent(&user.LastName, update.LastName)
+	if update.Title != nil {
+		user.Title = update.Title
+	}
+	setIfPresent(&user.Email, update.Email)
+	setIfPresent(&user.Admin, update.Admin)
+	setIfPresent(&user.Type, update.Type)
+	if update.CompanyID != nil {
+		user.Company = relationship(*update.CompanyID, "companies")
+	}
+	now, me := s.touch()
+	user.UpdatedAt, user.UpdatedBy = now, new(relationship(*me, "users"))
+	s.users[user.ID] = user
+
+	writeLegacyOK(w)
+}
+
+func (s *Server) deleteUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := findByPath(w, r, s.users)
+	if !ok {
+		return
+	}
+	if user.ID == s.me {
+		writeError(w, http.StatusForbidden, "the authenticated user can't be deleted")
+		return
+	}
+	delete(s.users, user.ID)
+	writeLegacyOK(w)
+}
+
+func (s *Server) getUser(w http.ResponseWriter, r *http.Request) {
+	user, ok := findByPath(w, r, s.users)
+	if !ok {
+		return
+	}
+	s.writeItem(w, r, http.StatusOK, "person", "person", user)
+}
+
+func (s *Server) getMe(w http.ResponseWriter, r *http.Request) {
+	s.writeItem(w, r, http.StatusOK, "person", "person", projects.UserMe{
+		User:      s.users[s.me],
+		SiteOwner: true,
+	})
+}
+
+func (s *Server) listUsers(w http.ResponseWriter, r *http.Request) {
+	list, ok := listQueryOf(w, r)
+	if !ok {
+		return
+	}
+	var members []int64
+	if r.PathValue("projectId") != "" {
+		project, ok := findByPathValue(w, r, "projectId", s.projects)
+		if !ok {
+			return
+		}
+		members = s.projectMembers(project)
+	}
+
+	var items []projects.User
+	for _, user := range sortedValues(s.users) {
+		if members != nil && !slices.Contains(members, user.ID) {
+			continue
+		}
+		if list.matchSearch(user.FirstName, user.LastName, user.Email) && list.matchUpdated(user.UpdatedAt) {
+			items = append(items, user)
+		}
+	}
+	writeList(s, w, r, list, "people", "people", items)
+}
+
+// projectMembers returns the users that take part in the project, which the
+// model approximates as its creator, its owner and the assignees of its tasks.
+// The caller must hold the mutex.
+func (s *Server) projectMembers(project projects.Project) []int64 {
+	members := []int64{}
+	if project.CreatedBy != nil {
+		members = append(members, *project.CreatedBy)
+	}
+	if project.Owner != nil {
+		members = append(members, project.Owner.ID)
+	}
+	for _, task := range s.tasks {
+		if s.tasklists[task.Tasklist.ID].Project.ID != project.ID {
+			continue
+		}
+		for _, assignee := range task.Assignees {
+			if assignee.Type == "users" {
+				members = append(members, assignee.ID)
+			}
+		}
+	}
+	return members
+}
@@ -14,25 +14,36 @@ import (
 
 var reHexColor = regexp.MustCompile(`^#([0-9a-f]{6})$`)
 
-// HTTPError represents an error response from the API.
+// HTTPError represents an error response from the API. It matches the
+// sentinel errors, such as ErrNotFound, through errors.Is according to its
+// status code.
 type HTTPError struct {
 	StatusCode int
 	Headers    http.Header
 	Message    string
-	Details    string
+
+	// Details is the raw response body.
+	Details string
+
+	// Errors contains the error details decoded from the response body, when
+	// the API reported them in a known format.
+	Errors []APIErrorDetail
 }
 
 // NewHTTPError creates a new HTTPError from an http.Response.
 func NewHTTPError(resp *http.Response, message string) *HTTPError {
 	body := "no response body"
+	var details []APIErrorDetail
 	if b, err := io.ReadAll(resp.Body); err == nil && len(b) > 0 {
 		body = string(b)
+		details = parseAPIErrorDetails(b)
 	}
 	return &HTTPError{
 		StatusCode: resp.StatusCode,
 		Headers:    resp.Header,
 		Message:    message,
 		Details:    body,
+		Errors:     details,
 	}
 }
 
@@ -41,6 +52,13 @@ func (e *HTTPError) Error() string {
 	return fmt.Sprintf("%s (%d): %s", e.Message, e.StatusCode, e.Details)
 }
 
+// Is reports whether the error matches the target sentinel error, according to
+// the status code returned by the API.
+func (e *HTTPError) Is(target error) bool {
+	sentinel, ok := statusErrors[e.StatusCode]
+	return ok && sentinel == target
+}
+
 // Relationship describes the relation between the main entity and a sideload type.
 type Relationship struct {
 	ID   int64          `json:"id"`
@@ -0,0 +1,297 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/teamwork/twapi-go-sdk/projects"
+)
+
+// Event is the name of a webhook event, as sent in HeaderEvent. It is the
+// type projects.WebhookCreateRequest subscribes with, so the same constants
+// serve to register a webhook and to receive it.
+type Event = projects.WebhookEvent
+
+// List of webhook events.
+const (
+	EventTaskCreated   = projects.WebhookEventTaskCreated
+	EventTaskUpdated   = projects.WebhookEventTaskUpdated
+	EventTaskDeleted   = projects.WebhookEventTaskDeleted
+	EventTaskCompleted = projects.WebhookEventTaskCompleted
+	EventTaskReopened  = projects.WebhookEventTaskReopened
+	EventTaskMoved     = projects.WebhookEventTaskMoved
+
+	EventTasklistCreated = projects.WebhookEventTasklistCreated
+	EventTasklistUpdated = projects.WebhookEventTasklistUpdated
+	EventTasklistDeleted = projects.WebhookEventTasklistDeleted
+
+	EventCommentCreated = projects.WebhookEventCommentCreated
+	EventCommentUpdated = projects.WebhookEventCommentUpdated
+	EventCommentDeleted = projects.WebhookEventCommentDeleted
+
+	EventTimelogCreated = projects.WebhookEventTimelogCreated
+	EventTimelogUpdated = projects.WebhookEventTimelogUpdated
+	EventTimelogDeleted = projects.WebhookEventTimelogDeleted
+
+	EventMilestoneCreated   = projects.WebhookEventMilestoneCreated
+	EventMilestoneUpdated   = projects.WebhookEventMilestoneUpdated
+	EventMilestoneDeleted   = projects.WebhookEventMilestoneDeleted
+	EventMilestoneCompleted = projects.WebhookEventMilestoneCompleted
+	EventMilestoneReopened  = projects.WebhookEventMilestoneReopened
+
+	EventMessageCreated = projects.WebhookEventMessageCreated
+	EventMessageUpdated = projects.WebhookEventMessageUpdated
+	EventMessageDeleted = projects.WebhookEventMessageDeleted
+
+	EventProjectCreated   = projects.WebhookEventProjectCreated
+	EventProjectUpdated   = projects.WebhookEventProjectUpdated
+	EventProjectDeleted   = projects.WebhookEventProjectDeleted
+	EventProjectCompleted = projects.WebhookEventProjectCompleted
+	EventProjectArchived  = projects.WebhookEventProjectArchived
+	EventProjectReopened  = projects.WebhookEventProjectReopened
+)
+
+// EventCreator is the user whose action triggered the event.
+type EventCreator struct {
+	// ID is the unique identifier of the user.
+	ID int64 `json:"id"`
+
+	// FirstName is the first name of the user.
+	FirstName string `json:"firstName"`
+
+	// LastName is the last name of the user.
+	LastName string `json:"lastName"`
+
+	// Avatar is the URL of the avatar of the user.
+	Avatar string `json:"avatar"`
+}
+
+// Envelope contains the fields common to every event payload.
+type Envelope struct {
+	// Event is the name of the event, taken from HeaderEvent.
+	Event Event `json:"-"`
+
+	// EventCreator is the user whose action triggered the event.
+	EventCreator EventCreator `json:"eventCreator"`
+
+	// Project is the project the item belongs to, when the event is about an
+	// item of a project.
+	Project *projects.Project `json:"project"`
+}
+
+func (e *Envelope) setEvent(event Event) {
+	e.Event = event
+}
+
+// RawEvent is an event with its payload left undecoded.
+type RawEvent struct {
+	// Event is the name of the event, taken from HeaderEvent.
+	Event Event
+
+	// Payload is the body of the delivery.
+	Payload json.RawMessage
+}
+
+// TaskEvent is the payload of the TASK.* events.
+type TaskEvent struct {
+	Envelope
+
+	// Task is the task the event is about.
+	Task projects.Task `json:"task"`
+
+	// Tasklist is the tasklist the task belongs to.
+	Tasklist *projects.Tasklist `json:"taskList"`
+}
+
+// TasklistEvent is the payload of the TASKLIST.* events.
+type TasklistEvent struct {
+	Envelope
+
+	// Tasklist is the tasklist the event is about.
+	Tasklist projects.Tasklist `json:"taskList"`
+}
+
+// CommentEvent is the payload of the COMMENT.* events.
+type CommentEvent struct {
+	Envelope
+
+	// Comment is the comment the event is about.
+	Comment projects.Comment `json:"comment"`
+}
+
+// TimelogEvent is the payload of the TIME.* events.
+type TimelogEvent struct {
+	Envelope
+
+	// Timelog is the timelog the event is about.
+	Timelog projects.Timelog `json:"time"`
+}
+
+// MilestoneEvent is the payload of the MILESTONE.* events.
+type MilestoneEvent struct {
+	Envelope
+
+	// Milestone is the milestone the event is about.
+	Milestone projects.Milestone `json:"milestone"`
+}
+
+// MessageEvent is the payload of the MESSAGE.* events.
+type MessageEvent struct {
+	Envelope
+
+	// Message is the message the event is about.
+	Message projects.Message `json:"message"`
+}
+
+// ProjectEvent is the payload of the PROJECT.* events. The project is stored
+// in the Project field of the envelope.
+type ProjectEvent struct {
+	Envelope
+}
+
+// OnTaskCreated registers the function called for EventTaskCreated.
+func (h *Handler) OnTaskCreated(fn func(ctx context.Context, event TaskEvent) error) {
+	on(h, EventTaskCreated, fn)
+}
+
+// OnTaskUpdated registers the function called for EventTaskUpdated.
+func (h *Handler) OnTaskUpdated(fn func(ctx context.Context, event TaskEvent) error) {
+	on(h, EventTaskUpdated, fn)
+}
+
+// OnTaskDeleted registers the function called for EventTaskDeleted.
+func (h *Handler) OnTaskDeleted(fn func(ctx context.Context, event TaskEvent) error) {
+	on(h, EventTaskDeleted, fn)
+}
+
+// OnTaskCompleted registers the function called for EventTaskCompleted.
+func (h *Handler) OnTaskCompleted(fn func(ctx context.Context, event TaskEvent) error) {
+	on(h, EventTaskCompleted, fn)
+}
+
+// OnTaskReopened registers the function called for EventTaskReopened.
+func (h *Handler) OnTaskReopened(fn func(ctx context.Context, event TaskEvent) error) {
+	on(h, EventTaskReopened, fn)
+}
+
+// OnTaskMoved registers the function called for EventTaskMoved.
+func (h *Handler) OnTaskMoved(fn func(ctx context.Context, event TaskEvent) error) {
+	on(h, EventTaskMoved, fn)
+}
+
+// OnTasklistCreated registers the function called for EventTasklistCreated.
+func (h *Handler) OnTasklistCreated(fn func(ctx context.Context, event TasklistEvent) error) {
+	on(h, EventTasklistCreated, fn)
+}
+
+// OnTasklistUpdated registers the function called for EventTasklistUpdated.
+func (h *Handler) OnTasklistUpdated(fn func(ctx context.Context, event TasklistEvent) error) {
+	on(h, EventTasklistUpdated, fn)
+}
+
+// OnTasklistDeleted registers the function called for EventTasklistDeleted.
+func (h *Handler) OnTasklistDeleted(fn func(ctx context.Context, event TasklistEvent) error) {
+	on(h, EventTasklistDeleted, fn)
+}
+
+// OnCommentCreated registers the function called for EventCommentCreated.
+func (h *Handler) OnCommentCreated(fn func(ctx context.Context, event CommentEvent) error) {
+	on(h, EventCommentCreated, fn)
+}
+
+// OnCommentUpdated registers the function called for EventCommentUpdated.
+func (h *Handler) OnCommentUpdated(fn func(ctx context.Context, event CommentEvent) error) {
+	on(h, EventCommentUpdated, fn)
+}
+
+// OnCommentDeleted registers the function called for EventCommentDeleted.
+func (h *Handler) OnCommentDeleted(fn func(ctx context.Context, event CommentEvent) error) {
+	on(h, EventCommentDeleted, fn)
+}
+
+// OnTimelogCreated registers the function called for EventTimelogCreated.
+func (h *Handler) OnTimelogCreated(fn func(ctx context.Context, event TimelogEvent) error) {
+	on(h, EventTimelogCreated, fn)
+}
+
+// OnTimelogUpdated registers the function called for EventTimelogUpdated.
+func (h *Handler) OnTimelogUpdated(fn func(ctx context.Context, event TimelogEvent) error) {
+	on(h, EventTimelogUpdated, fn)
+}
+
+// OnTimelogDeleted registers the function called for EventTimelogDeleted.
+func (h *Handler) OnTimelogDeleted(fn func(ctx context.Context, event TimelogEvent) error) {
+	on(h, EventTimelogDeleted, fn)
+}
+
+// OnMilestoneCreated registers the function called for EventMilestoneCreated.
+func (h *Handler) OnMilestoneCreated(fn func(ctx context.Context, event MilestoneEvent) error) {
+	on(h, EventMilestoneCreated, fn)
+}
+
+// OnMilestoneUpdated registers the function called for EventMilestoneUpdated.
+func (h *Handler) OnMilestoneUpdated(fn func(ctx context.Context, event MilestoneEvent) error) {
+	on(h, EventMilestoneUpdated, fn)
+}
+
+// OnMilestoneDeleted registers the function called for EventMilestoneDeleted.
+func (h *Handler) OnMilestoneDeleted(fn func(ctx context.Context, event MilestoneEvent) error) {
+	on(h, EventMilestoneDeleted, fn)
+}
+
+// OnMilestoneCompleted registers the function called for
+// EventMilestoneCompleted.
+func (h *Handler) OnMilestoneCompleted(fn func(ctx context.Context, event MilestoneEvent) error) {
+	on(h, EventMilestoneCompleted, fn)
+}
+
+// OnMilestoneReopened registers the function called for
+// EventMilestoneReopened.
+func (h *Handler) OnMilestoneReopened(fn func(ctx context.Context, event MilestoneEvent) error) {
+	on(h, EventMilestoneReopened, fn)
+}
+
+// OnMessageCreated registers the function called for EventMessageCreated.
+func (h *Handler) OnMessageCreated(fn func(ctx context.Context, event MessageEvent) error) {
+	on(h, EventMessageCreated, fn)
+}
+
+// OnMessageUpdated registers the function called for EventMessageUpdated.
+func (h *Handler) OnMessageUpdated(fn func(ctx context.Context, event MessageEvent) error) {
+	on(h, EventMessageUpdated, fn)
+}
+
+// OnMessageDeleted registers the function called for EventMessageDeleted.
+func (h *Handler) OnMessageDeleted(fn func(ctx context.Context, event MessageEvent) error) {
+	on(h, EventMessageDeleted, fn)
+}
+
+// OnProjectCreated registers the function called for EventProjectCreated.
+func (h *Handler) OnProjectCreated(fn func(ctx context.Context, event ProjectEvent) error) {
+	on(h, EventProjectCreated, fn)
+}
+
+// OnProjectUpdated registers the function called for EventProjectUpdated.
+func (h *Handler) OnProjectUpdated(fn func(ctx context.Context, event ProjectEvent) error) {
+	on(h, EventProjectUpdated, fn)
+}
+
+// OnProjectDeleted registers the function called for EventProjectDeleted.
+func (h *Handler) OnProjectDeleted(fn func(ctx context.Context, event ProjectEvent) error) {
+	on(h, EventProjectDeleted, fn)
+}
+
+// OnProjectCompleted registers the function called for EventProjectCompleted.
+func (h *Handler) OnProjectCompleted(fn func(ctx context.Context, event ProjectEvent) error) {
+	on(h, EventProjectCompleted, fn)
+}
+
+// OnProjectArchived registers the function called for EventProjectArchived.
+func (h *Handler) OnProjectArchived(fn func(ctx context.Context, event ProjectEvent) error) {
+	on(h, EventProjectArchived, fn)
+}
+
+// OnProjectReopened registers the function called for EventProjectReopened.
+func (h *Handler) OnProjectReopened(fn func(ctx context.Context, event ProjectEvent) error) {
+	on(h, EventProjectReopened, fn)
+}
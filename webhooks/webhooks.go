@@ -0,0 +1,313 @@
+// Package webhooks receives the webhooks Teamwork sends when something changes
+// in an installation.
+//
+// Handler is an http.Handler verifying that each delivery was signed with the
+// webhook token, rejecting deliveries already received, and dispatching the
+// event to the callback registered for it, with a payload decoded into the
+// types of the projects package:
+//
+//	handler := webhooks.NewHandler(token)
+//	handler.OnTaskCreated(func(ctx context.Context, event webhooks.TaskEvent) error {
+//		fmt.Println("task created:", event.Task.Name)
+//		return nil
+//	})
+//	http.Handle("/webhooks", handler)
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Headers set by Teamwork on every delivery.
+const (
+	// HeaderEvent is the name of the event, such as TASK.CREATED.
+	HeaderEvent = "X-Projects-Event"
+
+	// HeaderSignature is the hex-encoded HMAC-SHA256 of the body, keyed with the
+	// webhook token.
+	HeaderSignature = "X-Projects-Signature"
+)
+
+const (
+	defaultReplayWindow = 24 * time.Hour
+	defaultMaxBodySize  = 1 << 20
+)
+
+var (
+	// ErrInvalidSignature is returned by Verify when the signature is missing or
+	// does not match the body.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+
+	// ErrReplay indicates a delivery that was already received.
+	ErrReplay = errors.New("webhook delivery replayed")
+)
+
+// Sign returns the signature of the body for the webhook token, as sent in
+// HeaderSignature.
+func Sign(token string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks that the signature was computed from the body with the webhook
+// token, in constant time.
+func Verify(token string, body []byte, signature string) error {
+	decoded, err := hex.DecodeString(signature)
+	if err != nil || len(decoded) == 0 {
+		return ErrInvalidSignature
+	}
+	mac := hmac.New(sha256.New, []byte(token))
+	mac.Write(body)
+	if !hmac.Equal(decoded, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Option defines a function type that can modify the Handler configuration.
+type Option func(*Handler)
+
+// WithLogger sets the logger used to report rejected deliveries and failed
+// callbacks. By default, it uses slog.Default().
+func WithLogger(logger *slog.Logger) Option {
+	return func(h *Handler) {
+		h.logger = logger
+	}
+}
+
+// WithReplayWindow sets how long a delivery is remembered to reject its
+// replays. Teamwork does not sign a timestamp, so a replay is recognized by its
+// signature, which only changes with the body. Defaults to 24 hours.
+func WithReplayWindow(window time.Duration) Option {
+	return func(h *Handler) {
+		h.replayWindow = window
+	}
+}
+
+// WithMaxBodySize sets the largest body accepted, in bytes. Larger deliveries
+// are rejected with 413 Request Entity Too Large. Defaults to 1MiB.
+func WithMaxBodySize(size int64) Option {
+	return func(h *Handler) {
+		h.maxBodySize = size
+	}
+}
+
+// WithClock sets the function used to expire the deliveries remembered to
+// reject replays. By default, it uses time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(h *Handler) {
+		h.now = now
+	}
+}
+
+// callback decodes the payload of an event and calls the function registered
+// for it.
+type callback func(ctx context.Context, event Event, body []byte) error
+
+// Handler is an http.Handler receiving Teamwork webhooks. A delivery is
+// answered with:
+//
+//   - 401 Unauthorized when its signature is missing or invalid.
+//   - 409 Conflict when it was already received within the replay window.
+//   - 400 Bad Request when it does not name its event or its payload cannot be
+//     decoded.
+//   - 500 Internal Server Error when the callback failed, so Teamwork delivers
+//     it again later.
+//   - 204 No Content otherwise, including for the events without a callback.
+//
+// Callbacks are registered before the handler starts serving, and are called
+// with the context of the request.
+type Handler struct {
+	token        string
+	logger       *slog.Logger
+	replayWindow time.Duration
+	maxBodySize  int64
+	now          func() time.Time
+	callbacks    map[Event]callback
+
+	mutex    sync.Mutex
+	seen     map[string]time.Time
+	received []delivery
+}
+
+// delivery is a signature received at a time, queued in the order of
+// reception so the expired signatures are found without scanning them all.
+type delivery struct {
+	signature  string
+	receivedAt time.Time
+}
+
+// NewHandler creates a handler for the webhooks signed with the token.
+func NewHandler(token string, opts ...Option) *Handler {
+	h := &Handler{
+		token:        token,
+		logger:       slog.Default(),
+		replayWindow: defaultReplayWindow,
+		maxBodySize:  defaultMaxBodySize,
+		now:          time.Now,
+		callbacks:    make(map[Event]callback),
+		seen:         make(map[string]time.Time),
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// On registers the function called for the event with its raw payload, for
+// the events without a typed callback. It replaces any callback already
+// registered for the event.
+func (h *Handler) On(event Event, fn func(ctx context.Context, event RawEvent) error) {
+	h.callbacks[event] = func(ctx context.Context, event Event, body []byte) error {
+		return fn(ctx, RawEvent{Event: event, Payload: json.RawMessage(body)})
+	}
+}
+
+// on registers a typed callback for the event. The payload types embed
+// Envelope, which receives the name of the event.
+func on[T any, PT interface {
+	*T
+	setEvent(Event)
+}](h *Handler, event Event, fn func(ctx context.Context, payload T) error) {
+	h.callbacks[event] = func(ctx context.Context, event Event, body []byte) error {
+		var payload T
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return &payloadError{err: err}
+		}
+		PT(&payload).setEvent(event)
+		return fn(ctx, payload)
+	}
+}
+
+// payloadError is returned by a callback when the payload cannot be decoded.
+type payloadError struct {
+	err error
+}
+
+func (e *payloadError) Error() string {
+	return fmt.Sprintf("failed to decode webhook payload: %s", e.err)
+}
+
+func (e *payloadError) Unwrap() error {
+	return e.err
+}
+
+// ServeHTTP receives a delivery.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.maxBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.reject(w, r, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		h.reject(w, r, http.StatusBadRequest, fmt.Errorf("failed to read body: %w", err))
+		return
+	}
+
+	signature := strings.ToLower(r.Header.Get(HeaderSignature))
+	if err := Verify(h.token, body, signature); err != nil {
+		h.reject(w, r, http.StatusUnauthorized, err)
+		return
+	}
+	event := Event(r.Header.Get(HeaderEvent))
+	if event == "" {
+		h.reject(w, r, http.StatusBadRequest, errors.New("missing webhook event"))
+		return
+	}
+	if !h.claim(signature) {
+		h.reject(w, r, http.StatusConflict, ErrReplay)
+		return
+	}
+
+	callback, ok := h.callbacks[event]
+	if !ok {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err := callback(r.Context(), event, body); err != nil {
+		// the delivery will be sent again, which must not be taken for a replay
+		h.release(signature)
+
+		var payloadErr *payloadError
+		if errors.As(err, &payloadErr) {
+			h.reject(w, r, http.StatusBadRequest, err)
+			return
+		}
+		h.logger.Error("webhook callback failed",
+			slog.String("event", string(event)),
+			slog.String("error", err.Error()),
+		)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// reject answers a delivery that was not dispatched.
+func (h *Handler) reject(w http.ResponseWriter, r *http.Request, status int, err error) {
+	h.logger.Warn("webhook rejected",
+		slog.String("event", r.Header.Get(HeaderEvent)),
+		slog.Int("status", status),
+		slog.String("error", err.Error()),
+	)
+	http.Error(w, http.StatusText(status), status)
+}
+
+// claim records the signature of a delivery, reporting false when it was
+// already received within the replay window. Expired signatures are dropped
+// on the way, from the front of the queue of received deliveries.
+func (h *Handler) claim(signature string) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	now := h.now()
+	var expired int
+	for _, d := range h.received {
+		if now.Sub(d.receivedAt) < h.replayWindow {
+			break
+		}
+		// a released signature may have been received again since
+		if receivedAt, ok := h.seen[d.signature]; ok && receivedAt.Equal(d.receivedAt) {
+			delete(h.seen, d.signature)
+		}
+		expired++
+	}
+	clear(h.received[:expired])
+	h.received = h.received[expired:]
+
+	if _, ok := h.seen[signature]; ok {
+		return false
+	}
+	h.seen[signature] = now
+	h.received = append(h.received, delivery{signature: signature, receivedAt: now})
+	return true
+}
+
+// release forgets the signature of a delivery that failed. Its entry in the
+// queue is left to expire.
+func (h *Handler) release(signature string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	delete(h.seen, signature)
+}
@@ -0,0 +1,202 @@
+package webhooks_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/teamwork/twapi-go-sdk/webhooks"
+)
+
+const testToken = "secret"
+
+// deliver sends a delivery signed with the signature to the handler, returning
+// the status code of the response.
+func deliver(t *testing.T, handler http.Handler, event webhooks.Event, body, signature string) int {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", strings.NewReader(body))
+	req.Header.Set(webhooks.HeaderEvent, string(event))
+	req.Header.Set(webhooks.HeaderSignature, signature)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func newTestHandler(opts ...webhooks.Option) *webhooks.Handler {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return webhooks.NewHandler(testToken, append([]webhooks.Option{webhooks.WithLogger(logger)}, opts...)...)
+}
+
+func TestHandler(t *testing.T) {
+	handler := newTestHandler()
+
+	var tasks []webhooks.TaskEvent
+	handler.OnTaskCreated(func(_ context.Context, event webhooks.TaskEvent) error {
+		tasks = append(tasks, event)
+		return nil
+	})
+	var comments []webhooks.CommentEvent
+	handler.OnCommentCreated(func(_ context.Context, event webhooks.CommentEvent) error {
+		comments = append(comments, event)
+		return nil
+	})
+
+	taskBody := `{"eventCreator":{"id":7,"firstName":"Ada"},"project":{"id":1,"name":"Apollo"},` +
+		`"task":{"id":42,"name":"Countdown"},"taskList":{"id":3,"name":"Launch"}}`
+	if code := deliver(t, handler, webhooks.EventTaskCreated, taskBody, webhooks.Sign(testToken, []byte(taskBody))); code != http.StatusNoContent {
+		t.Fatalf("expected status 204 but got %d", code)
+	}
+	if len(tasks) != 1 {
+		t.Fatalf("expected 1 task event but got %d", len(tasks))
+	}
+	task := tasks[0]
+	if task.Event != webhooks.EventTaskCreated {
+		t.Errorf("expected event %q but got %q", webhooks.EventTaskCreated, task.Event)
+	}
+	if task.Task.ID != 42 || task.Task.Name != "Countdown" {
+		t.Errorf("unexpected task %+v", task.Task)
+	}
+	if task.Tasklist == nil || task.Tasklist.ID != 3 {
+		t.Errorf("unexpected tasklist %+v", task.Tasklist)
+	}
+	if task.Project == nil || task.Project.Name != "Apollo" {
+		t.Errorf("unexpected project %+v", task.Project)
+	}
+	if task.EventCreator.ID != 7 {
+		t.Errorf("unexpected event creator %+v", task.EventCreator)
+	}
+
+	commentBody := `{"comment":{"id":5,"body":"Go for launch"}}`
+	if code := deliver(t, handler, webhooks.EventCommentCreated, commentBody, webhooks.Sign(testToken, []byte(commentBody))); code != http.StatusNoContent {
+		t.Fatalf("expected status 204 but got %d", code)
+	}
+	if len(comments) != 1 || comments[0].Comment.Body != "Go for launch" {
+		t.Errorf("unexpected comment events %+v", comments)
+	}
+
+	// events without a callback are acknowledged
+	otherBody := `{"milestone":{"id":9}}`
+	if code := deliver(t, handler, webhooks.EventMilestoneCreated, otherBody, webhooks.Sign(testToken, []byte(otherBody))); code != http.StatusNoContent {
+		t.Errorf("expected status 204 for an event without callback but got %d", code)
+	}
+}
+
+func TestHandlerRejections(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	handler := newTestHandler(
+		webhooks.WithReplayWindow(time.Hour),
+		webhooks.WithMaxBodySize(64),
+		webhooks.WithClock(func() time.Time { return now }),
+	)
+	var calls int
+	handler.OnTaskUpdated(func(context.Context, webhooks.TaskEvent) error {
+		calls++
+		return nil
+	})
+
+	body := `{"task":{"id":1}}`
+	signature := webhooks.Sign(testToken, []byte(body))
+
+	tests := []struct {
+		name      string
+		event     webhooks.Event
+		body      string
+		signature string
+		want      int
+	}{{
+		name:      "it should reject a missing signature",
+		event:     webhooks.EventTaskUpdated,
+		body:      body,
+		signature: "",
+		want:      http.StatusUnauthorized,
+	}, {
+		name:      "it should reject a signature made with another token",
+		event:     webhooks.EventTaskUpdated,
+		body:      body,
+		signature: webhooks.Sign("other", []byte(body)),
+		want:      http.StatusUnauthorized,
+	}, {
+		name:      "it should reject a missing event",
+		event:     "",
+		body:      body,
+		signature: signature,
+		want:      http.StatusBadRequest,
+	}, {
+		name:      "it should accept a valid delivery",
+		event:     webhooks.EventTaskUpdated,
+		body:      body,
+		signature: strings.ToUpper(signature),
+		want:      http.StatusNoContent,
+	}, {
+		name:      "it should reject a replay",
+		event:     webhooks.EventTaskUpdated,
+		body:      body,
+		signature: signature,
+		want:      http.StatusConflict,
+	}, {
+		name:      "it should reject an invalid payload",
+		event:     webhooks.EventTaskUpdated,
+		body:      `{"task":[]}`,
+		signature: webhooks.Sign(testToken, []byte(`{"task":[]}`)),
+		want:      http.StatusBadRequest,
+	}, {
+		name:      "it should reject a body too large",
+		event:     webhooks.EventTaskUpdated,
+		body:      `{"task":{"name":"` + strings.Repeat("a", 64) + `"}}`,
+		signature: webhooks.Sign(testToken, []byte(`{"task":{"name":"`+strings.Repeat("a", 64)+`"}}`)),
+		want:      http.StatusRequestEntityTooLarge,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := deliver(t, handler, tt.event, tt.body, tt.signature); code != tt.want {
+				t.Errorf("expected status %d but got %d", tt.want, code)
+			}
+		})
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call but got %d", calls)
+	}
+
+	// once the replay window elapsed, the delivery is accepted again
+	now = now.Add(time.Hour)
+	if code := deliver(t, handler, webhooks.EventTaskUpdated, body, signature); code != http.StatusNoContent {
+		t.Errorf("expected status 204 after the replay window but got %d", code)
+	}
+	if code := deliver(t, handler, webhooks.EventTaskUpdated, body, signature); code != http.StatusConflict {
+		t.Errorf("expected status 409 for a replay of the delivery accepted again but got %d", code)
+	}
+}
+
+func TestHandlerCallbackError(t *testing.T) {
+	handler := newTestHandler()
+	fail := true
+	handler.On(webhooks.EventTaskMoved, func(_ context.Context, event webhooks.RawEvent) error {
+		if event.Event != webhooks.EventTaskMoved || len(event.Payload) == 0 {
+			t.Errorf("unexpected raw event %+v", event)
+		}
+		if fail {
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+
+	body := `{"task":{"id":1}}`
+	signature := webhooks.Sign(testToken, []byte(body))
+	if code := deliver(t, handler, webhooks.EventTaskMoved, body, signature); code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500 but got %d", code)
+	}
+
+	// the failed delivery is sent again, and must not be taken for a replay
+	fail = false
+	if code := deliver(t, handler, webhooks.EventTaskMoved, body, signature); code != http.StatusNoContent {
+		t.Errorf("expected status 204 for the redelivery but got %d", code)
+	}
+}